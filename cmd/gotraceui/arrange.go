@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"sort"
+
+	"github.com/joonho3020/gotraceui/clip"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/text"
+)
+
+// isFixedTimeline reports whether tl is the GC or STW timeline. These are always displayed at the top and cannot be
+// hidden, pinned or moved.
+func isFixedTimeline(tl *Timeline) bool {
+	switch tl.item.(type) {
+	case *GC, *STW:
+		return true
+	default:
+		return false
+	}
+}
+
+// timelineArrangeable reports whether the user can hide, pin and move tl.
+func timelineArrangeable(tl *Timeline) bool {
+	if isFixedTimeline(tl) {
+		return false
+	}
+	_, isGroup := tl.item.(*timelineGroup)
+	return !isGroup
+}
+
+// TimelinePinned reports whether tl is pinned to the top of the canvas.
+func (cv *Canvas) TimelinePinned(tl *Timeline) bool {
+	_, ok := cv.pinnedSet[tl]
+	return ok
+}
+
+// setPinnedTimelines replaces the pinned timelines without updating the displayed timelines.
+func (cv *Canvas) setPinnedTimelines(tls []*Timeline) {
+	cv.pinnedTimelines = tls
+	cv.pinnedSet = make(map[*Timeline]struct{}, len(tls))
+	for _, tl := range tls {
+		cv.pinnedSet[tl] = struct{}{}
+	}
+}
+
+// PinTimeline displays tl at the top of the canvas, below the GC and STW timelines and previously pinned timelines.
+func (cv *Canvas) PinTimeline(gtx layout.Context, tl *Timeline) {
+	if cv.TimelinePinned(tl) {
+		return
+	}
+	cv.keepTopTimeline(gtx, func() {
+		if cv.pinnedSet == nil {
+			cv.pinnedSet = map[*Timeline]struct{}{}
+		}
+		cv.pinnedTimelines = append(cv.pinnedTimelines, tl)
+		cv.pinnedSet[tl] = struct{}{}
+		cv.updateDisplayedTimelines()
+	})
+}
+
+// UnpinTimeline returns tl to its position among the unpinned timelines.
+func (cv *Canvas) UnpinTimeline(gtx layout.Context, tl *Timeline) {
+	if !cv.TimelinePinned(tl) {
+		return
+	}
+	cv.keepTopTimeline(gtx, func() {
+		for i, p := range cv.pinnedTimelines {
+			if p == tl {
+				cv.pinnedTimelines = append(cv.pinnedTimelines[:i:i], cv.pinnedTimelines[i+1:]...)
+				break
+			}
+		}
+		delete(cv.pinnedSet, tl)
+		cv.updateDisplayedTimelines()
+	})
+}
+
+// HideTimeline stops displaying tl until it gets unhidden.
+func (cv *Canvas) HideTimeline(gtx layout.Context, tl *Timeline) {
+	cv.keepTopTimeline(gtx, func() {
+		if cv.hiddenTimelines == nil {
+			cv.hiddenTimelines = map[*Timeline]struct{}{}
+		}
+		cv.hiddenTimelines[tl] = struct{}{}
+		cv.updateDisplayedTimelines()
+	})
+}
+
+// UnhideTimeline undoes the effect of HideTimeline.
+func (cv *Canvas) UnhideTimeline(gtx layout.Context, tl *Timeline) {
+	cv.keepTopTimeline(gtx, func() {
+		delete(cv.hiddenTimelines, tl)
+		cv.updateDisplayedTimelines()
+	})
+}
+
+// UnhideAllTimelines unhides all hidden timelines.
+func (cv *Canvas) UnhideAllTimelines(gtx layout.Context) {
+	cv.keepTopTimeline(gtx, func() {
+		clear(cv.hiddenTimelines)
+		cv.updateDisplayedTimelines()
+	})
+}
+
+// HiddenTimelines returns the hidden timelines, in their display order.
+func (cv *Canvas) HiddenTimelines() []*Timeline {
+	if len(cv.hiddenTimelines) == 0 {
+		return nil
+	}
+	out := make([]*Timeline, 0, len(cv.hiddenTimelines))
+	for _, tl := range cv.allTimelines {
+		if _, ok := cv.hiddenTimelines[tl]; ok {
+			out = append(out, tl)
+		}
+	}
+	return out
+}
+
+// timelineContextMenu returns the context menu items for arranging tl.
+func (cv *Canvas) timelineContextMenu(tl *Timeline) []*theme.MenuItem {
+	if !timelineArrangeable(tl) {
+		return nil
+	}
+	pin := &theme.MenuItem{
+		Label: PlainLabel("Pin timeline to top"),
+		Action: func() theme.Action {
+			return &CanvasPinTimelineAction{Timeline: tl}
+		},
+	}
+	if cv.TimelinePinned(tl) {
+		pin = &theme.MenuItem{
+			Label: PlainLabel("Unpin timeline"),
+			Action: func() theme.Action {
+				return &CanvasUnpinTimelineAction{Timeline: tl}
+			},
+		}
+	}
+	return []*theme.MenuItem{
+		pin,
+		{
+			Label: PlainLabel("Hide timeline"),
+			Action: func() theme.Action {
+				return &CanvasHideTimelineAction{Timeline: tl}
+			},
+		},
+	}
+}
+
+// moveBefore returns a copy of tls in which tl has been moved in front of, or behind, anchor.
+func moveBefore(tls []*Timeline, tl, anchor *Timeline, behind bool) []*Timeline {
+	out := make([]*Timeline, 0, len(tls))
+	for _, other := range tls {
+		if other == tl {
+			continue
+		}
+		if other == anchor && !behind {
+			out = append(out, tl)
+		}
+		out = append(out, other)
+		if other == anchor && behind {
+			out = append(out, tl)
+		}
+	}
+	return out
+}
+
+// moveTimeline moves tl to the position of the displayed timeline at index idx, or to the end if idx is
+// len(cv.timelines). Pinned timelines can only be moved among pinned timelines, and unpinned timelines among unpinned
+// ones.
+func (cv *Canvas) moveTimeline(tl *Timeline, idx int) {
+	pinned := cv.TimelinePinned(tl)
+	candidate := func(other *Timeline) bool {
+		return other != tl && timelineArrangeable(other) && cv.TimelinePinned(other) == pinned
+	}
+
+	var (
+		anchor *Timeline
+		behind bool
+	)
+	for _, other := range cv.timelines[idx:] {
+		if candidate(other) {
+			anchor = other
+			break
+		}
+	}
+	if anchor == nil {
+		for i := idx - 1; i >= 0; i-- {
+			if candidate(cv.timelines[i]) {
+				anchor = cv.timelines[i]
+				behind = true
+				break
+			}
+		}
+	}
+	if anchor == nil {
+		return
+	}
+
+	if pinned {
+		cv.pinnedTimelines = moveBefore(cv.pinnedTimelines, tl, anchor, behind)
+	} else {
+		cv.allTimelines = moveBefore(cv.allTimelines, tl, anchor, behind)
+	}
+	cv.updateDisplayedTimelines()
+}
+
+// timelineDropIndex returns the index of the displayed timeline in front of which a timeline dropped at the vertical
+// position y would be inserted.
+func (cv *Canvas) timelineDropIndex(gtx layout.Context, y float32) int {
+	absY := cv.denormalizeY(gtx, cv.y) + int(y)
+	i := sort.Search(len(cv.timelineEnds), func(i int) bool {
+		return cv.timelineEnds[i] > absY
+	})
+	if i == len(cv.timelineEnds) {
+		return i
+	}
+	start := 0
+	if i > 0 {
+		start = cv.timelineEnds[i-1]
+	}
+	if absY-start > (cv.timelineEnds[i]-start)/2 {
+		i++
+	}
+	return i
+}
+
+// drawTimelineDropIndicator draws a line where the timeline that is being dragged would be inserted.
+func (cv *Canvas) drawTimelineDropIndicator(win *theme.Window, gtx layout.Context) {
+	if !cv.timelineDrag.active || len(cv.timelineEnds) != len(cv.timelines) {
+		return
+	}
+	idx := cv.timelineDropIndex(gtx, cv.timelineDrag.y)
+	y := -cv.denormalizeY(gtx, cv.y)
+	if idx > 0 {
+		y += cv.timelineEnds[idx-1]
+	}
+	rect := clip.Rect{
+		Min: image.Pt(0, y-gtx.Dp(1)),
+		Max: image.Pt(gtx.Constraints.Max.X, y+gtx.Dp(1)),
+	}
+	theme.FillShape(win, gtx.Ops, win.Theme.Palette.NavigationLink, rect.Op())
+}
+
+// HiddenTimelinesComponent lists hidden timelines and allows restoring them.
+type HiddenTimelinesComponent struct {
+	canvas *Canvas
+
+	restoreAll widget.PrimaryClickable
+	restore    map[*Timeline]*widget.Clickable
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewHiddenTimelinesComponent(cv *Canvas) *HiddenTimelinesComponent {
+	return &HiddenTimelinesComponent{
+		canvas:  cv,
+		restore: map[*Timeline]*widget.Clickable{},
+	}
+}
+
+// Title implements theme.Component.
+func (*HiddenTimelinesComponent) Title() string {
+	return "Hidden timelines"
+}
+
+// Transition implements theme.Component.
+func (*HiddenTimelinesComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*HiddenTimelinesComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (hc *HiddenTimelinesComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if hc.table != nil {
+		return
+	}
+	hc.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Timeline", Alignment: text.Start},
+		{Name: "Canvas", Alignment: text.Start},
+	}
+	hc.table.SetColumns(win, gtx, cols)
+}
+
+// Layout implements theme.Component.
+func (hc *HiddenTimelinesComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.HiddenTimelinesComponent.Layout").End()
+
+	hc.initTable(win, gtx)
+	hc.table.Update(gtx)
+	hc.cellFormatter.Update(win, gtx)
+
+	for hc.restoreAll.Clicked(gtx) {
+		hc.canvas.UnhideAllTimelines(gtx)
+	}
+	for tl, click := range hc.restore {
+		for {
+			if _, ok := click.Clicked(gtx); !ok {
+				break
+			}
+			hc.canvas.UnhideTimeline(gtx, tl)
+		}
+	}
+
+	tls := hc.canvas.HiddenTimelines()
+	for tl := range hc.restore {
+		if _, ok := hc.canvas.hiddenTimelines[tl]; !ok {
+			delete(hc.restore, tl)
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		tl := tls[row]
+		switch colName := hc.table.Columns[col].Name; colName {
+		case "Timeline":
+			return hc.cellFormatter.Text(win, gtx, tl.label)
+		case "Canvas":
+			click, ok := hc.restore[tl]
+			if !ok {
+				click = &widget.Clickable{}
+				hc.restore[tl] = click
+			}
+			return click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, "Restore", win.ColorMaterial(gtx, win.Theme.Palette.Link))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Dumb(win, theme.Button(win.Theme, &hc.restoreAll.Clickable, "Restore all").Layout)(gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.SimpleTable(win, gtx, hc.table, &hc.scrollState, len(tls), cellFn)
+		},
+	)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+type gcAssistRow struct {
+	expanded bool
+	toggle   widget.Clickable
+
+	text      Text
+	prevSpans []TextSpan
+}
+
+// gcAssistTable displays GC assist groups, grouped either by function or by stack.
+type gcAssistTable struct {
+	byStack bool
+
+	groups SortedIndices[ptrace.GCAssistGroup, []ptrace.GCAssistGroup]
+	// Per-row state, indexed by the index into groups.Items.
+	rows []gcAssistRow
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func (at *gcAssistTable) reset(groups []ptrace.GCAssistGroup) {
+	at.groups.Reset(groups)
+	at.rows = make([]gcAssistRow, len(groups))
+}
+
+func (at *gcAssistTable) label(tr *Trace, group *ptrace.GCAssistGroup) string {
+	if at.byStack {
+		if fn := blockingFunction(tr, group.Stack); fn != "" {
+			return fn
+		}
+		return "unknown"
+	}
+	if group.Function == nil || group.Function.Fn == "" {
+		return "unknown"
+	}
+	return group.Function.Fn
+}
+
+func (at *gcAssistTable) initTable(win *theme.Window, gtx layout.Context) {
+	if at.table != nil {
+		return
+	}
+	first := "Function"
+	if at.byStack {
+		first = "Assisted in"
+	}
+	at.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: first, Alignment: text.Start, Clickable: true},
+		{Name: "Count", Alignment: text.End, Clickable: true},
+		{Name: "Mark assist", Alignment: text.End, Clickable: true},
+		{Name: "Blocked on GC", Alignment: text.End, Clickable: true},
+		{Name: "Total", Alignment: text.End, Clickable: true},
+	}
+	at.table.SetColumns(win, gtx, cols)
+	// ComputeGCAssists sorts groups by total time.
+	at.table.SortedBy = 4
+	at.table.SortOrder = theme.SortDescending
+}
+
+func (at *gcAssistTable) sort(tr *Trace) {
+	desc := at.table.SortOrder == theme.SortDescending
+	switch at.table.Columns[at.table.SortedBy].Name {
+	case "Function", "Assisted in":
+		at.groups.Sort(func(a, b ptrace.GCAssistGroup) int {
+			return cmp(at.label(tr, &a), at.label(tr, &b), desc)
+		})
+	case "Count":
+		at.groups.Sort(func(a, b ptrace.GCAssistGroup) int {
+			return cmp(a.Count, b.Count, desc)
+		})
+	case "Mark assist":
+		at.groups.Sort(func(a, b ptrace.GCAssistGroup) int {
+			return cmp(a.Assist, b.Assist, desc)
+		})
+	case "Blocked on GC":
+		at.groups.Sort(func(a, b ptrace.GCAssistGroup) int {
+			return cmp(a.BlockedGC, b.BlockedGC, desc)
+		})
+	case "Total":
+		at.groups.Sort(func(a, b ptrace.GCAssistGroup) int {
+			return cmp(a.Total(), b.Total(), desc)
+		})
+	}
+}
+
+func (at *gcAssistTable) Layout(win *theme.Window, gtx layout.Context, tr *Trace) layout.Dimensions {
+	at.initTable(win, gtx)
+	at.table.Update(gtx)
+	if _, ok := at.table.SortByClickedColumn(); ok {
+		at.sort(tr)
+	}
+
+	at.cellFormatter.Update(win, gtx)
+	for i := range at.rows {
+		row := &at.rows[i]
+		for {
+			if _, ok := row.toggle.Clicked(gtx); !ok {
+				break
+			}
+			row.expanded = !row.expanded
+		}
+		if row.expanded {
+			for _, ev := range row.text.Update(gtx, row.prevSpans) {
+				handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+			}
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, rowIdx, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := at.groups.Ptr(rowIdx)
+		row := &at.rows[at.groups.Order[rowIdx]]
+		switch colName := at.table.Columns[col].Name; colName {
+		case "Function":
+			if group.Function == nil {
+				return at.cellFormatter.Text(win, gtx, at.label(tr, group))
+			}
+			return at.cellFormatter.Function(win, gtx, group.Function)
+		case "Assisted in":
+			return row.toggle.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				indicator := "▶ "
+				if row.expanded {
+					indicator = "▼ "
+				}
+				return at.cellFormatter.Text(win, gtx, indicator+at.label(tr, group))
+			})
+		case "Count":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return at.cellFormatter.Number(win, gtx, group.Count)
+			})
+		case "Mark assist":
+			return at.cellFormatter.Duration(win, gtx, group.Assist, false)
+		case "Blocked on GC":
+			return at.cellFormatter.Duration(win, gtx, group.BlockedGC, false)
+		case "Total":
+			return at.cellFormatter.Duration(win, gtx, group.Total(), false)
+		default:
+			panic(colName)
+		}
+	}
+
+	rowFn := func(win *theme.Window, gtx layout.Context, rowIdx int) layout.Dimensions {
+		row := &at.rows[at.groups.Order[rowIdx]]
+		if !row.expanded {
+			return theme.TableSimpleRow(at.table).Layout(win, gtx, rowIdx, cellFn)
+		}
+
+		return layout.Rigids(gtx, layout.Vertical,
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableSimpleRow(at.table).Layout(win, gtx, rowIdx, cellFn)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableExpandedRow(at.table).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(5).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						group := at.groups.Ptr(rowIdx)
+						tb := TextBuilder{Window: win}
+						if group.Stack == 0 {
+							tb.Bold("Stack: ")
+							tb.Span("unknown")
+						} else {
+							tb.Bold("Stack:\n")
+							tb.Span(formatStack(tr, group.Stack))
+						}
+						row.text.Reset(win.Theme)
+						row.prevSpans = tb.Spans
+						return row.text.Layout(win, gtx, row.prevSpans)
+					})
+				})
+			},
+		)
+	}
+
+	gtx.Constraints.Min = gtx.Constraints.Max
+	return theme.FairlySimpleTable(win, gtx, at.table, &at.scrollState, at.groups.Len(), rowFn)
+}
+
+// GCAssistsComponent ranks functions and stacks by the time they spent assisting the GC or being blocked on it,
+// either over the whole trace or during a single GC cycle.
+type GCAssistsComponent struct {
+	trace *Trace
+	// The cycle the report is limited to, or nil for the whole trace.
+	cycle *ptrace.GCCycle
+
+	byFunction gcAssistTable
+	byStack    gcAssistTable
+}
+
+func NewGCAssistsComponent(tr *Trace, cycle *ptrace.GCCycle) *GCAssistsComponent {
+	start, end := trace.Timestamp(0), tr.End()
+	if cycle != nil {
+		start, end = cycle.Start, cycle.End
+	}
+	byFunction, byStack := ptrace.ComputeGCAssists(tr.Trace, start, end)
+
+	ac := &GCAssistsComponent{
+		trace:   tr,
+		cycle:   cycle,
+		byStack: gcAssistTable{byStack: true},
+	}
+	ac.byFunction.reset(byFunction)
+	ac.byStack.reset(byStack)
+	return ac
+}
+
+// Title implements theme.Component.
+func (ac *GCAssistsComponent) Title() string {
+	if ac.cycle != nil {
+		return local.Sprintf("Mark assists (GC cycle %d)", ac.cycle.Index+1)
+	}
+	return "Mark assists"
+}
+
+// Transition implements theme.Component.
+func (*GCAssistsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*GCAssistsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+// Layout implements theme.Component.
+func (ac *GCAssistsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.GCAssistsComponent.Layout").End()
+
+	if ac.byFunction.groups.Len() == 0 {
+		return theme.Label(win.Theme, "No goroutines assisted the GC or were blocked on it.").Layout(win, gtx)
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return ac.byFunction.Layout(win, gtx, ac.trace)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return ac.byStack.Layout(win, gtx, ac.trace)
+		}),
+	)
+}
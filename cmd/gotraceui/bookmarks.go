@@ -0,0 +1,623 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+	rtrace "runtime/trace"
+	"sort"
+	"time"
+
+	"github.com/joonho3020/gotraceui/clip"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/op"
+)
+
+// Bookmark is a named marker on a timestamp or on a span.
+type Bookmark struct {
+	Name string `json:"name"`
+	// Start is the bookmarked timestamp, or the start of the bookmarked span.
+	Start trace.Timestamp `json:"start"`
+	// End is the end of the bookmarked span. It is zero for bookmarks of timestamps.
+	End trace.Timestamp `json:"end,omitempty"`
+	// Timeline identifies the timeline of the bookmarked span, see timelineKey. It is empty for bookmarks of
+	// timestamps.
+	Timeline string `json:"timeline,omitempty"`
+}
+
+// IsSpan reports whether the bookmark is attached to a span.
+func (b Bookmark) IsSpan() bool {
+	return b.Timeline != ""
+}
+
+// timelineKey returns a string that identifies the timeline of item across sessions, or the empty string if the
+// timeline cannot be identified.
+func timelineKey(item any) string {
+	switch item := item.(type) {
+	case *ptrace.Goroutine:
+		return fmt.Sprintf("goroutine:%d", item.ID)
+	case *ptrace.Processor:
+		return fmt.Sprintf("processor:%d", item.ID)
+	case *ptrace.Machine:
+		return fmt.Sprintf("machine:%d", item.ID)
+	case *GC:
+		return "gc"
+	case *STW:
+		return "stw"
+	default:
+		return ""
+	}
+}
+
+// bookmarksFile is the on-disk representation of a trace's bookmarks.
+type bookmarksFile struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// bookmarksPath returns the path of the file that stores the bookmarks of the trace at tracePath. Bookmarks are stored
+// alongside the trace so that they are shared by everyone who has access to the trace.
+func bookmarksPath(tracePath string) string {
+	return tracePath + ".bookmarks.json"
+}
+
+// LoadBookmarks loads the bookmarks of the trace at tracePath. It returns no bookmarks and no error if there are no
+// stored bookmarks.
+func LoadBookmarks(tracePath string) ([]Bookmark, error) {
+	b, err := os.ReadFile(bookmarksPath(tracePath))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var f bookmarksFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	sortBookmarks(f.Bookmarks)
+	return f.Bookmarks, nil
+}
+
+// SaveBookmarks stores the bookmarks of the trace at tracePath. The bookmarks file is removed if there are no
+// bookmarks.
+func SaveBookmarks(tracePath string, bms []Bookmark) error {
+	path := bookmarksPath(tracePath)
+	if len(bms) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	b, err := json.MarshalIndent(bookmarksFile{Bookmarks: bms}, "", "\t")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so that we never leave behind partially written bookmarks.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func sortBookmarks(bms []Bookmark) {
+	sort.SliceStable(bms, func(i, j int) bool {
+		return bms[i].Start < bms[j].Start
+	})
+}
+
+// Bookmarks are the bookmarks of the currently loaded trace, sorted by timestamp.
+type Bookmarks struct {
+	// The path of the trace file. Bookmarks aren't persisted if it is empty, which happens when the trace wasn't
+	// loaded from a file.
+	tracePath string
+	items     []Bookmark
+	// gen is incremented on every change, which allows users to cache derived data.
+	gen int
+
+	// The timelines of the bookmarks, as of resolvedGen.
+	timelines   []*Timeline
+	resolvedGen int
+}
+
+func (bs *Bookmarks) Len() int {
+	return len(bs.items)
+}
+
+func (bs *Bookmarks) At(i int) Bookmark {
+	return bs.items[i]
+}
+
+func (bs *Bookmarks) Add(b Bookmark) error {
+	bs.items = append(bs.items, b)
+	sortBookmarks(bs.items)
+	return bs.changed()
+}
+
+func (bs *Bookmarks) Remove(i int) error {
+	bs.items = append(bs.items[:i], bs.items[i+1:]...)
+	return bs.changed()
+}
+
+func (bs *Bookmarks) Rename(i int, name string) error {
+	bs.items[i].Name = name
+	return bs.changed()
+}
+
+func (bs *Bookmarks) changed() error {
+	bs.gen++
+	if bs.tracePath == "" {
+		return nil
+	}
+	return SaveBookmarks(bs.tracePath, bs.items)
+}
+
+// timelinesOf returns the timelines of the bookmarks, with nil entries for bookmarks of timestamps and for bookmarks
+// whose timelines don't exist.
+func (bs *Bookmarks) timelinesOf(cv *Canvas) []*Timeline {
+	if bs.resolvedGen == bs.gen && len(bs.timelines) == len(bs.items) {
+		return bs.timelines
+	}
+
+	keys := map[string]struct{}{}
+	for i := range bs.items {
+		if bs.items[i].IsSpan() {
+			keys[bs.items[i].Timeline] = struct{}{}
+		}
+	}
+	bs.timelines = make([]*Timeline, len(bs.items))
+	if len(keys) != 0 {
+		byKey := map[string]*Timeline{}
+		for _, tl := range cv.allTimelines {
+			key := timelineKey(tl.item)
+			if _, ok := keys[key]; ok {
+				byKey[key] = tl
+			}
+		}
+		for i := range bs.items {
+			bs.timelines[i] = byKey[bs.items[i].Timeline]
+		}
+	}
+	bs.resolvedGen = bs.gen
+	return bs.timelines
+}
+
+func (cv *Canvas) addBookmark(win *theme.Window, gtx layout.Context, b Bookmark) {
+	if err := cv.bookmarks.Add(b); err != nil {
+		win.ShowNotification(gtx, fmt.Sprintf("Couldn't save bookmarks: %s", err))
+	}
+}
+
+// scrollToBookmark scrolls to the bookmark's timestamp and, for bookmarks of spans, to the span's timeline.
+func (cv *Canvas) scrollToBookmark(gtx layout.Context, i int) {
+	b := cv.bookmarks.At(i)
+	y := cv.y
+	if tl := cv.bookmarks.timelinesOf(cv)[i]; tl != nil {
+		cv.ensureObjectDisplayed(gtx, tl.item)
+		y = cv.timelineY(gtx, tl)
+	}
+	cv.scrollToTimestamp(gtx, b.Start, y)
+}
+
+// drawBookmarks draws bookmarks of timestamps as lines across all timelines and outlines bookmarked spans.
+func (cv *Canvas) drawBookmarks(win *theme.Window, gtx layout.Context) {
+	if cv.bookmarks.Len() == 0 {
+		return
+	}
+
+	c := colors[colorBookmark]
+	width := float32(gtx.Constraints.Max.X)
+	lineWidth := float32(gtx.Dp(1))
+	tls := cv.bookmarks.timelinesOf(cv)
+
+	// Find the vertical extents of every displayed timeline that has a bookmarked span.
+	type extent struct {
+		start, end int
+		displayed  bool
+	}
+	var extents map[*Timeline]extent
+	for _, tl := range tls {
+		if tl != nil {
+			if extents == nil {
+				extents = map[*Timeline]extent{}
+			}
+			extents[tl] = extent{}
+		}
+	}
+	if extents != nil {
+		cvy := cv.denormalizeY(gtx, cv.y)
+		for i, tl := range cv.timelines {
+			if _, ok := extents[tl]; !ok {
+				continue
+			}
+			start := 0
+			if i > 0 {
+				start = cv.timelineEnds[i-1]
+			}
+			extents[tl] = extent{start - cvy, cv.timelineEnds[i] - cvy, true}
+		}
+	}
+
+	drawLabel := func(name string, pt image.Point) {
+		rec := theme.Record(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			l := theme.LineLabel(win.Theme, name)
+			l.Font = font.Font{Weight: font.Bold}
+			return l.Layout(win, gtx)
+		})
+		defer op.Offset(pt).Push(gtx.Ops).Pop()
+		theme.FillShape(win, gtx.Ops, win.Theme.Palette.Background, clip.Rect{Max: rec.Dimensions.Size}.Op())
+		rec.Layout(win, gtx)
+	}
+
+	for i := 0; i < cv.bookmarks.Len(); i++ {
+		b := cv.bookmarks.At(i)
+		x0 := cv.tsToPx(b.Start)
+		if !b.IsSpan() {
+			if x0 < -lineWidth || x0 > width+lineWidth {
+				continue
+			}
+			rect := clip.FRect{
+				Min: f32.Pt(x0-lineWidth/2, 0),
+				Max: f32.Pt(x0+lineWidth/2, float32(gtx.Constraints.Max.Y)),
+			}
+			theme.FillShape(win, gtx.Ops, c, rect.Op(gtx.Ops))
+			drawLabel(b.Name, image.Pt(int(round32(x0))+gtx.Dp(2), 0))
+			continue
+		}
+
+		tl := tls[i]
+		if tl == nil {
+			continue
+		}
+		ext := extents[tl]
+		if !ext.displayed || ext.end < 0 || ext.start > gtx.Constraints.Max.Y {
+			continue
+		}
+		x1 := max(cv.tsToPx(b.End), x0+lineWidth)
+		if x1 < 0 || x0 > width {
+			continue
+		}
+		rect := clip.FRect{
+			Min: f32.Pt(max(x0, -lineWidth), float32(ext.start)),
+			Max: f32.Pt(min(x1, width+lineWidth), float32(ext.end)),
+		}
+		theme.FillShape(win, gtx.Ops, c, clip.Stroke{Path: rect.Path(gtx.Ops), Width: float32(gtx.Dp(2))}.Op())
+		drawLabel(b.Name, image.Pt(int(round32(max(x0, 0)))+gtx.Dp(2), ext.start))
+	}
+}
+
+// drawBookmarks marks the timestamps of bookmarks on the axis.
+func (axis *Axis) drawBookmarks(win *theme.Window, gtx layout.Context, height float32) {
+	bs := &axis.cv.bookmarks
+	if bs.Len() == 0 {
+		return
+	}
+	width := float32(gtx.Constraints.Max.X)
+	half := height / 2
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	for i := 0; i < bs.Len(); i++ {
+		x := axis.cv.tsToPx(bs.At(i).Start)
+		if x < -half || x > width+half {
+			continue
+		}
+		// Draw a triangle pointing at the timestamp.
+		p.MoveTo(f32.Pt(x-half, 0))
+		p.LineTo(f32.Pt(x+half, 0))
+		p.LineTo(f32.Pt(x, height))
+		p.Close()
+	}
+	theme.FillShape(win, gtx.Ops, colors[colorBookmark], clip.Outline{Path: p.End()}.Op())
+}
+
+// displayBookmarkDialog displays a dialog for naming a new bookmark, or for renaming the bookmark at index if index
+// isn't negative.
+func displayBookmarkDialog(win *theme.Window, cv *Canvas, b Bookmark, index int) {
+	var (
+		editor widget.Editor
+		save   widget.PrimaryClickable
+		cancel widget.PrimaryClickable
+	)
+	editor.SingleLine = true
+	editor.Submit = true
+	editor.SetText(b.Name)
+	editor.SetCaret(editor.Len(), 0)
+	focused := false
+
+	title := "Add bookmark"
+	if index >= 0 {
+		title = "Rename bookmark"
+	}
+
+	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		if !focused {
+			editor.Focus()
+			focused = true
+		}
+
+		submitted := false
+		for _, ev := range editor.Events() {
+			if _, ok := ev.(widget.SubmitEvent); ok {
+				submitted = true
+			}
+		}
+		for save.Clicked(gtx) {
+			submitted = true
+		}
+		for cancel.Clicked(gtx) {
+			win.CloseModal()
+			return layout.Dimensions{}
+		}
+		if submitted && editor.Text() != "" {
+			b.Name = editor.Text()
+			if index >= 0 {
+				// The bookmark may have been removed while the dialog was open.
+				if index >= cv.bookmarks.Len() {
+					win.CloseModal()
+					return layout.Dimensions{}
+				}
+				if err := cv.bookmarks.Rename(index, b.Name); err != nil {
+					win.ShowNotification(gtx, fmt.Sprintf("Couldn't save bookmarks: %s", err))
+				}
+			} else {
+				cv.addBookmark(win, gtx, b)
+			}
+			win.CloseModal()
+			return layout.Dimensions{}
+		}
+
+		return theme.Dialog(win.Theme, title).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Constraints.Constrain(image.Pt(500, 0)).X
+			gtx.Constraints.Max.X = gtx.Constraints.Min.X
+			return layout.Rigids(gtx, layout.Vertical,
+				func(gtx layout.Context) layout.Dimensions {
+					tb := TextBuilder{Window: win}
+					if b.IsSpan() {
+						tb.Span(local.Sprintf("Span from %s to %s", formatTimestamp(nil, b.Start), formatTimestamp(nil, b.End)))
+					} else {
+						tb.Span(local.Sprintf("Timestamp %s", formatTimestamp(nil, b.Start)))
+					}
+					var txt Text
+					txt.Reset(win.Theme)
+					return txt.Layout(win, gtx, tb.Spans)
+				},
+				layout.Spacer{Height: 5}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return theme.TextBox(win.Theme, &editor, "Name").Layout(win, gtx)
+				},
+				layout.Spacer{Height: 10}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					return layout.Rigids(gtx, layout.Horizontal,
+						func(gtx layout.Context) layout.Dimensions {
+							if editor.Text() == "" {
+								gtx.Queue = nil
+							}
+							return theme.Button(win.Theme, &save.Clickable, "Save").Layout(win, gtx)
+						},
+						layout.Spacer{Width: 5}.Layout,
+						func(gtx layout.Context) layout.Dimensions {
+							return theme.Button(win.Theme, &cancel.Clickable, "Cancel").Layout(win, gtx)
+						},
+					)
+				},
+			)
+		})
+	})
+}
+
+// newBookmarkSpanMenuItem returns a context menu item for bookmarking a span, or nil if the span cannot be bookmarked.
+func newBookmarkSpanMenuItem(cv *Canvas, spans Items[ptrace.Span]) *theme.MenuItem {
+	if spans.Len() != 1 {
+		return nil
+	}
+	c, ok := spans.Container()
+	if !ok || c.Timeline == nil {
+		return nil
+	}
+	key := timelineKey(c.Timeline.item)
+	if key == "" {
+		return nil
+	}
+	s := spans.AtPtr(0)
+	return &theme.MenuItem{
+		Label: PlainLabel("Add bookmark…"),
+		Action: func() theme.Action {
+			return &OpenAddBookmarkAction{
+				Bookmark: Bookmark{
+					Start:    s.Start,
+					End:      s.End,
+					Timeline: key,
+				},
+			}
+		},
+	}
+}
+
+type bookmarkRow struct {
+	text      Text
+	prevSpans []TextSpan
+	goTo      widget.PrimaryClickable
+	rename    widget.PrimaryClickable
+	remove    widget.PrimaryClickable
+}
+
+// BookmarksComponent is a panel that lists the trace's bookmarks.
+type BookmarksComponent struct {
+	canvas *Canvas
+	mwin   *theme.Window
+
+	rows        []bookmarkRow
+	list        widget.List
+	hoveredLink ObjectLink
+
+	theme.ComponentButtons
+}
+
+func NewBookmarksComponent(mwin *theme.Window, cv *Canvas) *BookmarksComponent {
+	bc := &BookmarksComponent{
+		canvas: cv,
+		mwin:   mwin,
+	}
+	bc.list.Axis = layout.Vertical
+	return bc
+}
+
+func (bc *BookmarksComponent) HoveredLink() ObjectLink {
+	return bc.hoveredLink
+}
+
+// Title implements theme.Component.
+func (*BookmarksComponent) Title() string {
+	return "Bookmarks"
+}
+
+func (bc *BookmarksComponent) buildRow(win *theme.Window, b Bookmark, tl *Timeline) []TextSpan {
+	tb := TextBuilder{Window: win}
+	tb.Bold(b.Name)
+	tb.Span("\n")
+	if b.IsSpan() {
+		tb.Span("Span on ")
+		if tl != nil {
+			switch item := tl.item.(type) {
+			case *ptrace.Goroutine, *ptrace.Processor:
+				tb.DefaultLink(tl.shortName, "Bookmarks", item)
+			default:
+				tb.Span(tl.shortName)
+			}
+		} else {
+			tb.Span(b.Timeline)
+		}
+		tb.Span(" from ")
+		tb.DefaultLink(formatTimestamp(nil, b.Start), "Bookmarks", b.Start)
+		tb.Span(" to ")
+		tb.DefaultLink(formatTimestamp(nil, b.End), "Bookmarks", b.End)
+		tb.Span(local.Sprintf(", lasting %s", roundDuration(time.Duration(b.End-b.Start))))
+	} else {
+		tb.Span("At ")
+		tb.DefaultLink(formatTimestamp(nil, b.Start), "Bookmarks", b.Start)
+	}
+	return tb.Spans
+}
+
+// Layout implements theme.Component.
+func (bc *BookmarksComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.BookmarksComponent.Layout").End()
+
+	bs := &bc.canvas.bookmarks
+	if cap(bc.rows) >= bs.Len() {
+		bc.rows = bc.rows[:bs.Len()]
+	} else {
+		bc.rows = append(bc.rows[:cap(bc.rows)], make([]bookmarkRow, bs.Len()-cap(bc.rows))...)
+	}
+
+	bc.hoveredLink = nil
+	for i := range bc.rows {
+		row := &bc.rows[i]
+		for _, ev := range row.text.Update(gtx, row.prevSpans) {
+			handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+		}
+		if l := row.text.HoveredLink(); l != nil {
+			bc.hoveredLink = l
+		}
+	}
+
+	for bc.ComponentButtons.Backed(gtx) {
+		bc.mwin.EmitAction(&PrevPanelAction{})
+	}
+	// Modifying the bookmarks invalidates indices, so only handle one modification per frame.
+handleClicks:
+	for i := range bc.rows {
+		row := &bc.rows[i]
+		for row.goTo.Clicked(gtx) {
+			if bs.At(i).IsSpan() {
+				i := i
+				bc.mwin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+					if i < bc.canvas.bookmarks.Len() {
+						bc.canvas.scrollToBookmark(gtx, i)
+					}
+				}))
+			} else {
+				bc.mwin.EmitAction(ScrollToTimestampAction(bs.At(i).Start))
+			}
+		}
+		for row.rename.Clicked(gtx) {
+			displayBookmarkDialog(bc.mwin, bc.canvas, bs.At(i), i)
+		}
+		for row.remove.Clicked(gtx) {
+			if err := bs.Remove(i); err != nil {
+				win.ShowNotification(gtx, fmt.Sprintf("Couldn't save bookmarks: %s", err))
+			}
+			break handleClicks
+		}
+	}
+	if len(bc.rows) != bs.Len() {
+		bc.rows = bc.rows[:bs.Len()]
+	}
+
+	// Inset of 5 pixels on all sides. We can't use layout.Inset because it doesn't decrease the minimum constraint,
+	// which we do care about here.
+	gtx.Constraints.Min = gtx.Constraints.Min.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints.Max = gtx.Constraints.Max.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints = layout.Normalize(gtx.Constraints)
+	defer op.Offset(image.Pt(5, 5)).Push(gtx.Ops).Pop()
+
+	nothing := func(gtx layout.Context) layout.Dimensions {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+
+	tls := bs.timelinesOf(bc.canvas)
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Flexed(1, nothing),
+				layout.Rigid(theme.Dumb(win, bc.ComponentButtons.Layout)),
+			)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			if bs.Len() == 0 {
+				return theme.Label(win.Theme, "There are no bookmarks. Bookmark timestamps via the context menu of the axis or by pressing B, and spans via their context menus.").Layout(win, gtx)
+			}
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.List(win.Theme, &bc.list).Layout(win, gtx, len(bc.rows), func(gtx layout.Context, index int) layout.Dimensions {
+				row := &bc.rows[index]
+				return layout.Rigids(gtx, layout.Vertical,
+					func(gtx layout.Context) layout.Dimensions {
+						gtx.Constraints.Min = image.Point{}
+						row.text.Reset(win.Theme)
+						row.prevSpans = bc.buildRow(win, bs.At(index), tls[index])
+						return row.text.Layout(win, gtx, row.prevSpans)
+					},
+					layout.Spacer{Height: 5}.Layout,
+					func(gtx layout.Context) layout.Dimensions {
+						button := func(b *widget.PrimaryClickable, label string) layout.Widget {
+							return func(gtx layout.Context) layout.Dimensions {
+								return theme.Button(win.Theme, &b.Clickable, label).Layout(win, gtx)
+							}
+						}
+						return layout.Rigids(gtx, layout.Horizontal,
+							button(&row.goTo, "Go to"),
+							layout.Spacer{Width: 5}.Layout,
+							button(&row.rename, "Rename"),
+							layout.Spacer{Width: 5}.Layout,
+							button(&row.remove, "Delete"),
+						)
+					},
+					layout.Spacer{Height: 10}.Layout,
+				)
+			})
+		},
+	)
+}
@@ -125,13 +125,52 @@ type Canvas struct {
 	animate theme.Animation[canvasAnimation]
 
 	locationHistory locationHistory
-	// All timelines. Index 0 and 1 are the GC and STW timelines, followed by processors and goroutines.
-	timelines      []*Timeline
+	// All timelines, in the user's chosen order. Index 0 and 1 are the GC and STW timelines, followed by processors
+	// and goroutines.
+	allTimelines []*Timeline
+	// When only a subset of timelines is being displayed because of ShowOnlyTimelines, the subset. Otherwise nil.
+	onlyTimelines map[*Timeline]struct{}
+	// Timelines the user has hidden. They don't get displayed, even if they're part of onlyTimelines.
+	hiddenTimelines map[*Timeline]struct{}
+	// Timelines the user has pinned, in the order they get displayed in, below the GC and STW timelines.
+	pinnedTimelines []*Timeline
+	// The set of pinned timelines, for quick lookups.
+	pinnedSet map[*Timeline]struct{}
+	// The displayed timelines, as they would be displayed if goroutines weren't grouped.
+	ungroupedTimelines []*Timeline
+	// All displayed timelines. Index 0 and 1 are the GC and STW timelines, followed by pinned timelines, processors
+	// and goroutines.
+	timelines []*Timeline
+	// timelinesGeneration gets incremented every time the set of displayed timelines changes.
+	timelinesGeneration uint64
+	// How goroutine timelines are grouped.
+	groups timelineGroups
+
 	itemToTimeline map[any]*Timeline
 	scrollbar      widget.Scrollbar
 	axis           Axis
+	overview       Overview
+
+	// The item of the GC timeline, or nil if the trace doesn't contain any GC cycles.
+	gc *GC
+
+	// The plots displayed above the timelines, from top to bottom.
+	plots []*canvasPlot
+	// The user's settings, used for remembering the set of plots. May be nil.
+	settings *Settings
+
+	// The goroutine whose path across processors is drawn on top of the processor timelines, if any.
+	goroutinePath struct {
+		goroutine *ptrace.Goroutine
+		runs      []ptrace.ProcessorRun
+	}
 
-	memoryGraph Plot
+	// Goroutine creation and unblock relationships, sorted by time. Computed in the background the first time flow arrows
+	// get displayed.
+	flows *theme.Future[[]flow]
+
+	// Time intervals highlighted across all timelines, sorted by time.
+	highlightedIntervals []ptrace.Span
 
 	// State for dragging the canvas
 	drag struct {
@@ -144,6 +183,14 @@ type Canvas struct {
 		startY  normalizedY
 	}
 
+	// State for moving a timeline by dragging its label
+	timelineDrag struct {
+		timeline *Timeline
+		active   bool
+		// The vertical position of the pointer
+		y float32
+	}
+
 	// State for zooming to a selection
 	zoomSelection struct {
 		ready   bool
@@ -151,16 +198,32 @@ type Canvas struct {
 		active  bool
 	}
 
+	// State for selecting a time range
+	rangeSelection struct {
+		ready   bool
+		clickAt f32.Point
+		active  bool
+	}
+
+	// The time range selected by the user, which stays highlighted until the selection gets cleared.
+	selectedRange container.Option[TimeSpan]
+
+	bookmarks Bookmarks
+
 	// We have multiple sources of the pointer position, which are valid during different times: Canvas.hover and
 	// Canvas.drag.drag – when we're dragging, Canvas.drag.drag grabs pointer input and the hover won't update anymore.
 	pointerAt f32.Point
 	hover     gesture.Hover
 
 	timeline struct {
-		filter             Filter
+		filter Filter
+		// Should timelines without spans matching the filter be hidden?
+		hideNonMatching    bool
 		displayAllLabels   bool
 		compact            bool
 		displayStackTracks bool
+		// Should arrows between goroutines that created or unblocked each other be shown?
+		displayFlows bool
 		// Should tooltips be shown?
 		showTooltips showTooltips
 		// Should GC overlays be shown?
@@ -170,6 +233,19 @@ type Canvas struct {
 		hover           gesture.Hover
 	}
 
+	// State for hiding timelines without spans matching the filter
+	filteredTimelines struct {
+		// The filter that matches are being computed for
+		filter  Filter
+		compute *theme.Future[map[*Timeline]timelineFilterMatch]
+		applied bool
+		// The timelines with matches, or nil if timelines aren't being filtered.
+		matches map[*Timeline]timelineFilterMatch
+		// Timelines without matches that are displayed anyway because the user navigated to them. Reset whenever the
+		// filter changes.
+		revealed map[*Timeline]struct{}
+	}
+
 	resizeMemoryTimelines component.Resize
 
 	// prevFrame records the canvas's state in the previous state. It allows reusing the computed displayed spans
@@ -185,6 +261,7 @@ type Canvas struct {
 		hoveredTimeline    *Timeline
 		width              int
 		filter             Filter
+		timelines          uint64
 	}
 
 	cachedCanvasHeight struct {
@@ -211,10 +288,11 @@ func NewCanvasInto(cv *Canvas, dwin *DebugWindow, t *Trace) {
 			Ratio: 0.1,
 		},
 		axis:           Axis{cv: cv, anchor: AxisAnchorCenter},
+		overview:       Overview{cv: cv},
 		trace:          t,
 		debugWindow:    dwin,
 		itemToTimeline: make(map[any]*Timeline),
-		timelines:      make([]*Timeline, 0, len(t.Goroutines)+len(t.Processors)+len(t.Machines)+2),
+		allTimelines:   make([]*Timeline, 0, len(t.Goroutines)+len(t.Processors)+len(t.Machines)+2),
 		textures: TextureManager{
 			rgbas:         mysync.NewMutex(&container.RBTree[comparableTimeDuration, *texture]{AllowDuplicates: true}),
 			realizedRGBAs: mysync.NewMutex(container.Set[*texture]{}),
@@ -226,10 +304,15 @@ func NewCanvasInto(cv *Canvas, dwin *DebugWindow, t *Trace) {
 	cv.timeline.displayAllLabels = true
 
 	if len(t.GC) != 0 {
-		cv.timelines = append(cv.timelines, NewGCTimeline(cv, t, t.GC))
+		tl := NewGCTimeline(cv, t, t.GC)
+		cv.gc = tl.item.(*GC)
+		cv.itemToTimeline[cv.gc] = tl
+		cv.allTimelines = append(cv.allTimelines, tl)
 	}
 	if len(t.STW) != 0 {
-		cv.timelines = append(cv.timelines, NewSTWTimeline(cv, t, t.STW))
+		tl := NewSTWTimeline(cv, t, t.STW)
+		cv.itemToTimeline[tl.item] = tl
+		cv.allTimelines = append(cv.allTimelines, tl)
 	}
 }
 
@@ -362,6 +445,7 @@ func (cv *Canvas) unchanged(gtx layout.Context) bool {
 		cv.prevFrame.compact == cv.timeline.compact &&
 		cv.prevFrame.displayStackTracks == cv.timeline.displayStackTracks &&
 		cv.prevFrame.filter == cv.timeline.filter &&
+		cv.prevFrame.timelines == cv.timelinesGeneration &&
 		cv.prevFrame.metric == gtx.Metric
 }
 
@@ -376,9 +460,18 @@ func (cv *Canvas) abortZoomSelection() {
 
 func (cv *Canvas) endZoomSelection(win *theme.Window, gtx layout.Context, pos f32.Point) {
 	cv.zoomSelection.active = false
-	one := cv.zoomSelection.clickAt.X
-	two := pos.X
+	start, end := cv.selectionToTs(win, gtx, cv.zoomSelection.clickAt.X, pos.X)
+	if start == end {
+		// Cannot zoom to a zero width area
+		return
+	}
 
+	cv.navigateToStartAndEnd(gtx, start, end, cv.y)
+}
+
+// selectionToTs converts the horizontal positions of the two ends of a selection to timestamps, limiting them to the
+// visible portion of the canvas.
+func (cv *Canvas) selectionToTs(win *theme.Window, gtx layout.Context, one, two float32) (start, end trace.Timestamp) {
 	startPx := min(one, two)
 	endPx := max(one, two)
 
@@ -389,14 +482,7 @@ func (cv *Canvas) endZoomSelection(win *theme.Window, gtx layout.Context, pos f3
 		endPx = limit
 	}
 
-	start := cv.pxToTs(startPx)
-	end := cv.pxToTs(endPx)
-	if start == end {
-		// Cannot zoom to a zero width area
-		return
-	}
-
-	cv.navigateToStartAndEnd(gtx, start, end, cv.y)
+	return cv.pxToTs(startPx), cv.pxToTs(endPx)
 }
 
 func (cv *Canvas) startDrag(pos f32.Point) {
@@ -497,6 +583,23 @@ func (cv *Canvas) pxToTs(px float32) trace.Timestamp {
 func (cv *Canvas) ZoomToFitCurrentView(gtx layout.Context) {
 	var first, last trace.Timestamp = -1, -1
 	start, end := cv.visibleTimelines(gtx)
+	if matches := cv.filteredTimelines.matches; matches != nil {
+		// Only timelines with matches are being displayed, fit their matching spans instead of the whole timelines.
+		for _, tl := range cv.timelines[start:end] {
+			if m, ok := matches[tl]; ok {
+				if first == -1 || m.Start < first {
+					first = m.Start
+				}
+				if m.End > last {
+					last = m.End
+				}
+			}
+		}
+		if first != -1 {
+			cv.navigateToStartAndEnd(gtx, first, last, cv.y)
+			return
+		}
+	}
 	for _, tl := range cv.timelines[start:end] {
 		for _, track := range tl.tracks {
 			if track.kind == TrackKindStack && !cv.timeline.displayStackTracks {
@@ -518,6 +621,175 @@ func (cv *Canvas) ZoomToFitCurrentView(gtx layout.Context) {
 	cv.navigateToStartAndEnd(gtx, first, last, cv.y)
 }
 
+// AllTimelines returns all timelines, including those that aren't being displayed because of ShowOnlyTimelines or
+// because they are hidden.
+func (cv *Canvas) AllTimelines() []*Timeline {
+	return cv.allTimelines
+}
+
+// ShowingAllTimelines reports whether all timelines are being displayed.
+func (cv *Canvas) ShowingAllTimelines() bool {
+	return cv.onlyTimelines == nil
+}
+
+// ShowOnlyTimelines limits the displayed timelines to tls, in their original order. The GC and STW timelines are
+// always displayed.
+func (cv *Canvas) ShowOnlyTimelines(gtx layout.Context, tls []*Timeline) {
+	keep := make(map[*Timeline]struct{}, len(tls))
+	for _, tl := range tls {
+		keep[tl] = struct{}{}
+	}
+	cv.onlyTimelines = keep
+	cv.setDisplayedTimelines()
+}
+
+// ShowAllTimelines undoes the effect of ShowOnlyTimelines.
+func (cv *Canvas) ShowAllTimelines(gtx layout.Context) {
+	if cv.onlyTimelines == nil {
+		return
+	}
+	cv.onlyTimelines = nil
+	cv.setDisplayedTimelines()
+}
+
+// setDisplayedTimelines updates the displayed timelines and scrolls to the top.
+func (cv *Canvas) setDisplayedTimelines() {
+	cv.updateDisplayedTimelines()
+	// The old y offset is meaningless for the new set of timelines.
+	cv.cancelNavigation()
+	cv.y = 0
+}
+
+// updateDisplayedTimelines computes the displayed timelines from all timelines, taking into account
+// ShowOnlyTimelines, hidden and pinned timelines, and grouping.
+func (cv *Canvas) updateDisplayedTimelines() {
+	tls := make([]*Timeline, 0, len(cv.allTimelines))
+	for _, tl := range cv.allTimelines {
+		if isFixedTimeline(tl) {
+			tls = append(tls, tl)
+		}
+	}
+	for _, tl := range cv.pinnedTimelines {
+		if cv.timelineShown(tl) {
+			tls = append(tls, tl)
+		}
+	}
+	for _, tl := range cv.allTimelines {
+		if !isFixedTimeline(tl) && !cv.TimelinePinned(tl) && cv.timelineShown(tl) {
+			tls = append(tls, tl)
+		}
+	}
+	cv.ungroupedTimelines = tls
+
+	if cv.groups.by != timelineGroupingNone {
+		cv.computeTimelineGroups(tls)
+	} else {
+		cv.timelines = tls
+		cv.timelinesGeneration++
+		// Invalidate cached positions and heights, which assume a fixed set of timelines.
+		cv.timelineEnds = cv.timelineEnds[:0]
+		cv.cachedCanvasHeight.height = 0
+	}
+}
+
+// keepTopTimeline calls update, which is expected to change the displayed timelines, and scrolls so that the timeline
+// at the top of the canvas stays there. If it is no longer displayed, the next displayed timeline takes its place. If
+// there is none, the canvas scrolls to the top.
+func (cv *Canvas) keepTopTimeline(gtx layout.Context, update func()) {
+	var (
+		prev []*Timeline
+		off  int
+	)
+	if len(cv.timelineEnds) == len(cv.timelines) {
+		start, _ := cv.visibleTimelines(gtx)
+		prev = cv.timelines[start:]
+		if len(prev) != 0 {
+			off = cv.denormalizeY(gtx, cv.y)
+			if start > 0 {
+				off -= cv.timelineEnds[start-1]
+			}
+		}
+	}
+
+	update()
+	cv.cancelNavigation()
+	cv.y = 0
+
+	displayed := make(map[*Timeline]int, len(cv.timelines))
+	for i, tl := range cv.timelines {
+		displayed[tl] = i
+	}
+	for i, tl := range prev {
+		idx, ok := displayed[tl]
+		if !ok {
+			continue
+		}
+		y := 0
+		for _, other := range cv.timelines[:idx] {
+			y += other.Height(gtx, cv)
+		}
+		if i == 0 {
+			// The top timeline is still displayed, keep the offset into it.
+			y += off
+		}
+		cv.y = cv.normalizeY(gtx, y)
+		return
+	}
+}
+
+// timelineShown reports whether tl is neither hidden, nor excluded by ShowOnlyTimelines, nor filtered out.
+func (cv *Canvas) timelineShown(tl *Timeline) bool {
+	if _, ok := cv.hiddenTimelines[tl]; ok {
+		return false
+	}
+	if cv.onlyTimelines != nil {
+		if _, ok := cv.onlyTimelines[tl]; !ok {
+			return false
+		}
+	}
+	if cv.filteredTimelines.matches != nil {
+		if _, ok := cv.filteredTimelines.matches[tl]; !ok {
+			if _, ok := cv.filteredTimelines.revealed[tl]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ensureObjectDisplayed makes sure that the timeline of the object is being displayed, by displaying all timelines,
+// unhiding it and expanding its group if necessary. If timelines without highlighted spans are being hidden, the
+// timeline is revealed until the filter changes, without displaying all other timelines.
+func (cv *Canvas) ensureObjectDisplayed(gtx layout.Context, obj any) {
+	tl, ok := cv.itemToTimeline[obj]
+	if !ok {
+		// Group headers are always displayed.
+		return
+	}
+	if _, ok := cv.hiddenTimelines[tl]; ok {
+		cv.UnhideTimeline(gtx, tl)
+	}
+	if m := cv.filteredTimelines.matches; m != nil && !isFixedTimeline(tl) {
+		if _, ok := m[tl]; !ok {
+			ft := &cv.filteredTimelines
+			if _, ok := ft.revealed[tl]; !ok {
+				if ft.revealed == nil {
+					ft.revealed = map[*Timeline]struct{}{}
+				}
+				ft.revealed[tl] = struct{}{}
+				cv.updateDisplayedTimelines()
+			}
+		}
+	}
+	if cv.onlyTimelines != nil {
+		if _, ok := cv.onlyTimelines[tl]; !ok && !isFixedTimeline(tl) {
+			cv.ShowAllTimelines(gtx)
+		}
+	}
+	cv.expandGroupOf(gtx, obj)
+}
+
+// timelineY returns the vertical offset of dst, which has to be displayed. Use ensureObjectDisplayed to display it.
 func (cv *Canvas) timelineY(gtx layout.Context, dst *Timeline) normalizedY {
 	// OPT(dh): don't be O(n)
 	off := 0
@@ -531,6 +803,7 @@ func (cv *Canvas) timelineY(gtx layout.Context, dst *Timeline) normalizedY {
 	panic("unreachable")
 }
 
+// objectY returns the vertical offset of the timeline of act, which has to be displayed.
 func (cv *Canvas) objectY(gtx layout.Context, act any) normalizedY {
 	// OPT(dh): don't be O(n)
 	off := 0
@@ -545,11 +818,30 @@ func (cv *Canvas) objectY(gtx layout.Context, act any) normalizedY {
 }
 
 func (cv *Canvas) scrollToTimeline(gtx layout.Context, tl *Timeline) {
+	cv.ensureObjectDisplayed(gtx, tl.item)
 	off := cv.timelineY(gtx, tl)
 	cv.navigateTo(gtx, cv.start, cv.nsPerPx, off)
 }
 
+// scrollToTimestamp scrolls horizontally so that ts is at the axis's origin, and vertically to y.
+func (cv *Canvas) scrollToTimestamp(gtx layout.Context, ts trace.Timestamp, y normalizedY) {
+	d := cv.End() - cv.start
+	var off trace.Timestamp
+	switch cv.axis.anchor {
+	case AxisAnchorNone:
+		off = cv.pxToTs(cv.axis.position) - cv.start
+	case AxisAnchorStart:
+		off = 0
+	case AxisAnchorCenter:
+		off = d / 2
+	case AxisAnchorEnd:
+		off = d
+	}
+	cv.navigateTo(gtx, ts-off, cv.nsPerPx, y)
+}
+
 func (cv *Canvas) scrollToObject(gtx layout.Context, act any) {
+	cv.ensureObjectDisplayed(gtx, act)
 	off := cv.objectY(gtx, act)
 	cv.navigateTo(gtx, cv.start, cv.nsPerPx, off)
 }
@@ -700,6 +992,8 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 	win.AddShortcut(theme.Shortcut{Name: "C"})
 	win.AddShortcut(theme.Shortcut{Name: "T"})
 	win.AddShortcut(theme.Shortcut{Name: "O"})
+	win.AddShortcut(theme.Shortcut{Name: "F"})
+	win.AddShortcut(theme.Shortcut{Name: "B"})
 
 	for _, s := range win.PressedShortcuts() {
 		switch s {
@@ -764,6 +1058,13 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 		case theme.Shortcut{Name: "O"}:
 			cv.timeline.showGCOverlays = (cv.timeline.showGCOverlays + 1) % (showGCOverlaysBoth + 1)
 			showGCOverlaySettingNotification(win, gtx, cv.timeline.showGCOverlays)
+
+		case theme.Shortcut{Name: "F"}:
+			cv.timeline.hideNonMatching = !cv.timeline.hideNonMatching
+			showHideNonMatchingSettingNotification(win, gtx, cv.timeline.hideNonMatching)
+
+		case theme.Shortcut{Name: "B"}:
+			displayBookmarkDialog(win, cv, Bookmark{Start: cv.pxToTs(cv.pointerAt.X)}, -1)
 		}
 	}
 
@@ -774,6 +1075,7 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			case pointer.Scroll:
 				// XXX deal with Gio's asinine "scroll focused area into view" behavior when shrinking windows
 				cv.abortZoomSelection()
+				cv.abortRangeSelection()
 				switch ev.Modifiers {
 				case key.ModShortcut:
 					cv.zoom(gtx, ev.Scroll.Y, ev.Position)
@@ -792,32 +1094,53 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 		case pointer.Press:
 			switch ev.Modifiers {
 			case 0:
-				cv.drag.ready = true
+				if h := cv.timeline.hoveredTimeline; h != nil && h.widget != nil && h.widget.labelClick.Hovered() && timelineArrangeable(h) {
+					cv.timelineDrag.timeline = h
+				} else {
+					cv.drag.ready = true
+				}
 			case key.ModShortcut:
 				cv.zoomSelection.ready = true
+			case key.ModShift:
+				cv.rangeSelection.ready = true
 			}
 		case pointer.Drag:
 			cv.pointerAt = ev.Position
-			if cv.drag.ready && !cv.drag.active {
+			if cv.timelineDrag.timeline != nil {
+				cv.timelineDrag.active = true
+				cv.timelineDrag.y = ev.Position.Y
+			} else if cv.drag.ready && !cv.drag.active {
 				cv.startDrag(ev.Position)
 			} else if cv.zoomSelection.ready && !cv.zoomSelection.active {
 				cv.startZoomSelection(ev.Position)
+			} else if cv.rangeSelection.ready && !cv.rangeSelection.active {
+				cv.startRangeSelection(ev.Position)
 			}
 			if cv.drag.active {
 				cv.dragTo(gtx, ev.Position)
 			}
 		case pointer.Release, pointer.Cancel:
+			if cv.timelineDrag.active && ev.Kind == pointer.Release && len(cv.timelineEnds) == len(cv.timelines) {
+				cv.moveTimeline(cv.timelineDrag.timeline, cv.timelineDropIndex(gtx, ev.Position.Y))
+			}
+			cv.timelineDrag.timeline = nil
+			cv.timelineDrag.active = false
 			cv.drag.ready = false
 			cv.zoomSelection.ready = false
+			cv.rangeSelection.ready = false
 			if cv.drag.active {
 				cv.endDrag()
 			}
 			if cv.zoomSelection.active {
 				cv.endZoomSelection(win, gtx, ev.Position)
 			}
+			if cv.rangeSelection.active {
+				cv.endRangeSelection(win, gtx, ev.Position)
+			}
 		}
 	}
 
+	cv.updateFilteredTimelines(win, gtx)
 	cv.computeTimelinePositions(gtx)
 
 	func(gtx layout.Context) {
@@ -882,8 +1205,13 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			theme.FillShape(win, gtx.Ops, c, clip.Outline{Path: p.End()}.Op())
 		}
 
-		// Draw axis, memory graph, timelines, and scrollbar
+		// Draw overview, axis, memory graph, timelines, and scrollbar
 		layout.Rigids(gtx, layout.Vertical,
+			// Overview
+			func(gtx layout.Context) layout.Dimensions {
+				return cv.overview.Layout(win, gtx)
+			},
+
 			// Axis
 			func(gtx layout.Context) layout.Dimensions {
 				// Note that even though the axis is wider than the timelines (because timelines have a scrollbar), the
@@ -921,91 +1249,91 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			},
 
 			func(gtx layout.Context) layout.Dimensions {
-				return theme.Resize(win.Theme, &cv.resizeMemoryTimelines).Layout(win, gtx,
-					// Memory graph
-					func(win *theme.Window, gtx layout.Context) layout.Dimensions {
-						defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
-						cv.drag.drag.Add(gtx.Ops)
-
-						dims := cv.memoryGraph.Layout(win, gtx, cv)
-						return dims
-					},
-
-					// Timelines and scrollbar
-					func(win *theme.Window, gtx layout.Context) layout.Dimensions {
-						return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
-							// Timelines
-							layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-								defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
-
-								if width := gtx.Constraints.Max.X; width != cv.width {
-									panic(fmt.Sprintf("computed timelines width differs from actual width: %d != %d", cv.width, width))
+				// Timelines and scrollbar
+				timelines := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+						// Timelines
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+							if width := gtx.Constraints.Max.X; width != cv.width {
+								panic(fmt.Sprintf("computed timelines width differs from actual width: %d != %d", cv.width, width))
+							}
+
+							cv.drag.drag.Add(gtx.Ops)
+
+							cv.timeline.hover.Add(gtx.Ops)
+							// OPT(dh): reuse slice
+							texs := cv.scratchTexs[:0]
+							texs = cv.planTimelines(win, gtx, texs)
+							cv.scratchTexs = texs[:0]
+							if time.Since(gtx.Now) <= 5*time.Millisecond {
+								// Start computing textures in the background and wait up to 1ms for all textures to
+								// finish. This avoids showing single frames of placeholders. We only do this if we
+								// have enough time to spare to hit our rough goal of 144 fps.
+
+								dones := cv.scratchDones
+								if cap(dones) >= len(texs) {
+									dones = dones[:len(texs)]
+								} else {
+									dones = make([]chan struct{}, len(texs))
+									cv.scratchDones = dones
 								}
 
-								cv.drag.drag.Add(gtx.Ops)
-
-								cv.timeline.hover.Add(gtx.Ops)
-								// OPT(dh): reuse slice
-								texs := cv.scratchTexs[:0]
-								texs = cv.planTimelines(win, gtx, texs)
-								cv.scratchTexs = texs[:0]
-								if time.Since(gtx.Now) <= 5*time.Millisecond {
-									// Start computing textures in the background and wait up to 1ms for all textures to
-									// finish. This avoids showing single frames of placeholders. We only do this if we
-									// have enough time to spare to hit our rough goal of 144 fps.
-
-									dones := cv.scratchDones
-									if cap(dones) >= len(texs) {
-										dones = dones[:len(texs)]
-									} else {
-										dones = make([]chan struct{}, len(texs))
-										cv.scratchDones = dones
-									}
-
-									for i, tex := range texs {
-										dones[i] = tex.Realize(&cv.textures, cv.trace)
-									}
+								for i, tex := range texs {
+									dones[i] = tex.Realize(&cv.textures, cv.trace)
+								}
 
-									notReady := false
-									for _, ch := range dones {
-										if !TryRecv(ch) {
-											notReady = true
-											break
-										}
+								notReady := false
+								for _, ch := range dones {
+									if !TryRecv(ch) {
+										notReady = true
+										break
 									}
-									if notReady {
-										timeout := time.NewTimer(time.Millisecond)
-										defer timeout.Stop()
-									doneLoop:
-										for n := 0; n < len(dones); n++ {
-											select {
-											case <-dones[n]:
-											case <-timeout.C:
-												break doneLoop
-											}
+								}
+								if notReady {
+									timeout := time.NewTimer(time.Millisecond)
+									defer timeout.Stop()
+								doneLoop:
+									for n := 0; n < len(dones); n++ {
+										select {
+										case <-dones[n]:
+										case <-timeout.C:
+											break doneLoop
 										}
 									}
-									clear(dones)
-								}
-								dims, tws := cv.layoutTimelines(win, gtx)
-								cv.prevFrame.displayedTls = tws
-								return dims
-							}),
-
-							// Scrollbar
-							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-								totalHeight := cv.height(gtx)
-								if len(cv.timelines) > 0 {
-									// Allow scrolling past the last goroutine
-									totalHeight += cv.timelines[len(cv.timelines)-1].Height(gtx, cv)
 								}
+								clear(dones)
+							}
+							dims, tws := cv.layoutTimelines(win, gtx)
+							cv.prevFrame.displayedTls = tws
+							return dims
+						}),
+
+						// Scrollbar
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							totalHeight := cv.height(gtx)
+							if len(cv.timelines) > 0 {
+								// Allow scrolling past the last goroutine
+								totalHeight += cv.timelines[len(cv.timelines)-1].Height(gtx, cv)
+							}
+
+							fraction := float32(gtx.Constraints.Max.Y) / float32(totalHeight)
+							sb := theme.Scrollbar(win.Theme, &cv.scrollbar)
+							return sb.Layout(win, gtx, layout.Vertical, float32(cv.y), float32(cv.y)+fraction)
+						}),
+					)
+				}
 
-								fraction := float32(gtx.Constraints.Max.Y) / float32(totalHeight)
-								sb := theme.Scrollbar(win.Theme, &cv.scrollbar)
-								return sb.Layout(win, gtx, layout.Vertical, float32(cv.y), float32(cv.y)+fraction)
-							}),
-						)
+				if len(cv.plots) == 0 {
+					return timelines(win, gtx)
+				}
+				return theme.Resize(win.Theme, &cv.resizeMemoryTimelines).Layout(win, gtx,
+					// Plots
+					func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+						return cv.layoutPlots(win, gtx, cv.plots)
 					},
+					timelines,
 				)
 			},
 		)
@@ -1021,6 +1349,9 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			theme.FillShape(win, gtx.Ops, win.Theme.Palette.PrimarySelection, rect.Op(gtx.Ops))
 		}
 
+		// Draw the selected time range
+		cv.drawSelectedRange(win, gtx)
+
 		// Draw STW and GC overlays
 		if cv.timeline.showGCOverlays >= showGCOverlaysBoth {
 			// TODO(dh): make this less brittle. relying on the fact that cv.timelines[0] and [1] are GC and STW
@@ -1053,6 +1384,13 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 			drawRegionOverlays(sSTW, c, gtx.Constraints.Max.Y)
 		}
 
+		// Draw highlighted intervals
+		if len(cv.highlightedIntervals) != 0 {
+			c := win.Theme.Palette.PrimarySelection
+			c.A = 0.35
+			drawRegionOverlays(SimpleItems[ptrace.Span, any]{items: cv.highlightedIntervals, subslice: true}, c, gtx.Constraints.Max.Y)
+		}
+
 		// Draw cursor
 		rect := clip.Rect{
 			Min: image.Pt(int(round32(cv.pointerAt.X)), 0),
@@ -1116,6 +1454,7 @@ func (cv *Canvas) Layout(win *theme.Window, gtx layout.Context) layout.Dimension
 	cv.prevFrame.displayStackTracks = cv.timeline.displayStackTracks
 	cv.prevFrame.hoveredTimeline = cv.timeline.hoveredTimeline
 	cv.prevFrame.filter = cv.timeline.filter
+	cv.prevFrame.timelines = cv.timelinesGeneration
 	cv.prevFrame.metric = gtx.Metric
 
 	cv.clickedSpans = cv.clickedSpans[:0]
@@ -1197,7 +1536,9 @@ func (cv *Canvas) layoutTimelines(win *theme.Window, gtx layout.Context) (layout
 		tl := cv.timelines[i]
 		stack := op.Offset(image.Pt(0, y)).Push(gtx.Ops)
 		topBorder := i > 0 && cv.timelines[i-1].widget.Hovered(gtx)
-		tl.Layout(win, gtx, cv, cv.timeline.displayAllLabels, cv.timeline.compact, topBorder, &cv.trackSpanLabels)
+		// Always label group headers, as they are the only way of expanding groups.
+		_, isGroup := tl.item.(*timelineGroup)
+		tl.Layout(win, gtx, cv, cv.timeline.displayAllLabels || isGroup, cv.timeline.compact, topBorder, &cv.trackSpanLabels)
 		stack.Pop()
 
 		y += tl.Height(gtx, cv)
@@ -1218,6 +1559,11 @@ func (cv *Canvas) layoutTimelines(win *theme.Window, gtx layout.Context) (layout
 		}
 	}
 
+	cv.drawGoroutinePath(win, gtx)
+	cv.drawFlows(win, gtx)
+	cv.drawBookmarks(win, gtx)
+	cv.drawTimelineDropIndicator(win, gtx)
+
 	return layout.Dimensions{Size: gtx.Constraints.Max}, cv.timelines[start:end]
 }
 
@@ -1248,10 +1594,12 @@ type Axis struct {
 	anchor AxisAnchor
 
 	prevFrame struct {
-		ops    mem.ReusableOps
-		call   op.CallOp
-		dims   layout.Dimensions
-		origin trace.Timestamp
+		ops           mem.ReusableOps
+		call          op.CallOp
+		dims          layout.Dimensions
+		origin        trace.Timestamp
+		selectedRange container.Option[TimeSpan]
+		bookmarksGen  int
 	}
 }
 
@@ -1278,8 +1626,22 @@ func (axis *Axis) Layout(win *theme.Window, gtx layout.Context) (dims layout.Dim
 
 	for _, ev := range axis.click.Update(gtx.Queue) {
 		if ev.Kind == gesture.KindPress && ev.Button == pointer.ButtonSecondary {
+			ts := axis.cv.pxToTs(float32(ev.Position.X))
 			win.SetContextMenu(
 				[]*theme.MenuItem{
+					{
+						Label: PlainLabel("Show goroutine states here"),
+						Action: func() theme.Action {
+							return &OpenGoroutineSnapshotAction{Timestamp: ts}
+						},
+					},
+					{
+						Label:    PlainLabel("Add bookmark here…"),
+						Shortcut: "B",
+						Action: func() theme.Action {
+							return &OpenAddBookmarkAction{Bookmark: Bookmark{Start: ts}}
+						},
+					},
 					{
 						Label:    PlainLabel("Move origin to the left"),
 						Disabled: func() bool { return axis.anchor == AxisAnchorStart },
@@ -1356,7 +1718,7 @@ func (axis *Axis) Layout(win *theme.Window, gtx layout.Context) (dims layout.Dim
 		origin = max
 	}
 
-	if axis.cv.unchanged(gtx) && axis.prevFrame.origin == origin {
+	if axis.cv.unchanged(gtx) && axis.prevFrame.origin == origin && axis.prevFrame.selectedRange == axis.cv.selectedRange && axis.prevFrame.bookmarksGen == axis.cv.bookmarks.gen {
 		axis.prevFrame.call.Add(gtx.Ops)
 		debugCaching(win, gtx)
 		return axis.prevFrame.dims
@@ -1371,6 +1733,8 @@ func (axis *Axis) Layout(win *theme.Window, gtx layout.Context) (dims layout.Dim
 		axis.prevFrame.call = call
 		axis.prevFrame.dims = dims
 		axis.prevFrame.origin = origin
+		axis.prevFrame.selectedRange = axis.cv.selectedRange
+		axis.prevFrame.bookmarksGen = axis.cv.bookmarks.gen
 	}()
 
 	var ticksPath clip.Path
@@ -1513,6 +1877,8 @@ func (axis *Axis) Layout(win *theme.Window, gtx layout.Context) (dims layout.Dim
 	}
 
 	theme.FillShape(win, gtx.Ops, win.Theme.Palette.Foreground, clip.Outline{Path: ticksPath.End()}.Op())
+	axis.drawSelectedRange(win, gtx, int(tickHeight))
+	axis.drawBookmarks(win, gtx, tickHeight/2)
 
 	labelHeight := originLabelExtents.Max.Y
 	return layout.Dimensions{Size: image.Pt(gtx.Constraints.Max.X, int(tickHeight+0.5)+labelHeight)}
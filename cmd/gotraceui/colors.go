@@ -46,6 +46,13 @@ var colors = [colorLast]color.Oklch{
 	colorStateDone:    oklch(0, 0, 0),
 	colorEvent:        oklch(colorsLightBase, colorsChromaBase, 0),
 	colorMergedEvents: oklch(colorsLightBase+colorLightStep1, colorsChromaBase, 284.44),
+	colorLogEvent:     oklch(colorsLightBase, colorsChromaBase, 70.67),
+
+	colorBookmark: oklch(colorsLightBase, colorsChromaBase+0.072, 262.88), // Manually chosen
+
+	// Darker than the states so that the arrows remain visible on top of spans
+	colorFlowCreate:  oklch(colorsLightBase-20, colorsChromaBase, 143.74),
+	colorFlowUnblock: oklch(colorsLightBase-20, colorsChromaBase, 206.35),
 
 	colorStateUnknown:              oklch(96.8, 0.211, 109.77),
 	colorStatePlaceholderStackSpan: oklch(92.59, 0.025, 106.88),
@@ -94,6 +101,12 @@ const (
 
 	colorEvent
 	colorMergedEvents
+	colorLogEvent
+
+	colorBookmark
+
+	colorFlowCreate
+	colorFlowUnblock
 
 	colorLast
 )
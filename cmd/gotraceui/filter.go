@@ -5,11 +5,14 @@ import (
 	rtrace "runtime/trace"
 	"sort"
 
+	"github.com/joonho3020/gotraceui/container"
 	"github.com/joonho3020/gotraceui/layout"
 	"github.com/joonho3020/gotraceui/theme"
 	"github.com/joonho3020/gotraceui/trace"
 	"github.com/joonho3020/gotraceui/trace/ptrace"
 	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/font"
 )
 
 type FilterMode uint8
@@ -38,6 +41,20 @@ type Filter struct {
 	Machine struct {
 		Processor int32
 	}
+
+	// Highlight exactly these spans, identified by their events. The set must not be modified after it has been
+	// assigned to a filter.
+	Spans *container.Set[ptrace.EventID]
+
+	// Highlight spans matching this query.
+	Query *FilterQuery
+}
+
+// Empty reports whether the filter doesn't filter anything and thus never matches.
+func (f Filter) Empty() bool {
+	// Unset Mode so we can compare with the empty literal
+	f.Mode = 0
+	return f == (Filter{})
 }
 
 func (f Filter) HasState(state ptrace.SchedulingState) bool {
@@ -116,8 +133,7 @@ func (f Filter) Match(spans ptrace.Spans, container ItemContainer) bool {
 				if _, ok := container.Timeline.item.(*ptrace.Processor); ok {
 					tr := container.Timeline.cv.trace
 					for i := 0; i < spans.Len(); i++ {
-						g := tr.G(tr.Event(spans.AtPtr(i).Event).G)
-						if g.ID == f.Processor.Goroutine {
+						if tr.Event(spans.AtPtr(i).Event).G == f.Processor.Goroutine {
 							return true, false
 						}
 					}
@@ -129,6 +145,18 @@ func (f Filter) Match(spans ptrace.Spans, container ItemContainer) bool {
 			}
 		},
 
+		func() (bool, bool) {
+			if f.Spans == nil {
+				return false, true
+			}
+			for i := 0; i < spans.Len(); i++ {
+				if _, ok := (*f.Spans)[spans.AtPtr(i).Event]; ok {
+					return true, false
+				}
+			}
+			return false, false
+		},
+
 		func() (bool, bool) {
 			if f.Machine.Processor != 0 {
 				if _, ok := container.Timeline.item.(*ptrace.Machine); ok {
@@ -146,6 +174,13 @@ func (f Filter) Match(spans ptrace.Spans, container ItemContainer) bool {
 				return false, true
 			}
 		},
+
+		func() (bool, bool) {
+			if f.Query == nil {
+				return false, true
+			}
+			return f.Query.match(spans, container), false
+		},
 	}
 
 	switch f.Mode {
@@ -180,20 +215,33 @@ func (f Filter) Match(spans ptrace.Spans, container ItemContainer) bool {
 // couldMatch checks if the filter could possibly match the spans. It's an optimization to avoid checking impossible
 // combinations.
 func (f Filter) couldMatch(spans ptrace.Spans, container ItemContainer) bool {
-	{
-		// Unset Mode so we can compare with the empty literal
-		f := f
-		f.Mode = 0
-		if f == (Filter{}) {
-			return false
-		}
+	if f.Empty() {
+		return false
+	}
+	if container.Track.kind == TrackKindProcessorLifecycle {
+		// Processor lifecycle spans don't have any of the properties that filters are concerned with.
+		return false
 	}
 
 	b := f.couldMatchState(spans, container)
 	b = b || f.couldMatchProcessor(spans, container)
+	b = b || f.couldMatchSpans(spans, container)
+	b = b || f.couldMatchQuery(spans, container)
 	return b
 }
 
+func (f Filter) couldMatchQuery(spans ptrace.Spans, container ItemContainer) bool {
+	return f.Query != nil && f.Query.couldMatch(container)
+}
+
+func (f Filter) couldMatchSpans(spans ptrace.Spans, container ItemContainer) bool {
+	if f.Spans == nil {
+		return false
+	}
+	_, ok := container.Timeline.item.(*ptrace.Goroutine)
+	return ok && container.Track.kind == TrackKindUnspecified
+}
+
 func (f Filter) couldMatchProcessor(spans ptrace.Spans, container ItemContainer) bool {
 	switch container.Timeline.item.(type) {
 	case *ptrace.Processor:
@@ -204,9 +252,18 @@ func (f Filter) couldMatchProcessor(spans ptrace.Spans, container ItemContainer)
 }
 
 func (f Filter) couldMatchState(spans ptrace.Spans, container ItemContainer) bool {
+	return couldMatchStates(f.States, container)
+}
+
+// couldMatchStates checks if spans in the container could possibly have any of the states in the bitmap.
+func couldMatchStates(states uint64, container ItemContainer) bool {
+	has := func(state ptrace.SchedulingState) bool {
+		return states&(1<<state) != 0
+	}
+
 	switch item := container.Timeline.item.(type) {
 	case *ptrace.Processor:
-		return f.HasState(ptrace.StateRunningG)
+		return has(ptrace.StateRunningG)
 	case *ptrace.Goroutine:
 		switch container.Track.kind {
 		case TrackKindUnspecified:
@@ -214,23 +271,134 @@ func (f Filter) couldMatchState(spans ptrace.Spans, container ItemContainer) boo
 				// bgsweep, especially in Go <1.21, can be responsible for millions of spans, but they can only ever be of
 				// two states.
 
-				return f.HasState(ptrace.StateActive) || f.HasState(ptrace.StateInactive)
+				return has(ptrace.StateActive) || has(ptrace.StateInactive)
 			}
 		case TrackKindUserRegions:
-			return f.HasState(ptrace.StateUserRegion)
+			return has(ptrace.StateUserRegion)
 		case TrackKindStack:
-			return f.HasState(ptrace.StateStack)
+			return has(ptrace.StateStack)
 		}
 
 	case *STW, *GC:
-		return f.HasState(ptrace.StateActive)
+		return has(ptrace.StateActive)
 	}
 
 	return true
 }
 
+// timelineFilterMatch describes the time range covered by the spans of a timeline that match a filter.
+type timelineFilterMatch struct {
+	Start trace.Timestamp
+	End   trace.Timestamp
+}
+
+// computeFilterMatches finds the timelines that have spans matching the filter. Tracks whose spans are computed on
+// demand, such as stack tracks, aren't considered. The GC and STW timelines are never included.
+func computeFilterMatches(tls []*Timeline, f Filter, cancelled <-chan struct{}) map[*Timeline]timelineFilterMatch {
+	defer rtrace.StartRegion(context.Background(), "main.computeFilterMatches").End()
+
+	out := map[*Timeline]timelineFilterMatch{}
+	for i, tl := range tls {
+		if i%100 == 0 && TryRecv(cancelled) {
+			return nil
+		}
+		if isFixedTimeline(tl) {
+			continue
+		}
+
+		m, found := timelineFilterMatch{}, false
+		for _, track := range tl.tracks {
+			if track.compute != nil {
+				continue
+			}
+			spans, _ := track.spans.ResultNoWait()
+			c := ItemContainer{Timeline: tl, Track: track}
+			if spans.Len() == 0 || !f.Match(spans, c) {
+				continue
+			}
+
+			// Narrow the time range down to the first and last individual spans that match. In FilterModeAnd, the
+			// track as a whole can match without any individual span matching, in which case we use the whole
+			// track.
+			start, end := track.Start, track.End
+			for j := 0; j < spans.Len(); j++ {
+				if j%10000 == 0 && TryRecv(cancelled) {
+					return nil
+				}
+				if f.Match(spans.Slice(j, j+1), c) {
+					start = spans.AtPtr(j).Start
+					break
+				}
+			}
+			for j := spans.Len() - 1; j >= 0; j-- {
+				if j%10000 == 0 && TryRecv(cancelled) {
+					return nil
+				}
+				if f.Match(spans.Slice(j, j+1), c) {
+					end = spans.AtPtr(j).End
+					break
+				}
+			}
+
+			if !found {
+				m = timelineFilterMatch{start, end}
+				found = true
+			} else {
+				m.Start = min(m.Start, start)
+				m.End = max(m.End, end)
+			}
+		}
+		if found {
+			out[tl] = m
+		}
+	}
+	return out
+}
+
+// updateFilteredTimelines hides timelines without spans that match the filter, if requested by the user. Matches are
+// computed in the background, and the displayed timelines get updated once the computation has finished.
+func (cv *Canvas) updateFilteredTimelines(win *theme.Window, gtx layout.Context) {
+	ft := &cv.filteredTimelines
+	if !cv.timeline.hideNonMatching || cv.timeline.filter.Empty() {
+		ft.compute = nil
+		ft.revealed = nil
+		if ft.matches != nil {
+			ft.matches = nil
+			cv.keepTopTimeline(gtx, cv.updateDisplayedTimelines)
+		}
+		return
+	}
+
+	if ft.compute == nil || ft.filter != cv.timeline.filter {
+		// The previous computation, if any, gets cancelled automatically because nobody reads from it anymore.
+		tls := cv.allTimelines
+		f := cv.timeline.filter
+		ft.filter = f
+		ft.compute = theme.NewFuture(win, func(cancelled <-chan struct{}) map[*Timeline]timelineFilterMatch {
+			return computeFilterMatches(tls, f, cancelled)
+		})
+		ft.applied = false
+		ft.revealed = nil
+	}
+	if ft.applied {
+		return
+	}
+	if matches, ok := ft.compute.ResultNoWait(); ok {
+		ft.matches = matches
+		ft.applied = true
+		cv.keepTopTimeline(gtx, cv.updateDisplayedTimelines)
+	}
+}
+
 type HighlightDialogStyle struct {
 	Filter *Filter
+	// Whether timelines without matches should be hidden
+	HideNonMatching *bool
+
+	hideNonMatching widget.Bool
+
+	query    widget.Editor
+	queryErr error
 
 	bits [ptrace.StateLast]widget.BackedBit[uint64]
 
@@ -241,11 +409,16 @@ type HighlightDialogStyle struct {
 	stateClickables []widget.Clickable
 }
 
-func HighlightDialog(win *theme.Window, f *Filter) HighlightDialogStyle {
+func HighlightDialog(win *theme.Window, f *Filter, hideNonMatching *bool) HighlightDialogStyle {
 	hd := HighlightDialogStyle{
-		Filter: f,
+		Filter:          f,
+		HideNonMatching: hideNonMatching,
 	}
 	hd.list.Axis = layout.Vertical
+	hd.query.SingleLine = true
+	if f.Query != nil {
+		hd.query.SetText(f.Query.String())
+	}
 
 	for i := range hd.bits {
 		hd.bits[i].Bits = &f.States
@@ -257,10 +430,53 @@ func HighlightDialog(win *theme.Window, f *Filter) HighlightDialogStyle {
 	return hd
 }
 
+func (hd *HighlightDialogStyle) layoutQuery(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			l := theme.LineLabel(win.Theme, "Query")
+			l.Font = font.Font{Weight: font.Bold}
+			return l.Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			tb := theme.TextBox(win.Theme, &hd.query, `state:blocked-net and fn:~"net/http" and dur>5ms`)
+			tb.Validate = func(string) bool { return hd.queryErr == nil }
+			dims := tb.Layout(win, gtx)
+
+			for _, ev := range hd.query.Events() {
+				if _, ok := ev.(widget.ChangeEvent); ok {
+					q, err := ParseFilterQuery(hd.query.Text())
+					hd.queryErr = err
+					// Invalid queries don't highlight anything, instead of highlighting spans that match a previous
+					// version of the query.
+					hd.Filter.Query = q
+				}
+			}
+			return dims
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			if hd.queryErr != nil {
+				return theme.Label(win.Theme, "Invalid query: "+hd.queryErr.Error()).Layout(win, gtx)
+			}
+			return theme.Label(win.Theme, "Fields: state, fn, region, tag, g, p, dur. Combine with and, or, not, and parentheses.").Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+	)
+}
+
 func (hd *HighlightDialogStyle) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
 	defer rtrace.StartRegion(context.Background(), "main.HighlightDialogStyle.Layout").End()
 
-	return theme.List(win.Theme, &hd.list).Layout(win, gtx, 1, func(gtx layout.Context, index int) layout.Dimensions {
+	// Pick up changes made via the menu or keyboard shortcut.
+	hd.hideNonMatching.Value = *hd.HideNonMatching
+	defer func() { *hd.HideNonMatching = hd.hideNonMatching.Value }()
+
+	return theme.List(win.Theme, &hd.list).Layout(win, gtx, 3, func(gtx layout.Context, index int) layout.Dimensions {
+		switch index {
+		case 0:
+			return theme.CheckBox(win.Theme, &hd.hideNonMatching, "Show only timelines with highlighted spans").Layout(win, gtx)
+		case 1:
+			return hd.layoutQuery(win, gtx)
+		}
 		return theme.Foldable(win.Theme, &hd.foldables.states, "States").Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 			return layout.Rigids(gtx, layout.Vertical,
 				func(gtx layout.Context) layout.Dimensions {
@@ -9,20 +9,26 @@ import (
 
 	"github.com/joonho3020/gotraceui/clip"
 	"github.com/joonho3020/gotraceui/color"
+	"github.com/joonho3020/gotraceui/container"
 	"github.com/joonho3020/gotraceui/layout"
 	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
 	"github.com/joonho3020/gotraceui/trace/ptrace"
 	"github.com/joonho3020/gotraceui/widget"
 )
 
 type FlameGraphComponent struct {
-	g     *ptrace.Goroutine
-	fg    *theme.Future[*widget.FlameGraph]
-	state theme.FlameGraphState
+	g *ptrace.Goroutine
+	// If set, only samples taken in this time range are included.
+	timeRange container.Option[TimeSpan]
+	fg        *theme.Future[*widget.FlameGraph]
+	state     theme.FlameGraphState
 }
 
 func (fc *FlameGraphComponent) Title() string {
-	if fc.g == nil {
+	if r, ok := fc.timeRange.Get(); ok {
+		return local.Sprintf("Flame graph for %s to %s", formatTimestamp(nil, r.Start), formatTimestamp(nil, r.End))
+	} else if fc.g == nil {
 		return "Flame graph"
 	} else {
 		// OPT(dh): avoid the allocation
@@ -38,8 +44,18 @@ func (tlc *FlameGraphComponent) WantsTransition(gtx layout.Context) theme.Compon
 }
 
 func NewFlameGraphComponent(win *theme.Window, trace *ptrace.Trace, g *ptrace.Goroutine) *FlameGraphComponent {
+	return newFlameGraphComponent(win, trace, g, container.None[TimeSpan]())
+}
+
+// NewTimeRangeFlameGraphComponent returns a flame graph of all CPU samples taken between start and end.
+func NewTimeRangeFlameGraphComponent(win *theme.Window, tr *ptrace.Trace, start, end trace.Timestamp) *FlameGraphComponent {
+	return newFlameGraphComponent(win, tr, nil, container.Some(TimeSpan{start, end}))
+}
+
+func newFlameGraphComponent(win *theme.Window, trace *ptrace.Trace, g *ptrace.Goroutine, timeRange container.Option[TimeSpan]) *FlameGraphComponent {
 	return &FlameGraphComponent{
-		g: g,
+		g:         g,
+		timeRange: timeRange,
 		fg: theme.NewFuture(win, func(cancelled <-chan struct{}) *widget.FlameGraph {
 			// Compute the sample duration by dividing the active time of all Ps by the total number of samples. This should
 			// closely approximate the inverse of the configured sampling rate.
@@ -70,6 +86,11 @@ func NewFlameGraphComponent(win *theme.Window, trace *ptrace.Trace, g *ptrace.Go
 			var fg widget.FlameGraph
 			do := func(samples []ptrace.EventID) {
 				for _, sample := range samples {
+					if r, ok := timeRange.Get(); ok {
+						if ts := trace.Event(sample).Ts; ts < r.Start || ts >= r.End {
+							continue
+						}
+					}
 					stack := trace.Stacks[trace.Event(sample).StkID]
 					var frames widget.FlamegraphSample
 					for i := len(stack) - 1; i >= 0; i-- {
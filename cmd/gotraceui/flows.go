@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"sort"
+
+	"github.com/joonho3020/gotraceui/clip"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+
+	"gioui.org/f32"
+)
+
+type flowKind uint8
+
+const (
+	// The source goroutine created the target goroutine.
+	flowCreate flowKind = iota
+	// The source goroutine unblocked the target goroutine.
+	flowUnblock
+)
+
+// flow describes a causal relationship between two goroutines. The target's span that results from the relationship
+// (the created goroutine's first span, or the unblocked goroutine's ready span) begins at the same time as the
+// source's event, which is why a flow only has a single timestamp.
+type flow struct {
+	Ts     trace.Timestamp
+	Source uint64
+	Target uint64
+	Kind   flowKind
+}
+
+// computeFlows returns all goroutine creation and unblock relationships in the trace, sorted by time. It returns false
+// if it was cancelled.
+func computeFlows(tr *Trace, cancelled <-chan struct{}) ([]flow, bool) {
+	var flows []flow
+	for j, g := range tr.Goroutines {
+		if j%1000 == 0 && TryRecv(cancelled) {
+			return nil, false
+		}
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			if i == 0 && s.State == ptrace.StateCreated {
+				if g.Parent != 0 {
+					flows = append(flows, flow{Ts: s.Start, Source: g.Parent, Target: g.ID, Kind: flowCreate})
+				}
+				continue
+			}
+			if i+1 == len(g.Spans) {
+				continue
+			}
+			if gid, ok := unblockedByGoroutine(tr, s); ok {
+				flows = append(flows, flow{Ts: g.Spans[i+1].Start, Source: gid, Target: g.ID, Kind: flowUnblock})
+			}
+		}
+	}
+	sort.Slice(flows, func(i, j int) bool { return flows[i].Ts < flows[j].Ts })
+	return flows, true
+}
+
+func (cv *Canvas) ToggleFlows() {
+	cv.timeline.displayFlows = !cv.timeline.displayFlows
+	if !cv.timeline.displayFlows && cv.flows != nil {
+		if _, ok := cv.flows.ResultNoWait(); !ok {
+			// Drop the pending computation. Because nothing reads the future anymore, it gets cancelled at the end of
+			// the frame.
+			cv.flows = nil
+		}
+	}
+}
+
+// drawFlows draws arrows from the goroutines that created or unblocked other goroutines to the goroutines they
+// created or unblocked. Only flows between two displayed goroutine timelines are drawn, and at least one of the two
+// has to be in view.
+func (cv *Canvas) drawFlows(win *theme.Window, gtx layout.Context) {
+	if !cv.timeline.displayFlows {
+		return
+	}
+	defer rtrace.StartRegion(context.Background(), "main.Canvas.drawFlows").End()
+
+	if cv.flows == nil {
+		tr := cv.trace
+		cv.flows = theme.NewFuture(win, func(cancelled <-chan struct{}) []flow {
+			flows, _ := computeFlows(tr, cancelled)
+			return flows
+		})
+	}
+	all, ok := cv.flows.ResultNoWait()
+	if !ok {
+		return
+	}
+
+	lo := sort.Search(len(all), func(i int) bool { return all[i].Ts >= cv.start })
+	hi := sort.Search(len(all), func(i int) bool { return all[i].Ts > cv.End() })
+	flows := all[lo:hi]
+	if len(flows) == 0 {
+		return
+	}
+
+	// Find the vertical extents of every displayed goroutine timeline.
+	type extent struct {
+		start, end float32
+		visible    bool
+	}
+	visStart, visEnd := cv.visibleTimelines(gtx)
+	cvy := cv.denormalizeY(gtx, cv.y)
+	extents := map[uint64]extent{}
+	for i, tl := range cv.timelines {
+		g, ok := tl.item.(*ptrace.Goroutine)
+		if !ok {
+			continue
+		}
+		start := 0
+		if i > 0 {
+			start = cv.timelineEnds[i-1]
+		}
+		extents[g.ID] = extent{
+			start:   float32(start - cvy),
+			end:     float32(cv.timelineEnds[i] - cvy),
+			visible: i >= visStart && i < visEnd,
+		}
+	}
+
+	type arrow struct {
+		x, y0, tip, dir float32
+	}
+	var arrows [2][]arrow
+	// When zoomed out, many flows between the same pair of goroutines collapse into the same pixel. Only draw one of
+	// them.
+	type pair struct{ source, target uint64 }
+	lastX := map[pair]int{}
+	for _, f := range flows {
+		if f.Source == f.Target {
+			continue
+		}
+		from, ok := extents[f.Source]
+		if !ok {
+			continue
+		}
+		to, ok := extents[f.Target]
+		if !ok {
+			continue
+		}
+		if !from.visible && !to.visible {
+			continue
+		}
+		x := round32(cv.tsToPx(f.Ts))
+		k := pair{f.Source, f.Target}
+		if px, ok := lastX[k]; ok && px == int(x) {
+			continue
+		}
+		lastX[k] = int(x)
+
+		// Connect the facing edges of the two timelines, so that the arrow doesn't cover the spans it connects.
+		var y0, tip, dir float32
+		if from.start < to.start {
+			y0, tip, dir = from.end, to.start, 1
+		} else {
+			y0, tip, dir = from.start, to.end, -1
+		}
+
+		arrows[f.Kind] = append(arrows[f.Kind], arrow{x, y0, tip, dir})
+	}
+
+	headLength := float32(gtx.Dp(6))
+	headWidth := float32(gtx.Dp(3))
+	for kind, c := range [2]colorIndex{flowCreate: colorFlowCreate, flowUnblock: colorFlowUnblock} {
+		if len(arrows[kind]) == 0 {
+			continue
+		}
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		for _, a := range arrows[kind] {
+			head := a.tip - a.dir*headLength
+			p.MoveTo(f32.Pt(a.x, a.y0))
+			p.LineTo(f32.Pt(a.x, head))
+			p.LineTo(f32.Pt(a.x-headWidth, head))
+			p.LineTo(f32.Pt(a.x, a.tip))
+			p.LineTo(f32.Pt(a.x+headWidth, head))
+			p.LineTo(f32.Pt(a.x, head))
+		}
+		theme.FillShape(win, gtx.Ops, colors[c], clip.Stroke{Path: p.End(), Width: float32(gtx.Dp(1))}.Op())
+	}
+}
@@ -117,6 +117,7 @@ func (fi *FunctionInfo) init(win *theme.Window) {
 	cfg := &widget.HistogramConfig{RejectOutliers: true, Bins: widget.DefaultHistogramBins}
 	fi.computeHistogram(win, cfg)
 	fi.goroutineList.HiddenColumns.Function = true
+	fi.goroutineList.Trace = fi.trace
 }
 
 func (fi *FunctionInfo) Title() string {
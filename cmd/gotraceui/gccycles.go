@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	rtrace "runtime/trace"
+	"time"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+func gcCyclesToCSV(tr *Trace, cycles []ptrace.GCCycle) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{
+		"Cycle", "Start", "Duration",
+		"Sweep termination STW", "Mark termination STW",
+		"Mark assist", "Assisting goroutines",
+		"Heap before", "Heap after", "Heap goal",
+	})
+
+	for i := range cycles {
+		c := &cycles[i]
+		fields := []string{
+			fmt.Sprintf("%d", c.Index+1),
+			fmt.Sprintf("%d", c.Start),
+			fmt.Sprintf("%d", c.Duration()),
+			fmt.Sprintf("%d", gcPause(tr, c.SweepTermination)),
+			fmt.Sprintf("%d", gcPause(tr, c.MarkTermination)),
+			fmt.Sprintf("%d", c.MarkAssist),
+			fmt.Sprintf("%d", len(c.Assists)),
+			fmt.Sprintf("%d", c.HeapBefore),
+			fmt.Sprintf("%d", c.HeapAfter),
+			fmt.Sprintf("%d", c.HeapGoal),
+		}
+		w.Write(fields)
+	}
+
+	w.Flush()
+	return buf.String()
+}
+
+// gcPause returns the duration of the STW pause with the given index into tr.STW, or zero if idx is -1.
+func gcPause(tr *Trace, idx int) time.Duration {
+	if idx == -1 {
+		return 0
+	}
+	return tr.STW[idx].Duration()
+}
+
+type gcCycleRow struct {
+	expanded bool
+	toggle   widget.Clickable
+	zoom     widget.Clickable
+	assists  widget.Clickable
+
+	text      Text
+	prevSpans []TextSpan
+}
+
+type GCCyclesComponent struct {
+	trace  *Trace
+	canvas *Canvas
+
+	cycles SortedIndices[ptrace.GCCycle, []ptrace.GCCycle]
+	// Per-row state, indexed by the index into cycles.Items.
+	rows []gcCycleRow
+
+	copyAsCSV widget.PrimaryClickable
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewGCCyclesComponent(tr *Trace, cv *Canvas) *GCCyclesComponent {
+	cycles := ptrace.ComputeGCCycles(tr.Trace)
+	gc := &GCCyclesComponent{
+		trace:  tr,
+		canvas: cv,
+		rows:   make([]gcCycleRow, len(cycles)),
+	}
+	gc.cycles.Reset(cycles)
+	return gc
+}
+
+// Title implements theme.Component.
+func (*GCCyclesComponent) Title() string {
+	return "GC cycles"
+}
+
+// Transition implements theme.Component.
+func (*GCCyclesComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*GCCyclesComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (gc *GCCyclesComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if gc.table != nil {
+		return
+	}
+	gc.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Cycle", Alignment: text.Start, Clickable: true},
+		{Name: "Start", Alignment: text.End, Clickable: true},
+		{Name: "Duration", Alignment: text.End, Clickable: true},
+		{Name: "Sweep term. STW", Alignment: text.End, Clickable: true},
+		{Name: "Mark term. STW", Alignment: text.End, Clickable: true},
+		{Name: "Mark assist", Alignment: text.End, Clickable: true},
+		{Name: "Assisting goroutines", Alignment: text.End, Clickable: true},
+		{Name: "Heap before", Alignment: text.End, Clickable: true},
+		{Name: "Heap after", Alignment: text.End, Clickable: true},
+		{Name: "Heap goal", Alignment: text.End, Clickable: true},
+		{Name: "Timeline", Alignment: text.Start},
+		{Name: "Assists", Alignment: text.Start},
+	}
+	gc.table.SetColumns(win, gtx, cols)
+}
+
+func (gc *GCCyclesComponent) sort() {
+	desc := gc.table.SortOrder == theme.SortDescending
+	switch gc.table.Columns[gc.table.SortedBy].Name {
+	case "Cycle", "Start":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(a.Index, b.Index, desc)
+		})
+	case "Duration":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(a.Duration(), b.Duration(), desc)
+		})
+	case "Sweep term. STW":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(gcPause(gc.trace, a.SweepTermination), gcPause(gc.trace, b.SweepTermination), desc)
+		})
+	case "Mark term. STW":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(gcPause(gc.trace, a.MarkTermination), gcPause(gc.trace, b.MarkTermination), desc)
+		})
+	case "Mark assist":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(a.MarkAssist, b.MarkAssist, desc)
+		})
+	case "Assisting goroutines":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(len(a.Assists), len(b.Assists), desc)
+		})
+	case "Heap before":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(a.HeapBefore, b.HeapBefore, desc)
+		})
+	case "Heap after":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(a.HeapAfter, b.HeapAfter, desc)
+		})
+	case "Heap goal":
+		gc.cycles.Sort(func(a, b ptrace.GCCycle) int {
+			return cmp(a.HeapGoal, b.HeapGoal, desc)
+		})
+	}
+}
+
+// cycleSpans returns the GC span of the cycle, belonging to the GC timeline.
+func (gc *GCCyclesComponent) cycleSpans(c *ptrace.GCCycle) Items[ptrace.Span] {
+	tl := gc.canvas.itemToTimeline[gc.canvas.gc]
+	return SimpleItems[ptrace.Span, any]{
+		items: gc.trace.GC[c.Index : c.Index+1],
+		container: ItemContainer{
+			Timeline: tl,
+			Track:    tl.tracks[0],
+		},
+		subslice: true,
+	}
+}
+
+func (gc *GCCyclesComponent) buildDetails(win *theme.Window, c *ptrace.GCCycle) []TextSpan {
+	// Display at most this many goroutines, to keep the text manageable.
+	const maxGoroutines = 50
+
+	tb := TextBuilder{Window: win}
+	pause := func(label string, idx int) {
+		tb.Bold(label)
+		if idx == -1 {
+			tb.Span("not in trace")
+		} else {
+			stw := &gc.trace.STW[idx]
+			reason := gc.trace.STWReason(gc.trace.Event(stw.Event).Args[trace.ArgSTWStartKind])
+			tb.Span(local.Sprintf("%s (%s), starting at ", roundDuration(stw.Duration()), reason))
+			tb.DefaultLink(formatTimestamp(nil, stw.Start), "GC cycle", stw.Start)
+		}
+		tb.Span("\n")
+	}
+	pause("Sweep termination pause: ", c.SweepTermination)
+	pause("Mark termination pause: ", c.MarkTermination)
+
+	tb.Bold("Heap goal: ")
+	tb.Span(local.Sprintf("%d bytes", c.HeapGoal))
+	if c.HeapGoal != 0 {
+		tb.Span(local.Sprintf(" (heap before cycle at %.2f%% of goal)", float64(c.HeapBefore)/float64(c.HeapGoal)*100))
+	}
+	tb.Span("\n\n")
+
+	if len(c.Assists) == 0 {
+		tb.Bold("No goroutines assisted the GC.")
+		return tb.Spans
+	}
+	tb.Bold("Mark assists:\n")
+	for i, a := range c.Assists {
+		if i == maxGoroutines {
+			tb.Span(local.Sprintf("and %d more", len(c.Assists)-maxGoroutines))
+			break
+		}
+		tb.DefaultLink(local.Sprintf("Goroutine %d", a.Goroutine.ID), "GC cycle", a.Goroutine)
+		if a.Goroutine.Function != nil {
+			tb.Span(local.Sprintf(" (%s)", a.Goroutine.Function.Fn))
+		}
+		tb.Span(local.Sprintf(": %s\n", roundDuration(a.Duration)))
+	}
+	return tb.Spans
+}
+
+// Layout implements theme.Component.
+func (gc *GCCyclesComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.GCCyclesComponent.Layout").End()
+
+	gc.initTable(win, gtx)
+	gc.table.Update(gtx)
+	if _, ok := gc.table.SortByClickedColumn(); ok {
+		gc.sort()
+	}
+
+	for gc.copyAsCSV.Clicked(gtx) {
+		win.AppWindow.WriteClipboard(gcCyclesToCSV(gc.trace, gc.cycles.Items))
+	}
+
+	gc.cellFormatter.Update(win, gtx)
+	for i := range gc.rows {
+		row := &gc.rows[i]
+		for {
+			if _, ok := row.toggle.Clicked(gtx); !ok {
+				break
+			}
+			row.expanded = !row.expanded
+		}
+		for {
+			if _, ok := row.zoom.Clicked(gtx); !ok {
+				break
+			}
+			win.EmitAction(&ZoomToSpansAction{Spans: gc.cycleSpans(&gc.cycles.Items[i])})
+		}
+		for {
+			if _, ok := row.assists.Clicked(gtx); !ok {
+				break
+			}
+			win.EmitAction(&OpenGCAssistsAction{Cycle: &gc.cycles.Items[i]})
+		}
+		if row.expanded {
+			for _, ev := range row.text.Update(gtx, row.prevSpans) {
+				handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+			}
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, rowIdx, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		c := gc.cycles.Ptr(rowIdx)
+		row := &gc.rows[gc.cycles.Order[rowIdx]]
+		switch colName := gc.table.Columns[col].Name; colName {
+		case "Cycle":
+			return layout.Rigids(gtx, layout.Horizontal,
+				func(gtx layout.Context) layout.Dimensions {
+					indicator := "▶ "
+					if row.expanded {
+						indicator = "▼ "
+					}
+					return row.toggle.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return gc.cellFormatter.Text(win, gtx, indicator)
+					})
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					link := gc.cellFormatter.Clicks.Grow()
+					link.Link = &SpansObjectLink{Spans: gc.cycleSpans(c)}
+					return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, local.Sprintf("%d", c.Index+1), win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+					})
+				},
+			)
+		case "Start":
+			return gc.cellFormatter.Timestamp(win, gtx, c.Start, "")
+		case "Duration":
+			return gc.cellFormatter.Duration(win, gtx, c.Duration(), false)
+		case "Sweep term. STW":
+			return gc.cellFormatter.Duration(win, gtx, gcPause(gc.trace, c.SweepTermination), false)
+		case "Mark term. STW":
+			return gc.cellFormatter.Duration(win, gtx, gcPause(gc.trace, c.MarkTermination), false)
+		case "Mark assist":
+			return gc.cellFormatter.Duration(win, gtx, c.MarkAssist, false)
+		case "Assisting goroutines":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gc.cellFormatter.Number(win, gtx, len(c.Assists))
+			})
+		case "Heap before":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gc.cellFormatter.Number(win, gtx, int(c.HeapBefore))
+			})
+		case "Heap after":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gc.cellFormatter.Number(win, gtx, int(c.HeapAfter))
+			})
+		case "Heap goal":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gc.cellFormatter.Number(win, gtx, int(c.HeapGoal))
+			})
+		case "Timeline":
+			return row.zoom.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, "Zoom to cycle", win.ColorMaterial(gtx, win.Theme.Palette.NavigationLink))
+			})
+		case "Assists":
+			return row.assists.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, "Show report", win.ColorMaterial(gtx, win.Theme.Palette.Link))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	rowFn := func(win *theme.Window, gtx layout.Context, rowIdx int) layout.Dimensions {
+		row := &gc.rows[gc.cycles.Order[rowIdx]]
+		if !row.expanded {
+			return theme.TableSimpleRow(gc.table).Layout(win, gtx, rowIdx, cellFn)
+		}
+
+		return layout.Rigids(gtx, layout.Vertical,
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableSimpleRow(gc.table).Layout(win, gtx, rowIdx, cellFn)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableExpandedRow(gc.table).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(5).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						row.text.Reset(win.Theme)
+						row.prevSpans = gc.buildDetails(win, gc.cycles.Ptr(rowIdx))
+						return row.text.Layout(win, gtx, row.prevSpans)
+					})
+				})
+			},
+		)
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.Button(win.Theme, &gc.copyAsCSV.Clickable, "Copy as CSV").Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			if gc.cycles.Len() == 0 {
+				return theme.Label(win.Theme, "The trace contains no GC cycles.").Layout(win, gtx)
+			}
+			return theme.FairlySimpleTable(win, gtx, gc.table, &gc.scrollState, gc.cycles.Len(), rowFn)
+		},
+	)
+}
@@ -904,6 +904,7 @@ func NewGoroutineInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, g *ptrace.G
 	cfg := SpansInfoConfig{
 		Title:      title,
 		Stacktrace: stacktrace,
+		Object:     g,
 		Navigations: SpansInfoConfigNavigations{
 			Scroll: struct {
 				ButtonLabel string
@@ -946,15 +947,23 @@ func NewGoroutineInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, g *ptrace.G
 }
 
 type GoroutineList struct {
-	Goroutines    SortedIndices[*ptrace.Goroutine, []*ptrace.Goroutine]
+	Goroutines SortedIndices[*ptrace.Goroutine, []*ptrace.Goroutine]
+	// The trace the goroutines belong to. The processor affinity column is only available if this is set.
+	Trace         *Trace
 	HiddenColumns struct {
 		ID        bool
 		Function  bool
 		StartTime bool
 		EndTime   bool
 		Duration  bool
+		Affinity  bool
 	}
 
+	// Computed the first time the processor affinity column is displayed.
+	migrations *theme.Future[*migrationData]
+	// Migration statistics indexed by Goroutine.SeqID, or nil if migrations isn't done yet.
+	goroutineMigrations []ptrace.GoroutineMigrations
+
 	table         *theme.Table
 	scrollState   theme.YScrollableListState
 	cellFormatter CellFormatter
@@ -1016,7 +1025,24 @@ func (gl *GoroutineList) setGoroutines(gtx layout.Context, gs []*ptrace.Goroutin
 
 			return cmp(di, dj, gl.table.SortOrder == theme.SortDescending)
 		})
+	case "P affinity":
+		gl.Goroutines.Sort(func(gi, gj *ptrace.Goroutine) int {
+			return cmp(gl.affinity(gi), gl.affinity(gj), gl.table.SortOrder == theme.SortDescending)
+		})
+	}
+}
+
+// affinity returns the goroutine's processor affinity, or -1 if the goroutine never ran or the affinities haven't been
+// computed yet.
+func (gl *GoroutineList) affinity(g *ptrace.Goroutine) float64 {
+	if gl.goroutineMigrations == nil {
+		return -1
 	}
+	gm := &gl.goroutineMigrations[g.SeqID]
+	if gm.Runs == 0 {
+		return -1
+	}
+	return gm.Affinity
 }
 
 func (gs *GoroutineList) initTable(win *theme.Window, gtx layout.Context) {
@@ -1060,6 +1086,13 @@ func (gs *GoroutineList) initTable(win *theme.Window, gtx layout.Context) {
 			Clickable: true,
 		})
 	}
+	if !gs.HiddenColumns.Affinity && gs.Trace != nil {
+		cols = append(cols, theme.Column{
+			Name:      "P affinity",
+			Alignment: text.End,
+			Clickable: true,
+		})
+	}
 	gs.table.SetColumns(win, gtx, cols)
 	gs.table.SortedBy = 0
 	gs.table.SortOrder = theme.SortAscending
@@ -1113,6 +1146,22 @@ func (gs *GoroutineList) Layout(win *theme.Window, gtx layout.Context) layout.Di
 	gs.Update(gtx)
 	gs.cellFormatter.Update(win, gtx)
 
+	if gs.Trace != nil && !gs.HiddenColumns.Affinity && gs.goroutineMigrations == nil {
+		if gs.migrations == nil {
+			tr := gs.Trace
+			gs.migrations = theme.NewFuture(win, func(cancelled <-chan struct{}) *migrationData {
+				return tr.Migrations()
+			})
+		}
+		if ms, ok := gs.migrations.ResultNoWait(); ok {
+			gs.goroutineMigrations = ms.goroutines
+			if gs.table.Columns[gs.table.SortedBy].Name == "P affinity" {
+				// Sort again now that we know the affinities.
+				gs.setGoroutines(gtx, gs.Goroutines.Items)
+			}
+		}
+	}
+
 	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
 		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
 
@@ -1165,6 +1214,19 @@ func (gs *GoroutineList) Layout(win *theme.Window, gtx layout.Context) layout.Di
 			}
 
 			return gs.cellFormatter.Duration(win, gtx, d, approx)
+		case "P affinity":
+			if gs.goroutineMigrations == nil {
+				return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+					return gs.cellFormatter.Text(win, gtx, "computing…")
+				})
+			}
+			a := gs.affinity(g)
+			if a < 0 {
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			}
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gs.cellFormatter.Text(win, gtx, formatAffinity(a))
+			})
 		default:
 			panic(colName)
 		}
@@ -1183,18 +1245,24 @@ func (gs *GoroutineList) Layout(win *theme.Window, gtx layout.Context) layout.Di
 
 type GoroutinesComponent struct {
 	list GoroutineList
+	// An optional title, used instead of the default one.
+	title string
 }
 
-func NewGoroutinesComponent(gs []*ptrace.Goroutine) *GoroutinesComponent {
+func NewGoroutinesComponent(tr *Trace, gs []*ptrace.Goroutine) *GoroutinesComponent {
 	return &GoroutinesComponent{
 		list: GoroutineList{
 			Goroutines: NewSortedIndices(gs),
+			Trace:      tr,
 		},
 	}
 }
 
 // Title implements theme.Component.
-func (*GoroutinesComponent) Title() string {
+func (gc *GoroutinesComponent) Title() string {
+	if gc.title != "" {
+		return gc.title
+	}
 	return "Goroutines"
 }
 
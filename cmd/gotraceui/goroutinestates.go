@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+)
+
+// The number of buckets used for the plot of goroutines by state.
+const goroutineStatePlotBuckets = 2000
+
+var stateGroupColors = [ptrace.NumStateGroups]colorIndex{
+	ptrace.StateGroupRunning: colorStateActive,
+	ptrace.StateGroupReady:   colorStateReady,
+	ptrace.StateGroupBlocked: colorStateBlocked,
+	ptrace.StateGroupGC:      colorStateGC,
+}
+
+// newGoroutineStatePlot returns a stacked plot of the number of goroutines in each state group. Each point is the
+// group's peak within its bucket, so the stack can briefly exceed the number of goroutines that were alive at any one
+// time. Clicking on the plot opens the goroutines that were in the clicked state at the clicked time.
+func newGoroutineStatePlot(tr *ptrace.Trace) *Plot {
+	counts := ptrace.StateGroupCounts(tr, goroutineStatePlotBuckets)
+
+	// Turn the counts into cumulative sums, so that later groups are stacked on top of earlier ones. The plot draws
+	// series in order, so the largest sum has to come first.
+	pl := &Plot{
+		Name:    "Goroutines",
+		Unit:    "goroutines",
+		Stacked: true,
+	}
+	var series [ptrace.NumStateGroups]PlotSeries
+	var prev []ptrace.Point
+	for group := ptrace.StateGroup(0); group < ptrace.NumStateGroups; group++ {
+		points := counts[group]
+		if prev != nil {
+			for i := range points {
+				points[i].Value += prev[i].Value
+			}
+		}
+		prev = points
+		series[ptrace.NumStateGroups-1-group] = PlotSeries{
+			Name:   group.String(),
+			Points: points,
+			Style:  PlotFilled,
+			Color:  colors[stateGroupColors[group]],
+		}
+	}
+	pl.AddSeries(series[:]...)
+
+	pl.OnClick = func(ts trace.Timestamp, s *PlotSeries) theme.Action {
+		if s == nil {
+			return nil
+		}
+		for group := ptrace.StateGroup(0); group < ptrace.NumStateGroups; group++ {
+			if group.String() != s.Name {
+				continue
+			}
+			gs := ptrace.GoroutinesInStateGroup(tr, ts, group)
+			if len(gs) == 0 {
+				return nil
+			}
+			return &OpenGoroutinesAction{
+				Title:      local.Sprintf("%s goroutines at %s", group, formatTimestamp(nil, ts)),
+				Goroutines: gs,
+			}
+		}
+		return nil
+	}
+
+	return pl
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	rtrace "runtime/trace"
+	"time"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+// goroutineChildren maps goroutine IDs to the goroutines they created.
+func goroutineChildren(tr *Trace) map[uint64][]*ptrace.Goroutine {
+	children := map[uint64][]*ptrace.Goroutine{}
+	for _, g := range tr.Goroutines {
+		if g.Parent != 0 {
+			children[g.Parent] = append(children[g.Parent], g)
+		}
+	}
+	return children
+}
+
+// goroutineSubtree returns root and all goroutines that were transitively created by it.
+func goroutineSubtree(tr *Trace, root *ptrace.Goroutine) []*ptrace.Goroutine {
+	children := goroutineChildren(tr)
+	out := []*ptrace.Goroutine{root}
+	for i := 0; i < len(out); i++ {
+		out = append(out, children[out[i].ID]...)
+	}
+	return out
+}
+
+type goroutineTreeNode struct {
+	g        *ptrace.Goroutine
+	depth    int
+	children []*goroutineTreeNode
+
+	// Number of goroutines in the subtree, including g itself.
+	size int
+	// Aggregated running and blocked time of the subtree.
+	running time.Duration
+	blocked time.Duration
+
+	expanded    bool
+	toggle      widget.Clickable
+	showSubtree widget.Clickable
+}
+
+func buildGoroutineTree(tr *Trace, cancelled <-chan struct{}) []*goroutineTreeNode {
+	nodes := make(map[uint64]*goroutineTreeNode, len(tr.Goroutines))
+	for _, g := range tr.Goroutines {
+		stats := ptrace.ComputeStatistics(ptrace.ToSpans(g.Spans))
+		nodes[g.ID] = &goroutineTreeNode{
+			g:       g,
+			size:    1,
+			running: stats.Running(),
+			blocked: stats.Blocked(),
+		}
+
+		select {
+		case <-cancelled:
+			return nil
+		default:
+		}
+	}
+
+	var roots []*goroutineTreeNode
+	// tr.Goroutines is sorted by ID, which makes children sorted by ID, too.
+	for _, g := range tr.Goroutines {
+		n := nodes[g.ID]
+		if parent, ok := nodes[g.Parent]; ok && g.Parent != 0 {
+			parent.children = append(parent.children, n)
+		} else {
+			// Goroutines without a parent, or whose parent isn't part of the trace.
+			roots = append(roots, n)
+		}
+	}
+
+	var aggregate func(n *goroutineTreeNode, depth int)
+	aggregate = func(n *goroutineTreeNode, depth int) {
+		n.depth = depth
+		for _, c := range n.children {
+			aggregate(c, depth+1)
+			n.size += c.size
+			n.running += c.running
+			n.blocked += c.blocked
+		}
+	}
+	for _, root := range roots {
+		aggregate(root, 0)
+	}
+
+	return roots
+}
+
+type GoroutineTreeComponent struct {
+	trace *Trace
+	roots *theme.Future[[]*goroutineTreeNode]
+
+	// The currently visible nodes, in depth-first order.
+	rows  []*goroutineTreeNode
+	dirty bool
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewGoroutineTreeComponent(win *theme.Window, tr *Trace) *GoroutineTreeComponent {
+	return &GoroutineTreeComponent{
+		trace: tr,
+		roots: theme.NewFuture(win, func(cancelled <-chan struct{}) []*goroutineTreeNode {
+			return buildGoroutineTree(tr, cancelled)
+		}),
+		dirty: true,
+	}
+}
+
+// Title implements theme.Component.
+func (*GoroutineTreeComponent) Title() string {
+	return "Goroutine hierarchy"
+}
+
+// Transition implements theme.Component.
+func (*GoroutineTreeComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*GoroutineTreeComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (gt *GoroutineTreeComponent) flatten(roots []*goroutineTreeNode) {
+	gt.rows = gt.rows[:0]
+	var visit func(n *goroutineTreeNode)
+	visit = func(n *goroutineTreeNode) {
+		gt.rows = append(gt.rows, n)
+		if n.expanded {
+			for _, c := range n.children {
+				visit(c)
+			}
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+}
+
+func (gt *GoroutineTreeComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if gt.table != nil {
+		return
+	}
+	gt.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Goroutine", Alignment: text.Start},
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Goroutines in subtree", Alignment: text.End},
+		{Name: "Running", Alignment: text.End},
+		{Name: "Blocked", Alignment: text.End},
+		{Name: "Timelines", Alignment: text.Start},
+	}
+	gt.table.SetColumns(win, gtx, cols)
+}
+
+// Layout implements theme.Component.
+func (gt *GoroutineTreeComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.GoroutineTreeComponent.Layout").End()
+
+	roots, ok := gt.roots.Result()
+	if !ok {
+		return theme.Label(win.Theme, "Computing goroutine hierarchy…").Layout(win, gtx)
+	}
+
+	gt.initTable(win, gtx)
+	gt.table.Update(gtx)
+	gt.cellFormatter.Update(win, gtx)
+
+	for _, n := range gt.rows {
+		for {
+			if _, ok := n.toggle.Clicked(gtx); !ok {
+				break
+			}
+			n.expanded = !n.expanded
+			gt.dirty = true
+		}
+		for {
+			if _, ok := n.showSubtree.Clicked(gtx); !ok {
+				break
+			}
+			win.EmitAction(&ShowGoroutineSubtreeAction{Goroutine: n.g, Provenance: "Goroutine hierarchy"})
+		}
+	}
+	if gt.dirty {
+		gt.flatten(roots)
+		gt.dirty = false
+	}
+
+	const indentDp unit.Dp = 15
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		n := gt.rows[row]
+		switch colName := gt.table.Columns[col].Name; colName {
+		case "Goroutine":
+			return layout.Rigids(gtx, layout.Horizontal,
+				layout.Spacer{Width: indentDp * unit.Dp(n.depth)}.Layout,
+				func(gtx layout.Context) layout.Dimensions {
+					indicator := "  "
+					if len(n.children) != 0 {
+						if n.expanded {
+							indicator = "▼ "
+						} else {
+							indicator = "▶ "
+						}
+					}
+					return n.toggle.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return gt.cellFormatter.Text(win, gtx, indicator)
+					})
+				},
+				func(gtx layout.Context) layout.Dimensions {
+					link := gt.cellFormatter.Clicks.Grow()
+					link.Link = &GoroutineObjectLink{Goroutine: n.g, Provenance: "Goroutine hierarchy"}
+					return link.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, local.Sprintf("%d", n.g.ID), win.ColorMaterial(gtx, win.Theme.Palette.OpenLink))
+					})
+				},
+			)
+		case "Function":
+			return gt.cellFormatter.Function(win, gtx, n.g.Function)
+		case "Goroutines in subtree":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gt.cellFormatter.Number(win, gtx, n.size)
+			})
+		case "Running":
+			return gt.cellFormatter.Duration(win, gtx, n.running, false)
+		case "Blocked":
+			return gt.cellFormatter.Duration(win, gtx, n.blocked, false)
+		case "Timelines":
+			return n.showSubtree.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, "Show only subtree", win.ColorMaterial(gtx, win.Theme.Palette.Link))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	return theme.SimpleTable(win, gtx, gt.table, &gt.scrollState, len(gt.rows), cellFn)
+}
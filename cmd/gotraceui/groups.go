@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+)
+
+type timelineGrouping uint8
+
+const (
+	timelineGroupingNone timelineGrouping = iota
+	timelineGroupingFunction
+	timelineGroupingPackage
+	timelineGroupingParent
+	timelineGroupingTask
+
+	timelineGroupingLast
+)
+
+var timelineGroupingNames = [timelineGroupingLast]string{
+	timelineGroupingNone:     "none",
+	timelineGroupingFunction: "function",
+	timelineGroupingPackage:  "package",
+	timelineGroupingParent:   "parent goroutine",
+	timelineGroupingTask:     "task",
+}
+
+// timelineGroups holds the state of grouped goroutine timelines. When goroutines are grouped, Canvas.timelines consists
+// of all timelines that aren't goroutines and all pinned timelines, followed by a header per group and the members of
+// expanded groups.
+type timelineGroups struct {
+	by timelineGrouping
+	// The timelines that don't get grouped, which are displayed above the groups.
+	others []*Timeline
+	list   []*timelineGroup
+	byItem map[any]*timelineGroup
+	// The keys of expanded groups. Groups are collapsed by default. This persists when the groups are recomputed
+	// because the set of displayed timelines changed, but not when the grouping changes.
+	expanded map[string]struct{}
+}
+
+type timelineGroup struct {
+	key     string
+	members []*Timeline
+	header  *Timeline
+
+	mu sync.Mutex
+	// The union of the members' active spans, computed lazily.
+	activity []ptrace.Span
+}
+
+func (grp *timelineGroup) goroutines() []*ptrace.Goroutine {
+	gs := make([]*ptrace.Goroutine, len(grp.members))
+	for i, tl := range grp.members {
+		gs[i] = tl.item.(*ptrace.Goroutine)
+	}
+	return gs
+}
+
+// functionPackage returns the import path of the package that fn, a fully qualified function name, belongs to.
+func functionPackage(fn string) string {
+	slash := strings.LastIndexByte(fn, '/')
+	if dot := strings.IndexByte(fn[slash+1:], '.'); dot != -1 {
+		return fn[:slash+1+dot]
+	}
+	return fn
+}
+
+// goroutineTask returns the ID of the task of the earliest user region of g, or 0 if none of its user regions belong
+// to a task.
+func goroutineTask(tr *Trace, g *ptrace.Goroutine) uint64 {
+	var (
+		id    uint64
+		start trace.Timestamp
+	)
+	for _, regions := range g.UserRegions {
+		for i := range regions {
+			s := &regions[i]
+			if id != 0 && s.Start >= start {
+				break
+			}
+			if taskID := tr.Event(s.Event).Args[trace.ArgUserRegionTaskID]; taskID != 0 {
+				id = taskID
+				start = s.Start
+				break
+			}
+		}
+	}
+	return id
+}
+
+func goroutineGroupKey(tr *Trace, by timelineGrouping, g *ptrace.Goroutine) string {
+	switch by {
+	case timelineGroupingFunction:
+		if g.Function.Fn == "" {
+			return "unknown function"
+		}
+		return g.Function.Fn
+	case timelineGroupingPackage:
+		if g.Function.Fn == "" {
+			return "unknown package"
+		}
+		return functionPackage(g.Function.Fn)
+	case timelineGroupingParent:
+		if g.Parent == 0 {
+			return "unknown parent"
+		}
+		return local.Sprintf("children of goroutine %d", g.Parent)
+	case timelineGroupingTask:
+		id := goroutineTask(tr, g)
+		if id == 0 {
+			return "no task"
+		}
+		if task := tr.Task(id); !task.Stub() {
+			return local.Sprintf("task %d: %s", id, task.Name)
+		}
+		return local.Sprintf("task %d", id)
+	default:
+		panic(fmt.Sprintf("unexpected grouping %d", by))
+	}
+}
+
+// groupActivity computes the intervals during which at least one of the goroutines was running.
+func groupActivity(gs []*ptrace.Goroutine, cancelled <-chan struct{}) ([]ptrace.Span, bool) {
+	defer rtrace.StartRegion(context.Background(), "main.groupActivity").End()
+
+	var spans []ptrace.Span
+	for i, g := range gs {
+		if i%1000 == 0 && TryRecv(cancelled) {
+			return nil, false
+		}
+		for j := range g.Spans {
+			s := &g.Spans[j]
+			switch s.State {
+			case ptrace.StateActive, ptrace.StateGCIdle, ptrace.StateGCDedicated, ptrace.StateGCFractional,
+				ptrace.StateGCMarkAssist, ptrace.StateGCSweep:
+				spans = append(spans, ptrace.Span{Start: s.Start, End: s.End, State: ptrace.StateActive})
+			}
+		}
+	}
+	if len(gs) > 1 {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	}
+
+	// Merge overlapping and adjacent spans.
+	out := spans[:0]
+	for _, s := range spans {
+		if n := len(out); n > 0 && s.Start <= out[n-1].End {
+			out[n-1].End = max(out[n-1].End, s.End)
+		} else {
+			out = append(out, s)
+		}
+	}
+	return out, true
+}
+
+func (cv *Canvas) newTimelineGroupHeader(grp *timelineGroup) *Timeline {
+	tl := &Timeline{
+		cv:        cv,
+		item:      grp,
+		shortName: grp.key,
+		widgetTooltip: func(win *theme.Window, gtx layout.Context, tl *Timeline) layout.Dimensions {
+			label := local.Sprintf("Goroutines grouped by %s: %s\n%d goroutines\nClick to expand or collapse the group.",
+				timelineGroupingNames[cv.groups.by], grp.key, len(grp.members))
+			return theme.Tooltip(win.Theme, label).Layout(win, gtx)
+		},
+	}
+
+	track := NewTrack(tl, TrackKindUnspecified)
+	track.Start = grp.members[0].tracks[0].Start
+	track.End = grp.members[0].tracks[0].End
+	for _, m := range grp.members[1:] {
+		track.Start = min(track.Start, m.tracks[0].Start)
+		track.End = max(track.End, m.tracks[0].End)
+	}
+	track.compute = func(track *Track, cancelled <-chan struct{}) Items[ptrace.Span] {
+		grp.mu.Lock()
+		defer grp.mu.Unlock()
+		if grp.activity == nil {
+			spans, ok := groupActivity(grp.goroutines(), cancelled)
+			if !ok {
+				return nil
+			}
+			grp.activity = spans
+		}
+		return SimpleItems[ptrace.Span, any]{
+			items: grp.activity,
+			container: ItemContainer{
+				Timeline: tl,
+				Track:    track,
+			},
+			subslice: true,
+		}
+	}
+	track.hideEventMarkers = true
+	tl.tracks = []*Track{track}
+	return tl
+}
+
+func (cv *Canvas) updateGroupHeaderLabel(grp *timelineGroup) {
+	indicator := "▶ "
+	if _, ok := cv.groups.expanded[grp.key]; ok {
+		indicator = "▼ "
+	}
+	grp.header.label = indicator + local.Sprintf("%s (%d goroutines)", grp.key, len(grp.members))
+}
+
+// computeTimelineGroups groups the goroutine timelines in tls and displays the result. Pinned timelines don't get
+// grouped.
+func (cv *Canvas) computeTimelineGroups(tls []*Timeline) {
+	defer rtrace.StartRegion(context.Background(), "main.Canvas.computeTimelineGroups").End()
+
+	cv.groups.others = nil
+	cv.groups.list = nil
+	cv.groups.byItem = map[any]*timelineGroup{}
+
+	byKey := map[string]*timelineGroup{}
+	for _, tl := range tls {
+		g, ok := tl.item.(*ptrace.Goroutine)
+		if !ok || cv.TimelinePinned(tl) {
+			cv.groups.others = append(cv.groups.others, tl)
+			continue
+		}
+		key := goroutineGroupKey(cv.trace, cv.groups.by, g)
+		grp, ok := byKey[key]
+		if !ok {
+			grp = &timelineGroup{key: key}
+			byKey[key] = grp
+			cv.groups.list = append(cv.groups.list, grp)
+		}
+		grp.members = append(grp.members, tl)
+		cv.groups.byItem[g] = grp
+	}
+	for _, grp := range cv.groups.list {
+		grp.header = cv.newTimelineGroupHeader(grp)
+		cv.updateGroupHeaderLabel(grp)
+	}
+
+	cv.flattenTimelineGroups()
+}
+
+// flattenTimelineGroups updates the displayed timelines to reflect which groups are collapsed.
+func (cv *Canvas) flattenTimelineGroups() {
+	tls := make([]*Timeline, 0, len(cv.groups.others)+len(cv.groups.list))
+	tls = append(tls, cv.groups.others...)
+	for _, grp := range cv.groups.list {
+		tls = append(tls, grp.header)
+		if _, ok := cv.groups.expanded[grp.key]; ok {
+			tls = append(tls, grp.members...)
+		}
+	}
+
+	cv.timelines = tls
+	cv.timelinesGeneration++
+	// Invalidate cached positions and heights, which assume a fixed set of timelines.
+	cv.timelineEnds = cv.timelineEnds[:0]
+	cv.cachedCanvasHeight.height = 0
+}
+
+// TimelineGrouping returns how goroutine timelines are currently grouped.
+func (cv *Canvas) TimelineGrouping() timelineGrouping {
+	return cv.groups.by
+}
+
+// SetTimelineGrouping groups goroutine timelines by the given criterion. All groups start out collapsed.
+func (cv *Canvas) SetTimelineGrouping(by timelineGrouping) {
+	if by == cv.groups.by {
+		return
+	}
+	cv.groups = timelineGroups{by: by}
+	if by != timelineGroupingNone {
+		cv.groups.expanded = map[string]struct{}{}
+	}
+	cv.setDisplayedTimelines()
+}
+
+// ToggleTimelineGroup expands a collapsed group or collapses an expanded one. The vertical position of the canvas is
+// kept, so that the group header stays in place.
+func (cv *Canvas) ToggleTimelineGroup(gtx layout.Context, grp *timelineGroup) {
+	// Only the timelines below the header change, so keeping the pixel offset keeps the header in place. The normalized
+	// offset doesn't, because it depends on the canvas's height.
+	y := cv.denormalizeY(gtx, cv.y)
+	if _, ok := cv.groups.expanded[grp.key]; ok {
+		delete(cv.groups.expanded, grp.key)
+	} else {
+		cv.groups.expanded[grp.key] = struct{}{}
+	}
+	cv.updateGroupHeaderLabel(grp)
+	cv.flattenTimelineGroups()
+	cv.cancelNavigation()
+	cv.y = cv.normalizeY(gtx, y)
+}
+
+// UngroupedTimelines returns the displayed timelines as they would be displayed without grouping, that is without group
+// headers and including the members of collapsed groups.
+func (cv *Canvas) UngroupedTimelines() []*Timeline {
+	return cv.ungroupedTimelines
+}
+
+// expandGroupOf expands the group containing the timeline of obj, if it is collapsed.
+func (cv *Canvas) expandGroupOf(gtx layout.Context, obj any) {
+	grp, ok := cv.groups.byItem[obj]
+	if !ok {
+		return
+	}
+	if _, ok := cv.groups.expanded[grp.key]; ok {
+		return
+	}
+	cv.ToggleTimelineGroup(gtx, grp)
+}
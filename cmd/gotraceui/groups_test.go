@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+
+	"gioui.org/unit"
+)
+
+func TestToggleTimelineGroupKeepsHeader(t *testing.T) {
+	gtx := layout.Context{
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(1000, 200)),
+	}
+
+	cv := &Canvas{trace: &Trace{Trace: &ptrace.Trace{}}}
+	for i := 0; i < 30; i++ {
+		g := &ptrace.Goroutine{
+			ID:       uint64(i + 1),
+			Function: &ptrace.Function{Frame: trace.Frame{Fn: fmt.Sprintf("main.fn%d", i/10)}},
+		}
+		tl := &Timeline{cv: cv, item: g}
+		tl.tracks = []*Track{NewTrack(tl, TrackKindUnspecified)}
+		cv.allTimelines = append(cv.allTimelines, tl)
+	}
+	cv.SetTimelineGrouping(timelineGroupingFunction)
+	if len(cv.groups.list) != 3 {
+		t.Fatalf("got %d groups, want 3", len(cv.groups.list))
+	}
+	first, last := cv.groups.list[0], cv.groups.list[2]
+	cv.ToggleTimelineGroup(gtx, first)
+	cv.ToggleTimelineGroup(gtx, last)
+
+	// headerY returns the position of the group's header relative to the top of the viewport.
+	headerY := func(grp *timelineGroup) int {
+		y := 0
+		for _, tl := range cv.timelines {
+			if tl == grp.header {
+				return y - cv.denormalizeY(gtx, cv.y)
+			}
+			y += tl.Height(gtx, cv)
+		}
+		t.Fatalf("header of %q isn't displayed", grp.key)
+		return 0
+	}
+
+	// Scroll to the last group, so that the first group is above the viewport.
+	cv.y = cv.normalizeY(gtx, headerY(last)-10)
+	for _, grp := range []*timelineGroup{first, last} {
+		for i, action := range []string{"collapsing", "expanding"} {
+			before := headerY(grp)
+			cv.ToggleTimelineGroup(gtx, grp)
+			if after := headerY(grp); after != before {
+				t.Errorf("%s %q moved its header from %d to %d", action, grp.key, before, after)
+			}
+			if i == 0 && grp == first && before >= 0 {
+				t.Fatalf("header of %q should be above the viewport, is at %d", grp.key, before)
+			}
+		}
+	}
+}
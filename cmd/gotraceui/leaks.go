@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"strings"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// Goroutines in the runtime and standard library that block for the entire lifetime of the process, in addition to
+// those covered by ptrace.BlockedIsInactive.
+var idleRuntimeFunctions = map[string]struct{}{
+	"os/signal.loop":                 {},
+	"os/signal.signal_recv":          {},
+	"runtime.ensureSigM.func1":       {},
+	"runtime/trace.Start.func1":      {},
+	"runtime.timerproc":              {},
+	"runtime.(*wakeableSleep).sleep": {},
+}
+
+func isIdleRuntimeGoroutine(g *ptrace.Goroutine) bool {
+	if g.Function == nil {
+		return false
+	}
+	if ptrace.BlockedIsInactive(g.Function.Fn) {
+		return true
+	}
+	_, ok := idleRuntimeFunctions[g.Function.Fn]
+	return ok
+}
+
+// formatStack formats a stack in the same style as the runtime's tracebacks.
+func formatStack(tr *Trace, stkID uint32) string {
+	stk := tr.Stacks[stkID]
+	sb := strings.Builder{}
+	for _, f := range stk {
+		frame := tr.PCs[f]
+		fmt.Fprintf(&sb, "%s\n        %s:%d\n", frame.Fn, frame.File, frame.Line)
+	}
+	s := sb.String()
+	if len(s) > 0 && s[len(s)-1] == '\n' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// blockingFunction returns the first function in the stack that isn't part of the runtime. This is usually more useful
+// than the top of the stack, which will be a function like runtime.chanrecv1 or runtime.selectgo.
+func blockingFunction(tr *Trace, stkID uint32) string {
+	stk := tr.Stacks[stkID]
+	for _, f := range stk {
+		if fn := tr.PCs[f].Fn; !strings.HasPrefix(fn, "runtime.") {
+			return fn
+		}
+	}
+	if len(stk) != 0 {
+		return tr.PCs[stk[0]].Fn
+	}
+	return ""
+}
+
+type possibleLeakRow struct {
+	expanded bool
+	toggle   widget.Clickable
+
+	text      Text
+	prevSpans []TextSpan
+}
+
+type PossibleLeaksComponent struct {
+	trace *Trace
+
+	filters struct {
+		hideIdleRuntime widget.Bool
+		hideIO          widget.Bool
+	}
+
+	leaks SortedIndices[ptrace.PossibleLeak, []ptrace.PossibleLeak]
+	// Per-row state, indexed by the index into leaks.Items.
+	rows []possibleLeakRow
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewPossibleLeaksComponent(tr *Trace) *PossibleLeaksComponent {
+	plc := &PossibleLeaksComponent{
+		trace: tr,
+	}
+	plc.filters.hideIdleRuntime.Value = true
+	plc.compute()
+	return plc
+}
+
+func (plc *PossibleLeaksComponent) compute() {
+	exclude := func(g *ptrace.Goroutine) bool {
+		if plc.filters.hideIdleRuntime.Value && isIdleRuntimeGoroutine(g) {
+			return true
+		}
+		if plc.filters.hideIO.Value {
+			switch g.Spans[len(g.Spans)-1].State {
+			case ptrace.StateBlockedNet, ptrace.StateBlockedSyscall:
+				return true
+			}
+		}
+		return false
+	}
+
+	leaks := ptrace.ComputePossibleLeaks(plc.trace.Trace, exclude)
+	plc.leaks.Reset(leaks)
+	plc.rows = make([]possibleLeakRow, len(leaks))
+	plc.sort()
+}
+
+func (plc *PossibleLeaksComponent) sort() {
+	if plc.table == nil {
+		// The order returned by ComputePossibleLeaks matches our default sort order.
+		return
+	}
+
+	desc := plc.table.SortOrder == theme.SortDescending
+	switch plc.table.Columns[plc.table.SortedBy].Name {
+	case "Goroutines":
+		plc.leaks.Sort(func(a, b ptrace.PossibleLeak) int {
+			return cmp(len(a.Goroutines), len(b.Goroutines), desc)
+		})
+	case "Function":
+		plc.leaks.Sort(func(a, b ptrace.PossibleLeak) int {
+			var fn1, fn2 string
+			if a.Goroutines[0].Function != nil {
+				fn1 = a.Goroutines[0].Function.Fn
+			}
+			if b.Goroutines[0].Function != nil {
+				fn2 = b.Goroutines[0].Function.Fn
+			}
+			return cmp(fn1, fn2, desc)
+		})
+	case "State":
+		plc.leaks.Sort(func(a, b ptrace.PossibleLeak) int {
+			return cmp(stateNamesCapitalized[a.State], stateNamesCapitalized[b.State], desc)
+		})
+	case "Blocked in":
+		plc.leaks.Sort(func(a, b ptrace.PossibleLeak) int {
+			return cmp(blockingFunction(plc.trace, a.BlockingStack), blockingFunction(plc.trace, b.BlockingStack), desc)
+		})
+	case "Min. blocked":
+		plc.leaks.Sort(func(a, b ptrace.PossibleLeak) int {
+			return cmp(a.MinBlocked, b.MinBlocked, desc)
+		})
+	case "Max. blocked":
+		plc.leaks.Sort(func(a, b ptrace.PossibleLeak) int {
+			return cmp(a.MaxBlocked, b.MaxBlocked, desc)
+		})
+	}
+}
+
+func (plc *PossibleLeaksComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if plc.table != nil {
+		return
+	}
+	plc.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Goroutines", Alignment: text.End, Clickable: true},
+		{Name: "Function", Alignment: text.Start, Clickable: true},
+		{Name: "State", Alignment: text.Start, Clickable: true},
+		{Name: "Blocked in", Alignment: text.Start, Clickable: true},
+		{Name: "Min. blocked", Alignment: text.End, Clickable: true},
+		{Name: "Max. blocked", Alignment: text.End, Clickable: true},
+	}
+	plc.table.SetColumns(win, gtx, cols)
+	plc.table.SortedBy = 0
+	plc.table.SortOrder = theme.SortDescending
+}
+
+// Title implements theme.Component.
+func (*PossibleLeaksComponent) Title() string {
+	return "Possibly leaked goroutines"
+}
+
+// Transition implements theme.Component.
+func (*PossibleLeaksComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*PossibleLeaksComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (plc *PossibleLeaksComponent) buildDetails(win *theme.Window, leak *ptrace.PossibleLeak) []TextSpan {
+	// Display at most this many goroutines, to keep the text manageable.
+	const maxGoroutines = 100
+	const goroutinesPerLine = 10
+
+	tb := TextBuilder{Window: win}
+	if leak.CreationStack != 0 {
+		tb.Bold("Created at:\n")
+		tb.Span(formatStack(plc.trace, leak.CreationStack))
+	} else {
+		tb.Bold("Created at: ")
+		tb.Span("before trace start")
+	}
+	tb.Span("\n\n")
+	tb.Bold("Blocked at:\n")
+	tb.Span(formatStack(plc.trace, leak.BlockingStack))
+	tb.Span("\n\n")
+	tb.Bold("Goroutines:\n")
+	for i, g := range leak.Goroutines {
+		if i == maxGoroutines {
+			tb.Span(local.Sprintf("\nand %d more", len(leak.Goroutines)-maxGoroutines))
+			break
+		}
+		if i != 0 {
+			if i%goroutinesPerLine == 0 {
+				tb.Span(",\n")
+			} else {
+				tb.Span(", ")
+			}
+		}
+		tb.DefaultLink(local.Sprintf("%d", g.ID), "Possibly leaked goroutine", g)
+	}
+	return tb.Spans
+}
+
+// Layout implements theme.Component.
+func (plc *PossibleLeaksComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.PossibleLeaksComponent.Layout").End()
+
+	plc.initTable(win, gtx)
+
+	changed := plc.filters.hideIdleRuntime.Update(gtx)
+	changed = plc.filters.hideIO.Update(gtx) || changed
+	if changed {
+		plc.compute()
+	}
+
+	plc.table.Update(gtx)
+	if _, ok := plc.table.SortByClickedColumn(); ok {
+		plc.sort()
+	}
+
+	plc.cellFormatter.Update(win, gtx)
+	for i := range plc.rows {
+		row := &plc.rows[i]
+		for {
+			if _, ok := row.toggle.Clicked(gtx); !ok {
+				break
+			}
+			row.expanded = !row.expanded
+		}
+		if !row.expanded {
+			continue
+		}
+		for _, ev := range row.text.Update(gtx, row.prevSpans) {
+			handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, rowIdx, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		leak := plc.leaks.Ptr(rowIdx)
+		row := &plc.rows[plc.leaks.Order[rowIdx]]
+		switch colName := plc.table.Columns[col].Name; colName {
+		case "Goroutines":
+			return row.toggle.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				indicator := "▶"
+				if row.expanded {
+					indicator = "▼"
+				}
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return plc.cellFormatter.Text(win, gtx, indicator)
+					}),
+					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+						gtx.Constraints.Min.X = gtx.Constraints.Max.X
+						return plc.cellFormatter.Number(win, gtx, len(leak.Goroutines))
+					}),
+				)
+			})
+		case "Function":
+			return plc.cellFormatter.Function(win, gtx, leak.Goroutines[0].Function)
+		case "State":
+			return plc.cellFormatter.Text(win, gtx, stateNamesCapitalized[leak.State])
+		case "Blocked in":
+			return plc.cellFormatter.Text(win, gtx, blockingFunction(plc.trace, leak.BlockingStack))
+		case "Min. blocked":
+			return plc.cellFormatter.Duration(win, gtx, leak.MinBlocked, true)
+		case "Max. blocked":
+			return plc.cellFormatter.Duration(win, gtx, leak.MaxBlocked, true)
+		default:
+			panic(colName)
+		}
+	}
+
+	rowFn := func(win *theme.Window, gtx layout.Context, rowIdx int) layout.Dimensions {
+		row := &plc.rows[plc.leaks.Order[rowIdx]]
+		if !row.expanded {
+			return theme.TableSimpleRow(plc.table).Layout(win, gtx, rowIdx, cellFn)
+		}
+
+		return layout.Rigids(gtx, layout.Vertical,
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableSimpleRow(plc.table).Layout(win, gtx, rowIdx, cellFn)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableExpandedRow(plc.table).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(5).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						row.text.Reset(win.Theme)
+						row.prevSpans = plc.buildDetails(win, plc.leaks.Ptr(rowIdx))
+						return row.text.Layout(win, gtx, row.prevSpans)
+					})
+				})
+			},
+		)
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.CheckBox(win.Theme, &plc.filters.hideIdleRuntime, "Hide idle runtime goroutines").Layout(win, gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.CheckBox(win.Theme, &plc.filters.hideIO, "Hide goroutines blocked on network I/O or syscalls").Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			if plc.leaks.Len() == 0 {
+				gtx.Constraints.Min = gtx.Constraints.Max
+				return theme.Label(win.Theme, "No goroutines remained blocked until the end of the trace.").Layout(win, gtx)
+			}
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.FairlySimpleTable(win, gtx, plc.table, &plc.scrollState, plc.leaks.Len(), rowFn)
+		},
+	)
+}
@@ -64,6 +64,10 @@ type OpenGoroutineAction struct {
 	Goroutine  *ptrace.Goroutine
 	Provenance string
 }
+type OpenProcessorAction struct {
+	Processor  *ptrace.Processor
+	Provenance string
+}
 type OpenGoroutineFlameGraphAction struct {
 	Goroutine  *ptrace.Goroutine
 	Provenance string
@@ -111,6 +115,56 @@ type RunGarbageCollectionAction struct{}
 type RunFreeOSMemoryAction struct{}
 type StartCPUProfileAction struct{}
 type StopCPUProfileAction struct{}
+type ShowGoroutineSubtreeAction struct {
+	Goroutine  *ptrace.Goroutine
+	Provenance string
+}
+type CanvasShowAllTimelinesAction struct{}
+type OpenGoroutineSnapshotAction struct {
+	Timestamp trace.Timestamp
+}
+type OpenGoroutinesAction struct {
+	Title      string
+	Goroutines []*ptrace.Goroutine
+}
+type OpenGCAssistsAction struct {
+	// The cycle to limit the report to, or nil for the whole trace.
+	Cycle *ptrace.GCCycle
+}
+type CanvasShowGoroutinePathAction struct {
+	// The goroutine whose path to display, or nil to hide the path.
+	Goroutine *ptrace.Goroutine
+}
+type CanvasPinTimelineAction struct {
+	Timeline *Timeline
+}
+type CanvasUnpinTimelineAction struct {
+	Timeline *Timeline
+}
+type CanvasHideTimelineAction struct {
+	Timeline *Timeline
+}
+type OpenHiddenTimelinesAction struct{}
+type OpenMigrationsAction struct{}
+type OpenParallelismAction struct{}
+type OpenSyscallsAction struct{}
+type OpenSearchAction struct{}
+type OpenTimeRangeAction struct {
+	Start trace.Timestamp
+	End   trace.Timestamp
+}
+type OpenTimeRangeFlameGraphAction struct {
+	Start trace.Timestamp
+	End   trace.Timestamp
+}
+type OpenAddBookmarkAction struct {
+	Bookmark Bookmark
+}
+type OpenBookmarksAction struct{}
+type CanvasSetNetworkGraphAction struct {
+	// The plot to display, or nil to remove the network graph.
+	Plot *Plot
+}
 type OpenPanelAction struct {
 	Panel Panel
 }
@@ -168,6 +222,26 @@ func (*CanvasToggleCompactDisplayAction) IsAction() {}
 func (*CanvasToggleStackTracksAction) IsAction()    {}
 func (*OpenScrollToTimelineAction) IsAction()       {}
 func (*OpenFileOpenAction) IsAction()               {}
+func (*ShowGoroutineSubtreeAction) IsAction()       {}
+func (*CanvasShowAllTimelinesAction) IsAction()     {}
+func (*CanvasSetNetworkGraphAction) IsAction()      {}
+func (*OpenGCAssistsAction) IsAction()              {}
+func (*OpenGoroutinesAction) IsAction()             {}
+func (*OpenGoroutineSnapshotAction) IsAction()      {}
+func (*OpenProcessorAction) IsAction()              {}
+func (*CanvasShowGoroutinePathAction) IsAction()    {}
+func (*CanvasPinTimelineAction) IsAction()          {}
+func (*CanvasUnpinTimelineAction) IsAction()        {}
+func (*CanvasHideTimelineAction) IsAction()         {}
+func (*OpenHiddenTimelinesAction) IsAction()        {}
+func (*OpenMigrationsAction) IsAction()             {}
+func (*OpenParallelismAction) IsAction()            {}
+func (*OpenSyscallsAction) IsAction()               {}
+func (*OpenSearchAction) IsAction()                 {}
+func (*OpenTimeRangeAction) IsAction()              {}
+func (*OpenTimeRangeFlameGraphAction) IsAction()    {}
+func (*OpenAddBookmarkAction) IsAction()            {}
+func (*OpenBookmarksAction) IsAction()              {}
 func (*ExitAction) IsAction()                       {}
 func (*WriteMemoryProfileAction) IsAction()         {}
 func (*RunGarbageCollectionAction) IsAction()       {}
@@ -247,17 +321,30 @@ func (l *GoroutineObjectLink) ContextMenu() []*theme.MenuItem {
 				return (*OpenGoroutineFlameGraphAction)(l)
 			},
 		},
+		{
+			Label: PlainLabel("Show only goroutines created by this goroutine"),
+			Action: func() theme.Action {
+				return (*ShowGoroutineSubtreeAction)(l)
+			},
+		},
+		{
+			Label: PlainLabel("Show path across processors"),
+			Action: func() theme.Action {
+				return &CanvasShowGoroutinePathAction{Goroutine: l.Goroutine}
+			},
+		},
 	}
 }
 
 func (l *ProcessorObjectLink) Action(mods key.Modifiers) theme.Action {
-	// There are no processor panels yet, so key.ModShift doesn't do anything
 	switch mods {
 	default:
 		return &ScrollToObjectAction{
 			Object:     l.Processor,
 			Provenance: l.Provenance,
 		}
+	case key.ModShift:
+		return (*OpenProcessorAction)(l)
 	case key.ModShortcut:
 		return &ZoomToObjectAction{
 			Object:     l.Processor,
@@ -268,6 +355,12 @@ func (l *ProcessorObjectLink) Action(mods key.Modifiers) theme.Action {
 
 func (l *ProcessorObjectLink) ContextMenu() []*theme.MenuItem {
 	return []*theme.MenuItem{
+		{
+			Label: PlainLabel("Open processor"),
+			Action: func() theme.Action {
+				return (*OpenProcessorAction)(l)
+			},
+		},
 		{
 			Label: PlainLabel("Scroll to processor"),
 			Action: func() theme.Action {
@@ -294,7 +387,20 @@ func (l *TimestampObjectLink) Action(mods key.Modifiers) theme.Action {
 }
 
 func (l *TimestampObjectLink) ContextMenu() []*theme.MenuItem {
-	return nil
+	return []*theme.MenuItem{
+		{
+			Label: PlainLabel("Scroll to timestamp"),
+			Action: func() theme.Action {
+				return ScrollToTimestampAction(l.Timestamp)
+			},
+		},
+		{
+			Label: PlainLabel("Show goroutine states at timestamp"),
+			Action: func() theme.Action {
+				return &OpenGoroutineSnapshotAction{Timestamp: l.Timestamp}
+			},
+		},
+	}
 }
 
 func (l *FunctionObjectLink) Action(mods key.Modifiers) theme.Action {
@@ -481,11 +587,13 @@ func (l *ScrollToTimelineAction) Open(gtx layout.Context, mwin *MainWindow) {
 func (l *ZoomToTimelineAction) Open(gtx layout.Context, mwin *MainWindow) {
 	// TODO(dh): this assumes that the first track is always the longest
 	tr := l.Timeline.tracks[0]
+	mwin.canvas.ensureObjectDisplayed(gtx, l.Timeline.item)
 	y := mwin.canvas.timelineY(gtx, l.Timeline)
 	mwin.canvas.navigateToStartAndEnd(gtx, tr.Start, tr.End, y)
 }
 
 func (l *ScrollToObjectAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.canvas.ensureObjectDisplayed(gtx, l.Object)
 	// OPT(dh): don't be O(n)
 	for _, tl := range mwin.canvas.timelines {
 		if tl.item == l.Object {
@@ -497,6 +605,7 @@ func (l *ScrollToObjectAction) Open(gtx layout.Context, mwin *MainWindow) {
 
 func (l *ZoomToObjectAction) Open(gtx layout.Context, mwin *MainWindow) {
 	// TODO(dh): this assumes that the first track is always the longest
+	mwin.canvas.ensureObjectDisplayed(gtx, l.Object)
 	// OPT(dh): don't be O(n)
 	for _, tl := range mwin.canvas.timelines {
 		if tl.item == l.Object {
@@ -512,24 +621,123 @@ func (l *OpenGoroutineAction) Open(_ layout.Context, mwin *MainWindow) {
 	mwin.openGoroutine(l.Goroutine)
 }
 
+func (l *OpenProcessorAction) Open(_ layout.Context, mwin *MainWindow) {
+	mwin.openProcessor(l.Processor)
+}
+
 func (l *OpenGoroutineFlameGraphAction) Open(gtx layout.Context, mwin *MainWindow) {
 	mwin.openFlameGraph(l.Goroutine)
 }
 
-func (l ScrollToTimestampAction) Open(gtx layout.Context, mwin *MainWindow) {
-	d := mwin.canvas.End() - mwin.canvas.start
-	var off trace.Timestamp
-	switch mwin.canvas.axis.anchor {
-	case AxisAnchorNone:
-		off = mwin.canvas.pxToTs(mwin.canvas.axis.position) - mwin.canvas.start
-	case AxisAnchorStart:
-		off = 0
-	case AxisAnchorCenter:
-		off = d / 2
-	case AxisAnchorEnd:
-		off = d
+func (l *ShowGoroutineSubtreeAction) Open(gtx layout.Context, mwin *MainWindow) {
+	gs := goroutineSubtree(mwin.trace, l.Goroutine)
+	tls := make([]*Timeline, 0, len(gs))
+	for _, g := range gs {
+		if tl, ok := mwin.canvas.itemToTimeline[g]; ok {
+			tls = append(tls, tl)
+		}
+	}
+	mwin.canvas.ShowOnlyTimelines(gtx, tls)
+	mwin.twin.ShowNotification(gtx, local.Sprintf("Showing %d goroutines created by goroutine %d", len(tls), l.Goroutine.ID))
+}
+
+func (l *CanvasShowAllTimelinesAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.canvas.ShowAllTimelines(gtx)
+}
+
+func (l *CanvasShowGoroutinePathAction) Open(gtx layout.Context, mwin *MainWindow) {
+	if l.Goroutine == nil {
+		mwin.canvas.HideGoroutinePath()
+		return
 	}
-	mwin.canvas.navigateTo(gtx, trace.Timestamp(l)-off, mwin.canvas.nsPerPx, mwin.canvas.y)
+	mwin.canvas.ShowGoroutinePath(l.Goroutine)
+	// Derive the statistics from the path instead of waiting for the migrations of all goroutines.
+	runs := mwin.canvas.goroutinePath.runs
+	ps := map[*ptrace.Processor]struct{}{}
+	var migrations int
+	for i, r := range runs {
+		ps[r.Processor] = struct{}{}
+		if i > 0 && runs[i-1].Processor != r.Processor {
+			migrations++
+		}
+	}
+	mwin.twin.ShowNotification(gtx, local.Sprintf("Showing path of goroutine %d: %d runs on %d processors, %d migrations", l.Goroutine.ID, len(runs), len(ps), migrations))
+}
+
+func (l *CanvasPinTimelineAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.canvas.PinTimeline(gtx, l.Timeline)
+}
+
+func (l *CanvasUnpinTimelineAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.canvas.UnpinTimeline(gtx, l.Timeline)
+}
+
+func (l *CanvasHideTimelineAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.canvas.HideTimeline(gtx, l.Timeline)
+	mwin.twin.ShowNotification(gtx, local.Sprintf("Hid %s. Hidden timelines can be restored via the Display menu.", l.Timeline.shortName))
+}
+
+func (l *OpenHiddenTimelinesAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openHiddenTimelines()
+}
+
+func (l *OpenMigrationsAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openMigrations()
+}
+
+func (l *OpenParallelismAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openParallelism()
+}
+
+func (l *OpenSyscallsAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openSyscalls()
+}
+
+func (l *OpenSearchAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openSearch()
+}
+
+func (l *OpenTimeRangeAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openTimeRange(l.Start, l.End)
+}
+
+func (l *OpenTimeRangeFlameGraphAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openTimeRangeFlameGraph(l.Start, l.End)
+}
+
+func (l *OpenAddBookmarkAction) Open(gtx layout.Context, mwin *MainWindow) {
+	displayBookmarkDialog(mwin.twin, &mwin.canvas, l.Bookmark, -1)
+}
+
+func (l *OpenBookmarksAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openBookmarks()
+}
+
+func (l *CanvasSetNetworkGraphAction) Open(gtx layout.Context, mwin *MainWindow) {
+	kind := plotKindByID("network")
+	if l.Plot == nil {
+		mwin.canvas.RemovePlot(kind)
+	} else {
+		mwin.canvas.AddPlot(mwin.twin, kind, l.Plot)
+	}
+}
+
+func (l *OpenGCAssistsAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openGCAssists(l.Cycle)
+}
+
+func (l *OpenGoroutineSnapshotAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.openPanel(NewGoroutineSnapshot(mwin.trace, mwin.twin, &mwin.canvas, l.Timestamp))
+}
+
+func (l *OpenGoroutinesAction) Open(gtx layout.Context, mwin *MainWindow) {
+	c := NewGoroutinesComponent(mwin.trace, l.Goroutines)
+	c.title = l.Title
+	mwin.openTab(Tab{Component: c})
+}
+
+func (l ScrollToTimestampAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.canvas.scrollToTimestamp(gtx, trace.Timestamp(l), mwin.canvas.y)
 }
 
 func (l *OpenFunctionAction) Open(_ layout.Context, mwin *MainWindow) {
@@ -543,6 +751,7 @@ func (l *OpenSpansAction) Open(gtx layout.Context, mwin *MainWindow) {
 func (l *ScrollAndPanToSpansAction) Open(gtx layout.Context, mwin *MainWindow) {
 	c, ok := l.Spans.Container()
 	assert(ok, "expected container")
+	mwin.canvas.ensureObjectDisplayed(gtx, c.Timeline.item)
 	y := mwin.canvas.timelineY(gtx, c.Timeline)
 	d := mwin.canvas.End() - mwin.canvas.start
 	tsp := SpansTimeSpan(l.Spans)
@@ -553,6 +762,7 @@ func (l *ScrollAndPanToSpansAction) Open(gtx layout.Context, mwin *MainWindow) {
 func (l *ZoomToSpansAction) Open(gtx layout.Context, mwin *MainWindow) {
 	c, ok := l.Spans.Container()
 	assert(ok, "expected container")
+	mwin.canvas.ensureObjectDisplayed(gtx, c.Timeline.item)
 	y := mwin.canvas.timelineY(gtx, c.Timeline)
 	mwin.canvas.navigateToStartAndEnd(gtx, l.Spans.AtPtr(0).Start, LastItemPtr(l.Spans).End, y)
 }
@@ -597,7 +807,7 @@ func (l OpenHeatmapAction) Open(gtx layout.Context, mwin *MainWindow) {
 	mwin.openHeatmap()
 }
 func (l OpenHighlightSpansDialogAction) Open(gtx layout.Context, mwin *MainWindow) {
-	displayHighlightSpansDialog(mwin.twin, &mwin.canvas.timeline.filter)
+	displayHighlightSpansDialog(mwin.twin, &mwin.canvas.timeline.filter, &mwin.canvas.timeline.hideNonMatching)
 }
 func (l CanvasToggleTimelineLabelsAction) Open(gtx layout.Context, mwin *MainWindow) {
 	mwin.canvas.ToggleTimelineLabels()
@@ -610,13 +820,14 @@ func (l CanvasToggleStackTracksAction) Open(gtx layout.Context, mwin *MainWindow
 }
 func (l OpenScrollToTimelineAction) Open(gtx layout.Context, mwin *MainWindow) {
 	pl := theme.CommandPalette{Prompt: "Scroll to timeline"}
-	pl.Set(ScrollToTimelineCommandProvider{mwin.twin, mwin.canvas.timelines})
+	pl.Set(ScrollToTimelineCommandProvider{mwin.twin, mwin.canvas.UngroupedTimelines()})
 	mwin.twin.SetModal(pl.Layout)
 }
 func (l OpenFileOpenAction) Open(gtx layout.Context, mwin *MainWindow) {
 	mwin.showFileOpenDialog()
 }
 func (l ExitAction) Open(gtx layout.Context, mwin *MainWindow) {
+	mwin.autosaveSession()
 	os.Exit(0)
 }
 func (l WriteMemoryProfileAction) Open(gtx layout.Context, mwin *MainWindow) {
@@ -712,3 +923,16 @@ func (*OpenHighlightSpansDialogAction) IsOpenAction()         {}
 func (*OpenScrollToTimelineAction) IsOpenAction()             {}
 func (*OpenFileOpenAction) IsOpenAction()                     {}
 func (*OpenPanelAction) IsOpenAction()                        {}
+func (*OpenGCAssistsAction) IsOpenAction()                    {}
+func (*OpenGoroutinesAction) IsOpenAction()                   {}
+func (*OpenGoroutineSnapshotAction) IsOpenAction()            {}
+func (*OpenProcessorAction) IsOpenAction()                    {}
+func (*OpenHiddenTimelinesAction) IsOpenAction()              {}
+func (*OpenMigrationsAction) IsOpenAction()                   {}
+func (*OpenParallelismAction) IsOpenAction()                  {}
+func (*OpenSyscallsAction) IsOpenAction()                     {}
+func (*OpenSearchAction) IsOpenAction()                       {}
+func (*OpenTimeRangeAction) IsOpenAction()                    {}
+func (*OpenTimeRangeFlameGraphAction) IsOpenAction()          {}
+func (*OpenAddBookmarkAction) IsOpenAction()                  {}
+func (*OpenBookmarksAction) IsOpenAction()                    {}
@@ -120,10 +120,15 @@ var (
 )
 
 func (mwin *MainWindow) openGoroutine(g *ptrace.Goroutine) {
-	gi := NewGoroutineInfo(mwin.trace, mwin.twin, &mwin.canvas, g, mwin.canvas.timelines)
+	gi := NewGoroutineInfo(mwin.trace, mwin.twin, &mwin.canvas, g, mwin.canvas.AllTimelines())
 	mwin.openPanel(gi)
 }
 
+func (mwin *MainWindow) openProcessor(p *ptrace.Processor) {
+	pi := NewProcessorInfo(mwin.trace, mwin.twin, &mwin.canvas, p, mwin.canvas.AllTimelines())
+	mwin.openPanel(pi)
+}
+
 func (mwin *MainWindow) openFunction(fn *ptrace.Function) {
 	fi := NewFunctionInfo(mwin.trace, mwin.twin, fn)
 	mwin.openPanel(fi)
@@ -143,7 +148,7 @@ func (mwin *MainWindow) openSpan(s Items[ptrace.Span]) {
 	cfg := SpansInfoConfig{
 		Label: label,
 	}
-	si := NewSpansInfo(cfg, mwin.trace, mwin.twin, theme.Immediate[Items[ptrace.Span]](s), mwin.canvas.timelines)
+	si := NewSpansInfo(cfg, mwin.trace, mwin.twin, theme.Immediate[Items[ptrace.Span]](s), mwin.canvas.AllTimelines())
 	mwin.openPanel(si)
 }
 
@@ -275,6 +280,80 @@ func (mwin *MainWindow) openFlameGraph(g *ptrace.Goroutine) {
 	mwin.openTab(Tab{Component: c})
 }
 
+func (mwin *MainWindow) openTimeRangeFlameGraph(start, end trace.Timestamp) {
+	c := NewTimeRangeFlameGraphComponent(mwin.twin, mwin.trace.Trace, start, end)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openTimeRange(start, end trace.Timestamp) {
+	mwin.canvas.SelectRange(start, end)
+	mwin.openPanel(NewTimeRangeComponent(mwin.trace, mwin.twin, &mwin.canvas, start, end))
+}
+
+func (mwin *MainWindow) openPossibleLeaks() {
+	c := NewPossibleLeaksComponent(mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openGoroutineTree() {
+	c := NewGoroutineTreeComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openNetwork() {
+	c := NewNetworkComponent(mwin.twin, mwin.trace, &mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openGCCycles() {
+	c := NewGCCyclesComponent(mwin.trace, &mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openGCAssists(cycle *ptrace.GCCycle) {
+	c := NewGCAssistsComponent(mwin.trace, cycle)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openMigrations() {
+	c := NewMigrationsComponent(mwin.twin, mwin.trace)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openHiddenTimelines() {
+	c := NewHiddenTimelinesComponent(&mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openParallelism() {
+	c := NewParallelismComponent(mwin.twin, mwin.trace, &mwin.canvas, 0, mwin.trace.End())
+	mwin.openTab(Tab{Component: c})
+}
+
+func (mwin *MainWindow) openSyscalls() {
+	c := NewSyscallsComponent(mwin.twin, mwin.trace, &mwin.canvas)
+	mwin.openTab(Tab{Component: c})
+}
+
+// openSearch switches to the search tab, opening it if necessary. The search persists when the tab gets closed.
+func (mwin *MainWindow) openSearch() {
+	if mwin.search == nil {
+		mwin.search = NewSearchComponent(mwin.trace, &mwin.canvas)
+	}
+	mwin.search.Focus()
+	for i, tab := range mwin.tabs {
+		if tab.Component == mwin.search {
+			mwin.tabbedState.Current = i
+			return
+		}
+	}
+	mwin.openTab(Tab{Component: mwin.search})
+}
+
+func (mwin *MainWindow) openBookmarks() {
+	mwin.openPanel(NewBookmarksComponent(mwin.twin, &mwin.canvas))
+}
+
 func (mwin *MainWindow) openTab(tab Tab) {
 	mwin.tabs = append(mwin.tabs, tab)
 	mwin.tabbedState.Current = len(mwin.tabs) - 1
@@ -326,6 +405,12 @@ type MainWindow struct {
 	tabs        []Tab
 	tabbedState theme.TabbedState
 
+	// The search component, which persists when its tab gets closed so that the results can still be stepped
+	// through.
+	search *SearchComponent
+	// The path of the trace file, if the trace was loaded from a file.
+	tracePath string
+
 	openTraceButton widget.PrimaryClickable
 	resize          component.Resize
 
@@ -344,6 +429,8 @@ type MainWindow struct {
 	err            error
 
 	debugWindow *DebugWindow
+
+	settings Settings
 }
 
 func NewMainWindow() *MainWindow {
@@ -353,6 +440,7 @@ func NewMainWindow() *MainWindow {
 		errs:        make(chan error),
 		subwindows:  map[Window]struct{}{},
 		gc:          NewGCScheduler(0, 0),
+		settings:    LoadSettings(),
 		resize: component.Resize{
 			Axis:  layout.Horizontal,
 			Ratio: 0.70,
@@ -378,7 +466,7 @@ func (mwin *MainWindow) OpenTrace(r io.Reader) {
 	mwin.gc.Pause()
 	defer mwin.gc.Resume()
 
-	res, err := loadTrace(r, mwin, &mwin.canvas)
+	res, err := loadTrace(r, mwin, &mwin.canvas, mwin.settings)
 	if memprofileLoad != "" {
 		writeMemprofile(memprofileLoad)
 	}
@@ -395,6 +483,18 @@ func (mwin *MainWindow) OpenTrace(r io.Reader) {
 		return
 	}
 
+	if f, ok := r.(interface{ Name() string }); ok {
+		res.path = f.Name()
+		res.bookmarks, err = LoadBookmarks(res.path)
+		if err != nil {
+			log.Println("couldn't load bookmarks:", err)
+		}
+		res.session, err = ReadSession(sessionPath(res.path))
+		if err != nil {
+			log.Println("couldn't load session:", err)
+		}
+	}
+
 	mwin.LoadTrace(res)
 }
 
@@ -473,8 +573,11 @@ func PlainLabel(s string) func() string { return func() string { return s } }
 
 type MainMenu struct {
 	File struct {
-		OpenTrace theme.MenuItem
-		Quit      theme.MenuItem
+		OpenTrace     theme.MenuItem
+		SaveSession   theme.MenuItem
+		SaveSessionAs theme.MenuItem
+		OpenSession   theme.MenuItem
+		Quit          theme.MenuItem
 	}
 
 	Display struct {
@@ -484,14 +587,37 @@ type MainMenu struct {
 		ZoomToFit            theme.MenuItem
 		JumpToBeginning      theme.MenuItem
 		HighlightSpans       theme.MenuItem
+		HideNonMatching      theme.MenuItem
 		ToggleCompactDisplay theme.MenuItem
 		ToggleTimelineLabels theme.MenuItem
 		ToggleStackTracks    theme.MenuItem
+		ToggleFlows          theme.MenuItem
+		ShowAllTimelines     theme.MenuItem
+		ShowHiddenTimelines  theme.MenuItem
+		HideGoroutinePath    theme.MenuItem
+		ClearHighlights      theme.MenuItem
+		ClearSelectedRange   theme.MenuItem
+		// One item per timelineGrouping.
+		GroupTimelines []theme.MenuItem
+		// One item per entry in PlotKinds.
+		TogglePlots []theme.MenuItem
+		// The built-in kinds of plots and the plots of the current trace's user metrics.
+		PlotKinds []*plotKind
 	}
 
 	Analyze struct {
-		OpenHeatmap    theme.MenuItem
-		OpenFlameGraph theme.MenuItem
+		OpenHeatmap       theme.MenuItem
+		OpenFlameGraph    theme.MenuItem
+		OpenPossibleLeaks theme.MenuItem
+		OpenGoroutineTree theme.MenuItem
+		OpenNetwork       theme.MenuItem
+		OpenGCCycles      theme.MenuItem
+		OpenGCAssists     theme.MenuItem
+		OpenMigrations    theme.MenuItem
+		OpenParallelism   theme.MenuItem
+		OpenSyscalls      theme.MenuItem
+		Search            theme.MenuItem
+		Bookmarks         theme.MenuItem
 	}
 
 	Debug struct {
@@ -502,25 +628,48 @@ type MainMenu struct {
 	}
 
 	menu *theme.Menu
+	// The index of the first plot item in the Display group.
+	plotItems int
 }
 
 func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 	m := &MainMenu{}
 
+	notMainDisabled := func() bool { return mwin.state != "main" }
 	m.File.OpenTrace = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+O", Label: PlainLabel("Open trace")}
+	m.File.SaveSession = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+S", Label: PlainLabel("Save session"), Disabled: func() bool { return notMainDisabled() || mwin.tracePath == "" }}
+	m.File.SaveSessionAs = theme.MenuItem{Label: PlainLabel("Save session as…"), Disabled: notMainDisabled}
+	m.File.OpenSession = theme.MenuItem{Label: PlainLabel("Open session…"), Disabled: notMainDisabled}
 	m.File.Quit = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+Q", Label: PlainLabel("Quit")}
 
-	notMainDisabled := func() bool { return mwin.state != "main" }
 	m.Display.UndoNavigation = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+Z", Label: PlainLabel("Undo previous navigation"), Disabled: notMainDisabled}
 	m.Display.RedoNavigation = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+Y", Label: PlainLabel("Redo navigation"), Disabled: notMainDisabled}
 	m.Display.ScrollToTop = theme.MenuItem{Shortcut: "Home", Label: PlainLabel("Scroll to top of canvas"), Disabled: notMainDisabled}
 	m.Display.ZoomToFit = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+Home", Label: PlainLabel("Zoom to fit visible timelines"), Disabled: notMainDisabled}
 	m.Display.JumpToBeginning = theme.MenuItem{Shortcut: "Shift+Home", Label: PlainLabel("Jump to beginning of timeline"), Disabled: notMainDisabled}
 	m.Display.HighlightSpans = theme.MenuItem{Shortcut: "H", Label: PlainLabel("Highlight spans…"), Disabled: notMainDisabled}
+	m.Display.HideNonMatching = theme.MenuItem{Shortcut: "F", Label: ToggleLabel("Show timelines without highlighted spans", "Hide timelines without highlighted spans", &mwin.canvas.timeline.hideNonMatching), Disabled: notMainDisabled}
 	m.Display.ToggleCompactDisplay = theme.MenuItem{Shortcut: "C", Label: ToggleLabel("Disable compact display", "Enable compact display", &mwin.canvas.timeline.compact), Disabled: notMainDisabled}
 	m.Display.ToggleTimelineLabels = theme.MenuItem{Shortcut: "X", Label: ToggleLabel("Hide timeline labels", "Show timeline labels", &mwin.canvas.timeline.displayAllLabels), Disabled: notMainDisabled}
 	m.Display.ToggleStackTracks = theme.MenuItem{Shortcut: "S", Label: ToggleLabel("Hide stack frames", "Show stack frames", &mwin.canvas.timeline.displayStackTracks), Disabled: notMainDisabled}
-
+	m.Display.ToggleFlows = theme.MenuItem{Label: ToggleLabel("Hide goroutine creation and unblock arrows", "Show goroutine creation and unblock arrows", &mwin.canvas.timeline.displayFlows), Disabled: notMainDisabled}
+	m.Display.ShowAllTimelines = theme.MenuItem{Label: PlainLabel("Show all timelines"), Disabled: func() bool { return notMainDisabled() || mwin.canvas.ShowingAllTimelines() }}
+	m.Display.ShowHiddenTimelines = theme.MenuItem{Label: PlainLabel("Show hidden timelines…"), Disabled: func() bool { return notMainDisabled() || len(mwin.canvas.hiddenTimelines) == 0 }}
+	m.Display.HideGoroutinePath = theme.MenuItem{Label: PlainLabel("Hide goroutine path across processors"), Disabled: func() bool { return notMainDisabled() || mwin.canvas.goroutinePath.goroutine == nil }}
+	m.Display.ClearHighlights = theme.MenuItem{Label: PlainLabel("Remove highlighted intervals"), Disabled: func() bool { return notMainDisabled() || len(mwin.canvas.highlightedIntervals) == 0 }}
+	m.Display.ClearSelectedRange = theme.MenuItem{Label: PlainLabel("Clear selected time range"), Disabled: func() bool { return notMainDisabled() || !mwin.canvas.selectedRange.Set() }}
+	m.Display.GroupTimelines = make([]theme.MenuItem, timelineGroupingLast)
+	for i := range m.Display.GroupTimelines {
+		by := timelineGrouping(i)
+		label := "Group goroutines by " + timelineGroupingNames[by]
+		if by == timelineGroupingNone {
+			label = "Don't group goroutines"
+		}
+		m.Display.GroupTimelines[i] = theme.MenuItem{
+			Label:    PlainLabel(label),
+			Disabled: func() bool { return notMainDisabled() || mwin.canvas.TimelineGrouping() == by },
+		}
+	}
 	m.Debug.Memprofile = theme.MenuItem{Label: PlainLabel("Write memory profile")}
 	m.Debug.Cpuprofile = theme.MenuItem{Label: func() string {
 		if mwin.cpuProfile == nil {
@@ -534,6 +683,16 @@ func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 
 	m.Analyze.OpenHeatmap = theme.MenuItem{Label: PlainLabel("Open processor utilization heatmap"), Disabled: notMainDisabled}
 	m.Analyze.OpenFlameGraph = theme.MenuItem{Label: PlainLabel("Open flame graph"), Disabled: notMainDisabled}
+	m.Analyze.OpenPossibleLeaks = theme.MenuItem{Label: PlainLabel("Open possibly leaked goroutines"), Disabled: notMainDisabled}
+	m.Analyze.OpenGoroutineTree = theme.MenuItem{Label: PlainLabel("Open goroutine hierarchy"), Disabled: notMainDisabled}
+	m.Analyze.OpenNetwork = theme.MenuItem{Label: PlainLabel("Open network I/O summary"), Disabled: notMainDisabled}
+	m.Analyze.OpenGCCycles = theme.MenuItem{Label: PlainLabel("Open GC cycles"), Disabled: notMainDisabled}
+	m.Analyze.OpenGCAssists = theme.MenuItem{Label: PlainLabel("Open mark assist report"), Disabled: notMainDisabled}
+	m.Analyze.OpenMigrations = theme.MenuItem{Label: PlainLabel("Open goroutine migration report"), Disabled: notMainDisabled}
+	m.Analyze.OpenParallelism = theme.MenuItem{Label: PlainLabel("Open parallelism profile"), Disabled: notMainDisabled}
+	m.Analyze.OpenSyscalls = theme.MenuItem{Label: PlainLabel("Open syscall report"), Disabled: notMainDisabled}
+	m.Analyze.Search = theme.MenuItem{Shortcut: key.ModShortcut.String() + "+F", Label: PlainLabel("Search…"), Disabled: notMainDisabled}
+	m.Analyze.Bookmarks = theme.MenuItem{Label: PlainLabel("Bookmarks"), Disabled: notMainDisabled}
 
 	m.menu = &theme.Menu{
 		Groups: []theme.MenuGroup{
@@ -541,6 +700,9 @@ func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 				Label: "File",
 				Items: []theme.Widget{
 					theme.NewMenuItemStyle(win.Theme, &m.File.OpenTrace).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.SaveSession).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.SaveSessionAs).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.File.OpenSession).Layout,
 					theme.NewMenuItemStyle(win.Theme, &m.File.Quit).Layout,
 				},
 			},
@@ -560,14 +722,26 @@ func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 					theme.MenuDivider(win.Theme).Layout,
 
 					theme.NewMenuItemStyle(win.Theme, &m.Display.HighlightSpans).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.HideNonMatching).Layout,
 
 					theme.MenuDivider(win.Theme).Layout,
 
 					theme.NewMenuItemStyle(win.Theme, &m.Display.ToggleCompactDisplay).Layout,
 					theme.NewMenuItemStyle(win.Theme, &m.Display.ToggleTimelineLabels).Layout,
 					theme.NewMenuItemStyle(win.Theme, &m.Display.ToggleStackTracks).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.ToggleFlows).Layout,
+
+					theme.MenuDivider(win.Theme).Layout,
+
+					theme.NewMenuItemStyle(win.Theme, &m.Display.ShowAllTimelines).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.ShowHiddenTimelines).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.HideGoroutinePath).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.ClearHighlights).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Display.ClearSelectedRange).Layout,
 					// TODO(dh): add items for STW and GC overlays
 					// TODO(dh): add item for tooltip display
+
+					theme.MenuDivider(win.Theme).Layout,
 				},
 			},
 			{
@@ -575,11 +749,29 @@ func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 				Items: []theme.Widget{
 					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenHeatmap).Layout,
 					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenFlameGraph).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenPossibleLeaks).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenGoroutineTree).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenNetwork).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenGCCycles).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenGCAssists).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenMigrations).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenParallelism).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.OpenSyscalls).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.Search).Layout,
+					theme.NewMenuItemStyle(win.Theme, &m.Analyze.Bookmarks).Layout,
 				},
 			},
 		},
 	}
 
+	display := &m.menu.Groups[1]
+	for i := range m.Display.GroupTimelines {
+		display.Items = append(display.Items, theme.NewMenuItemStyle(win.Theme, &m.Display.GroupTimelines[i]).Layout)
+	}
+	display.Items = append(display.Items, theme.MenuDivider(win.Theme).Layout)
+	m.plotItems = len(display.Items)
+	m.setPlotKinds(mwin, win, plotKinds)
+
 	if softDebug {
 		m.menu.Groups = append(m.menu.Groups, theme.MenuGroup{
 			Label: "Debug",
@@ -595,8 +787,33 @@ func NewMainMenu(mwin *MainWindow, win *theme.Window) *MainMenu {
 	return m
 }
 
-func displayHighlightSpansDialog(win *theme.Window, filter *Filter) {
-	hd := HighlightDialog(win, filter)
+// setPlotKinds replaces the items for adding and removing plots, so that they cover the user metrics of the current
+// trace.
+func (m *MainMenu) setPlotKinds(mwin *MainWindow, win *theme.Window, kinds []*plotKind) {
+	m.Display.PlotKinds = kinds
+	m.Display.TogglePlots = make([]theme.MenuItem, len(kinds))
+	for i, kind := range kinds {
+		kind := kind
+		m.Display.TogglePlots[i] = theme.MenuItem{
+			Label: func() string {
+				if mwin.canvas.HasPlot(kind) {
+					return "Remove plot: " + kind.Name
+				}
+				return "Add plot: " + kind.Name
+			},
+			Disabled: func() bool { return mwin.state != "main" },
+		}
+	}
+
+	display := &m.menu.Groups[1]
+	display.Items = display.Items[:m.plotItems]
+	for i := range m.Display.TogglePlots {
+		display.Items = append(display.Items, theme.NewMenuItemStyle(win.Theme, &m.Display.TogglePlots[i]).Layout)
+	}
+}
+
+func displayHighlightSpansDialog(win *theme.Window, filter *Filter, hideNonMatching *bool) {
+	hd := HighlightDialog(win, filter, hideNonMatching)
 	win.SetModal(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 		return theme.Dialog(win.Theme, "Highlight spans").Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 			gtx.Constraints.Min = gtx.Constraints.Constrain(image.Pt(1000, 500))
@@ -644,6 +861,9 @@ func (mwin *MainWindow) Run() error {
 
 		switch ev := e.(type) {
 		case system.DestroyEvent:
+			// Remember the plots' sizes, which we don't save every time they're resized.
+			mwin.canvas.savePlots()
+			mwin.autosaveSession()
 			return ev.Err
 		case system.FrameEvent:
 			if measureFrameAllocs {
@@ -690,7 +910,11 @@ func (mwin *MainWindow) Run() error {
 				}
 				if mwin.mainMenu.Display.HighlightSpans.Clicked(gtx) {
 					win.Menu.Close()
-					displayHighlightSpansDialog(win, &mwin.canvas.timeline.filter)
+					displayHighlightSpansDialog(win, &mwin.canvas.timeline.filter, &mwin.canvas.timeline.hideNonMatching)
+				}
+				if mwin.mainMenu.Display.HideNonMatching.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.canvas.timeline.hideNonMatching = !mwin.canvas.timeline.hideNonMatching
 				}
 				if mwin.mainMenu.Display.ToggleCompactDisplay.Clicked(gtx) {
 					win.Menu.Close()
@@ -704,6 +928,46 @@ func (mwin *MainWindow) Run() error {
 					win.Menu.Close()
 					mwin.canvas.ToggleStackTracks()
 				}
+				if mwin.mainMenu.Display.ToggleFlows.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.canvas.ToggleFlows()
+				}
+				if mwin.mainMenu.Display.ShowAllTimelines.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.canvas.ShowAllTimelines(gtx)
+				}
+				if mwin.mainMenu.Display.ShowHiddenTimelines.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openHiddenTimelines()
+				}
+				if mwin.mainMenu.Display.HideGoroutinePath.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.canvas.HideGoroutinePath()
+				}
+				if mwin.mainMenu.Display.ClearHighlights.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.canvas.HighlightIntervals(nil)
+				}
+				if mwin.mainMenu.Display.ClearSelectedRange.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.canvas.ClearSelectedRange()
+				}
+				for i := range mwin.mainMenu.Display.GroupTimelines {
+					if mwin.mainMenu.Display.GroupTimelines[i].Clicked(gtx) {
+						win.Menu.Close()
+						mwin.canvas.SetTimelineGrouping(timelineGrouping(i))
+					}
+				}
+				for i := range mwin.mainMenu.Display.TogglePlots {
+					if mwin.mainMenu.Display.TogglePlots[i].Clicked(gtx) {
+						win.Menu.Close()
+						if kind := mwin.mainMenu.Display.PlotKinds[i]; mwin.canvas.HasPlot(kind) {
+							mwin.canvas.RemovePlot(kind)
+						} else {
+							mwin.canvas.AddPlot(win, kind, nil)
+						}
+					}
+				}
 				if mwin.mainMenu.Analyze.OpenHeatmap.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.openHeatmap()
@@ -712,6 +976,46 @@ func (mwin *MainWindow) Run() error {
 					win.Menu.Close()
 					mwin.openFlameGraph(nil)
 				}
+				if mwin.mainMenu.Analyze.OpenPossibleLeaks.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openPossibleLeaks()
+				}
+				if mwin.mainMenu.Analyze.OpenGoroutineTree.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openGoroutineTree()
+				}
+				if mwin.mainMenu.Analyze.OpenNetwork.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openNetwork()
+				}
+				if mwin.mainMenu.Analyze.OpenGCCycles.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openGCCycles()
+				}
+				if mwin.mainMenu.Analyze.OpenGCAssists.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openGCAssists(nil)
+				}
+				if mwin.mainMenu.Analyze.OpenMigrations.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openMigrations()
+				}
+				if mwin.mainMenu.Analyze.OpenParallelism.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openParallelism()
+				}
+				if mwin.mainMenu.Analyze.OpenSyscalls.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openSyscalls()
+				}
+				if mwin.mainMenu.Analyze.Search.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openSearch()
+				}
+				if mwin.mainMenu.Analyze.Bookmarks.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.openBookmarks()
+				}
 				if mwin.mainMenu.Debug.Cpuprofile.Clicked(gtx) {
 					win.Menu.Close()
 					if mwin.cpuProfile != nil {
@@ -777,12 +1081,25 @@ func (mwin *MainWindow) Run() error {
 				}
 				if mwin.mainMenu.File.Quit.Clicked(gtx) {
 					win.Menu.Close()
+					mwin.autosaveSession()
 					os.Exit(0)
 				}
 				if mwin.mainMenu.File.OpenTrace.Clicked(gtx) {
 					win.Menu.Close()
 					mwin.showFileOpenDialog()
 				}
+				if mwin.mainMenu.File.SaveSession.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.saveSession(win, gtx)
+				}
+				if mwin.mainMenu.File.SaveSessionAs.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.showSessionSaveDialog()
+				}
+				if mwin.mainMenu.File.OpenSession.Clicked(gtx) {
+					win.Menu.Close()
+					mwin.showSessionOpenDialog()
+				}
 
 				for _, ev := range gtx.Events(profileTag) {
 					// Yup, profile.Event only contains a string. No structured access to data.
@@ -961,16 +1278,35 @@ func (mwin *MainWindow) renderLoadingTraceScene(win *theme.Window, gtx layout.Co
 func (mwin *MainWindow) renderMainScene(win *theme.Window, gtx layout.Context, shortcuts []theme.Shortcut) layout.Dimensions {
 	win.AddShortcut(theme.Shortcut{Name: "G"})
 	win.AddShortcut(theme.Shortcut{Name: "H"})
+	win.AddShortcut(theme.Shortcut{Name: "F", Modifiers: key.ModShortcut})
+	win.AddShortcut(theme.Shortcut{Name: "N"})
+	win.AddShortcut(theme.Shortcut{Name: "N", Modifiers: key.ModShift})
+	win.AddShortcut(theme.Shortcut{Name: "S", Modifiers: key.ModShortcut})
 
 	for _, s := range shortcuts {
 		switch s {
 		case theme.Shortcut{Name: "G"}:
 			pl := &theme.CommandPalette{Prompt: "Scroll to timeline"}
-			pl.Set(ScrollToTimelineCommandProvider{mwin.twin, mwin.canvas.timelines})
+			pl.Set(ScrollToTimelineCommandProvider{mwin.twin, mwin.canvas.UngroupedTimelines()})
 			win.SetModal(pl.Layout)
 
 		case theme.Shortcut{Name: "H"}:
-			displayHighlightSpansDialog(win, &mwin.canvas.timeline.filter)
+			displayHighlightSpansDialog(win, &mwin.canvas.timeline.filter, &mwin.canvas.timeline.hideNonMatching)
+
+		case theme.Shortcut{Name: "F", Modifiers: key.ModShortcut}:
+			mwin.openSearch()
+
+		case theme.Shortcut{Name: "N"}, theme.Shortcut{Name: "N", Modifiers: key.ModShift}:
+			delta := 1
+			if s.Modifiers == key.ModShift {
+				delta = -1
+			}
+			if mwin.search == nil || !mwin.search.Step(gtx, delta) {
+				win.ShowNotification(gtx, "No search result selected. Use Analyze → Search… to search.")
+			}
+
+		case theme.Shortcut{Name: "S", Modifiers: key.ModShortcut}:
+			mwin.saveSession(win, gtx)
 		}
 	}
 
@@ -1074,18 +1410,36 @@ func (mwin *MainWindow) renderMainScene(win *theme.Window, gtx layout.Context, s
 
 	// TODO(dh): add a public API to Canvas
 	for _, tl := range mwin.canvas.clickedTimelines {
-		if g, ok := tl.item.(*ptrace.Goroutine); ok {
-			mwin.openGoroutine(g)
+		switch item := tl.item.(type) {
+		case *ptrace.Goroutine:
+			mwin.openGoroutine(item)
 			// FIXME(dh): canvas does event handling _after_ layout, so we need a second frame
 			op.InvalidateOp{}.Add(gtx.Ops)
+		case *timelineGroup:
+			mwin.canvas.ToggleTimelineGroup(gtx, item)
+			op.InvalidateOp{}.Add(gtx.Ops)
 		}
 	}
 	for _, tl := range mwin.canvas.rightClickedTimelines {
-		if g, ok := tl.item.(*ptrace.Goroutine); ok {
-			win.SetContextMenu((&GoroutineObjectLink{Goroutine: g}).ContextMenu())
+		var items []*theme.MenuItem
+		switch item := tl.item.(type) {
+		case *ptrace.Goroutine:
+			items = (&GoroutineObjectLink{Goroutine: item}).ContextMenu()
+		case *ptrace.Processor:
+			items = (&ProcessorObjectLink{Processor: item}).ContextMenu()
+		}
+		items = append(items, mwin.canvas.timelineContextMenu(tl)...)
+		if len(items) != 0 {
+			win.SetContextMenu(items)
 		}
 	}
 	for _, clicked := range mwin.canvas.clickedSpans {
+		if c, ok := clicked.Container(); ok {
+			if _, ok := c.Timeline.item.(*timelineGroup); ok {
+				// The activity of groups doesn't correspond to any events.
+				continue
+			}
+		}
 		mwin.openSpan(clicked)
 		// FIXME(dh): canvas does event handling _after_ layout, so we need a second frame
 		op.InvalidateOp{}.Add(gtx.Ops)
@@ -1119,23 +1473,36 @@ func (mwin *MainWindow) showFileOpenDialog() {
 func (mwin *MainWindow) loadTraceImpl(res loadTraceResult) {
 	NewCanvasInto(&mwin.canvas, mwin.debugWindow, res.trace)
 	mwin.canvas.start = res.start
-	mwin.canvas.memoryGraph = res.plot
-	mwin.canvas.timelines = append(mwin.canvas.timelines, res.timelines...)
+	mwin.canvas.plots = newCanvasPlots(mwin.twin, res.trace, mwin.settings.Plots)
+	mwin.canvas.settings = &mwin.settings
+	mwin.canvas.resizeMemoryTimelines.Ratio = mwin.settings.PlotsRatio
+	mwin.canvas.allTimelines = append(mwin.canvas.allTimelines, res.timelines...)
+	mwin.canvas.bookmarks = Bookmarks{tracePath: res.path, items: res.bookmarks}
+	mwin.mainMenu.setPlotKinds(mwin, mwin.twin, availablePlotKinds(res.trace))
 
 	for _, tl := range res.timelines {
 		assert(tl.item != nil, "unexpected nil item")
 		mwin.canvas.itemToTimeline[tl.item] = tl
 	}
+	mwin.canvas.updateDisplayedTimelines()
 
 	mwin.trace = res.trace
+	mwin.search = nil
 	mwin.panel = nil
 	mwin.panelHistory = nil
 	mwin.tabs = mwin.tabs[:1]
 	mwin.tabbedState.Current = 0
 	mwin.openTabBg(Tab{
-		Component:  NewGoroutinesComponent(mwin.trace.Goroutines),
+		Component:  NewGoroutinesComponent(mwin.trace, mwin.trace.Goroutines),
 		Unclosable: true,
 	})
+	mwin.tracePath = res.path
+
+	if res.session != nil {
+		if err := mwin.RestoreSession(res.session); err != nil {
+			log.Println("couldn't restore session:", err)
+		}
+	}
 }
 
 type durationNumberFormat uint8
@@ -1446,9 +1813,13 @@ func main() {
 
 type loadTraceResult struct {
 	trace      *Trace
-	plot       Plot
 	start, end trace.Timestamp
 	timelines  []*Timeline
+	// The path of the trace file, if the trace was loaded from a file.
+	path      string
+	bookmarks []Bookmark
+	// The session stored alongside the trace, if any.
+	session *Session
 }
 
 type progresser interface {
@@ -1457,7 +1828,7 @@ type progresser interface {
 	SetProgress(p float64)
 }
 
-func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
+func loadTrace(f io.Reader, p progresser, cv *Canvas, settings Settings) (loadTraceResult, error) {
 	names := []string{
 		"Parsing trace",
 		"Parsing trace",
@@ -1579,25 +1950,6 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 	start := trace.Timestamp(-slack)
 	end = trace.Timestamp(float64(end) + slack)
 
-	mg := Plot{
-		Name: "Memory usage",
-		Unit: "bytes",
-	}
-	mg.AddSeries(
-		PlotSeries{
-			Name:   "Heap size",
-			Points: pt.HeapSize,
-			Style:  PlotFilled,
-			Color:  oklch(70.59, 0.102, 139.64),
-		},
-		PlotSeries{
-			Name:   "Heap goal",
-			Points: pt.HeapGoal,
-			Style:  PlotStaircase,
-			Color:  colors[colorStateBlockedGC],
-		},
-	)
-
 	var goroot, gopath string
 	for _, fn := range tr.Functions {
 		if strings.HasPrefix(fn.Fn, "runtime.") && strings.Count(fn.Fn, ".") == 1 && strings.Contains(fn.File, filepath.Join("go", "src", "runtime")) && !strings.ContainsRune(fn.Fn, os.PathSeparator) {
@@ -1640,9 +1992,20 @@ func loadTrace(f io.Reader, p progresser, cv *Canvas) (loadTraceResult, error) {
 	tr.GOROOT = goroot
 	tr.GOPATH = gopath
 
+	tr.userMetrics = ptrace.UserMetrics(pt, settings.MetricPrefixes)
+	metricKeys := map[string]struct{}{}
+	for _, m := range tr.userMetrics {
+		metricKeys[m.Key] = struct{}{}
+	}
+	tr.userMetricKeys = map[uint64]struct{}{}
+	for id, s := range pt.Strings {
+		if _, ok := metricKeys[s]; ok {
+			tr.userMetricKeys[id] = struct{}{}
+		}
+	}
+
 	return loadTraceResult{
 		trace:     tr,
-		plot:      mg,
 		start:     start,
 		end:       end,
 		timelines: timelines,
@@ -1797,6 +2160,14 @@ func showTooltipSettingNotification(win *theme.Window, gtx layout.Context, t sho
 	win.ShowNotification(gtx, s)
 }
 
+func showHideNonMatchingSettingNotification(win *theme.Window, gtx layout.Context, b bool) {
+	if b {
+		win.ShowNotification(gtx, "Showing only timelines with highlighted spans")
+	} else {
+		win.ShowNotification(gtx, "Showing timelines regardless of highlighted spans")
+	}
+}
+
 func showGCOverlaySettingNotification(win *theme.Window, gtx layout.Context, t showGCOverlays) {
 	var s string
 	switch t {
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"sort"
+
+	"github.com/joonho3020/gotraceui/clip"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+
+	"gioui.org/f32"
+	"gioui.org/text"
+)
+
+func formatAffinity(a float64) string {
+	return local.Sprintf("%.2f%%", a*100)
+}
+
+// goroutineMigrationTable lists goroutines and how often they migrated between processors.
+type goroutineMigrationTable struct {
+	goroutines SortedIndices[ptrace.GoroutineMigrations, []ptrace.GoroutineMigrations]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func (mt *goroutineMigrationTable) initTable(win *theme.Window, gtx layout.Context) {
+	if mt.table != nil {
+		return
+	}
+	mt.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Goroutine", Alignment: text.End, Clickable: true},
+		{Name: "Function", Alignment: text.Start, Clickable: true},
+		{Name: "Runs", Alignment: text.End, Clickable: true},
+		{Name: "Migrations", Alignment: text.End, Clickable: true},
+		{Name: "Processors", Alignment: text.End, Clickable: true},
+		{Name: "P affinity", Alignment: text.End, Clickable: true},
+	}
+	mt.table.SetColumns(win, gtx, cols)
+	mt.table.SortedBy = 3
+	mt.table.SortOrder = theme.SortDescending
+	mt.sort()
+}
+
+func (mt *goroutineMigrationTable) sort() {
+	desc := mt.table.SortOrder == theme.SortDescending
+	switch mt.table.Columns[mt.table.SortedBy].Name {
+	case "Goroutine":
+		mt.goroutines.Sort(func(a, b ptrace.GoroutineMigrations) int {
+			return cmp(a.Goroutine.ID, b.Goroutine.ID, desc)
+		})
+	case "Function":
+		mt.goroutines.Sort(func(a, b ptrace.GoroutineMigrations) int {
+			return cmp(a.Goroutine.Function.Fn, b.Goroutine.Function.Fn, desc)
+		})
+	case "Runs":
+		mt.goroutines.Sort(func(a, b ptrace.GoroutineMigrations) int {
+			return cmp(a.Runs, b.Runs, desc)
+		})
+	case "Migrations":
+		mt.goroutines.Sort(func(a, b ptrace.GoroutineMigrations) int {
+			return cmp(a.Migrations, b.Migrations, desc)
+		})
+	case "Processors":
+		mt.goroutines.Sort(func(a, b ptrace.GoroutineMigrations) int {
+			return cmp(a.Processors, b.Processors, desc)
+		})
+	case "P affinity":
+		mt.goroutines.Sort(func(a, b ptrace.GoroutineMigrations) int {
+			return cmp(a.Affinity, b.Affinity, desc)
+		})
+	}
+}
+
+func (mt *goroutineMigrationTable) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	mt.initTable(win, gtx)
+	mt.table.Update(gtx)
+	if _, ok := mt.table.SortByClickedColumn(); ok {
+		mt.sort()
+	}
+	mt.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		gm := mt.goroutines.Ptr(row)
+		switch colName := mt.table.Columns[col].Name; colName {
+		case "Goroutine":
+			return mt.cellFormatter.Goroutine(win, gtx, gm.Goroutine, "")
+		case "Function":
+			return mt.cellFormatter.Function(win, gtx, gm.Goroutine.Function)
+		case "Runs":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return mt.cellFormatter.Number(win, gtx, gm.Runs)
+			})
+		case "Migrations":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return mt.cellFormatter.Number(win, gtx, gm.Migrations)
+			})
+		case "Processors":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return mt.cellFormatter.Number(win, gtx, gm.Processors)
+			})
+		case "P affinity":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return mt.cellFormatter.Text(win, gtx, formatAffinity(gm.Affinity))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	gtx.Constraints.Min = gtx.Constraints.Max
+	return theme.SimpleTable(win, gtx, mt.table, &mt.scrollState, mt.goroutines.Len(), cellFn)
+}
+
+// functionMigrationTable lists functions and how often the goroutines that started in them migrated between
+// processors.
+type functionMigrationTable struct {
+	functions SortedIndices[ptrace.FunctionMigrations, []ptrace.FunctionMigrations]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+// migrationRate returns the share of runs that started on a different processor than the previous run.
+func migrationRate(fm *ptrace.FunctionMigrations) float64 {
+	if fm.Runs == 0 {
+		return 0
+	}
+	return float64(fm.Migrations) / float64(fm.Runs)
+}
+
+func (mt *functionMigrationTable) initTable(win *theme.Window, gtx layout.Context) {
+	if mt.table != nil {
+		return
+	}
+	mt.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Function", Alignment: text.Start, Clickable: true},
+		{Name: "Goroutines", Alignment: text.End, Clickable: true},
+		{Name: "Runs", Alignment: text.End, Clickable: true},
+		{Name: "Migrations", Alignment: text.End, Clickable: true},
+		{Name: "Migration rate", Alignment: text.End, Clickable: true},
+	}
+	mt.table.SetColumns(win, gtx, cols)
+	// ComputeFunctionMigrations sorts functions by the number of migrations.
+	mt.table.SortedBy = 3
+	mt.table.SortOrder = theme.SortDescending
+}
+
+func (mt *functionMigrationTable) sort() {
+	desc := mt.table.SortOrder == theme.SortDescending
+	switch mt.table.Columns[mt.table.SortedBy].Name {
+	case "Function":
+		mt.functions.Sort(func(a, b ptrace.FunctionMigrations) int {
+			return cmp(a.Function.Fn, b.Function.Fn, desc)
+		})
+	case "Goroutines":
+		mt.functions.Sort(func(a, b ptrace.FunctionMigrations) int {
+			return cmp(a.Goroutines, b.Goroutines, desc)
+		})
+	case "Runs":
+		mt.functions.Sort(func(a, b ptrace.FunctionMigrations) int {
+			return cmp(a.Runs, b.Runs, desc)
+		})
+	case "Migrations":
+		mt.functions.Sort(func(a, b ptrace.FunctionMigrations) int {
+			return cmp(a.Migrations, b.Migrations, desc)
+		})
+	case "Migration rate":
+		mt.functions.Sort(func(a, b ptrace.FunctionMigrations) int {
+			return cmp(migrationRate(&a), migrationRate(&b), desc)
+		})
+	}
+}
+
+func (mt *functionMigrationTable) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	mt.initTable(win, gtx)
+	mt.table.Update(gtx)
+	if _, ok := mt.table.SortByClickedColumn(); ok {
+		mt.sort()
+	}
+	mt.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		fm := mt.functions.Ptr(row)
+		switch colName := mt.table.Columns[col].Name; colName {
+		case "Function":
+			return mt.cellFormatter.Function(win, gtx, fm.Function)
+		case "Goroutines":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return mt.cellFormatter.Number(win, gtx, fm.Goroutines)
+			})
+		case "Runs":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return mt.cellFormatter.Number(win, gtx, fm.Runs)
+			})
+		case "Migrations":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return mt.cellFormatter.Number(win, gtx, fm.Migrations)
+			})
+		case "Migration rate":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return mt.cellFormatter.Text(win, gtx, local.Sprintf("%.2f%%", migrationRate(fm)*100))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	gtx.Constraints.Min = gtx.Constraints.Max
+	return theme.SimpleTable(win, gtx, mt.table, &mt.scrollState, mt.functions.Len(), cellFn)
+}
+
+// MigrationsComponent ranks goroutines and functions by how often they migrated between processors.
+type MigrationsComponent struct {
+	computed *theme.Future[migrationsComponentData]
+	// Whether the tables have been populated with the result of computed.
+	initialized bool
+
+	byGoroutine goroutineMigrationTable
+	byFunction  functionMigrationTable
+	hoveredLink ObjectLink
+}
+
+type migrationsComponentData struct {
+	goroutines []ptrace.GoroutineMigrations
+	functions  []ptrace.FunctionMigrations
+}
+
+func NewMigrationsComponent(win *theme.Window, tr *Trace) *MigrationsComponent {
+	return &MigrationsComponent{
+		computed: theme.NewFuture(win, func(cancelled <-chan struct{}) migrationsComponentData {
+			// Goroutines that never ran can't have migrated.
+			var gms []ptrace.GoroutineMigrations
+			for _, gm := range tr.Migrations().goroutines {
+				if gm.Runs != 0 {
+					gms = append(gms, gm)
+				}
+			}
+			return migrationsComponentData{
+				goroutines: gms,
+				functions:  ptrace.ComputeFunctionMigrations(gms),
+			}
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*MigrationsComponent) Title() string {
+	return "Goroutine migrations"
+}
+
+// Transition implements theme.Component.
+func (*MigrationsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*MigrationsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (mc *MigrationsComponent) HoveredLink() ObjectLink {
+	return mc.hoveredLink
+}
+
+// Layout implements theme.Component.
+func (mc *MigrationsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.MigrationsComponent.Layout").End()
+
+	data, ok := mc.computed.Result()
+	if !ok {
+		return theme.Label(win.Theme, "Computing migrations…").Layout(win, gtx)
+	}
+	if !mc.initialized {
+		mc.byGoroutine.goroutines.Reset(data.goroutines)
+		mc.byFunction.functions.Reset(data.functions)
+		mc.initialized = true
+	}
+	if mc.byGoroutine.goroutines.Len() == 0 {
+		return theme.Label(win.Theme, "No goroutines ran on any processors.").Layout(win, gtx)
+	}
+
+	dims := layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return mc.byGoroutine.Layout(win, gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return mc.byFunction.Layout(win, gtx)
+		}),
+	)
+
+	mc.hoveredLink = mc.byGoroutine.cellFormatter.HoveredLink()
+	if mc.hoveredLink == nil {
+		mc.hoveredLink = mc.byFunction.cellFormatter.HoveredLink()
+	}
+	return dims
+}
+
+// ShowGoroutinePath overlays the path that g took across processors on the processor timelines.
+func (cv *Canvas) ShowGoroutinePath(g *ptrace.Goroutine) {
+	cv.goroutinePath.goroutine = g
+	cv.goroutinePath.runs = ptrace.GoroutineProcessorRuns(cv.trace.Trace, g)
+}
+
+func (cv *Canvas) HideGoroutinePath() {
+	cv.goroutinePath.goroutine = nil
+	cv.goroutinePath.runs = nil
+}
+
+// drawGoroutinePath draws the goroutine path overlay. Each run is drawn as a line on its processor's timeline, and
+// consecutive runs are connected, making migrations stand out as diagonal lines.
+func (cv *Canvas) drawGoroutinePath(win *theme.Window, gtx layout.Context) {
+	runs := cv.goroutinePath.runs
+	if len(runs) == 0 {
+		return
+	}
+
+	// Find the vertical center of every displayed processor timeline.
+	cvy := cv.denormalizeY(gtx, cv.y)
+	centers := map[*ptrace.Processor]float32{}
+	for i, tl := range cv.timelines {
+		p, ok := tl.item.(*ptrace.Processor)
+		if !ok {
+			continue
+		}
+		start := 0
+		if i > 0 {
+			start = cv.timelineEnds[i-1]
+		}
+		centers[p] = float32(start-cvy) + float32(cv.timelineEnds[i]-start)/2
+	}
+
+	// Only draw the visible runs, as well as the runs just outside the visible area that connect to them.
+	lo := sort.Search(len(runs), func(i int) bool { return runs[i].End >= cv.start })
+	hi := sort.Search(len(runs), func(i int) bool { return runs[i].Start > cv.End() })
+	runs = runs[max(lo-1, 0):min(hi+1, len(runs))]
+
+	width := float32(gtx.Constraints.Max.X)
+	c := win.Theme.Palette.NavigationLink
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	var prev f32.Point
+	havePrev := false
+	for _, r := range runs {
+		y, ok := centers[r.Processor]
+		if !ok {
+			// The processor's timeline isn't displayed.
+			havePrev = false
+			continue
+		}
+		x0 := cv.tsToPx(r.Start)
+		x1 := max(cv.tsToPx(r.End), x0+1)
+		if havePrev {
+			p.MoveTo(prev)
+			p.LineTo(f32.Pt(x0, y))
+		}
+		p.MoveTo(f32.Pt(max(x0, -1), y))
+		p.LineTo(f32.Pt(min(x1, width+1), y))
+		prev = f32.Pt(x1, y)
+		havePrev = true
+
+		// Mark the start of each run so that back-to-back runs on the same processor remain distinguishable.
+		if x0 >= -3 && x0 <= width+3 {
+			rect := clip.Rect{
+				Min: image.Pt(int(round32(x0))-gtx.Dp(1), int(round32(y))-gtx.Dp(4)),
+				Max: image.Pt(int(round32(x0))+gtx.Dp(1), int(round32(y))+gtx.Dp(4)),
+			}
+			theme.FillShape(win, gtx.Ops, c, rect.Op())
+		}
+	}
+	theme.FillShape(win, gtx.Ops, c, clip.Stroke{Path: p.End(), Width: float32(gtx.Dp(2))}.Op())
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"strings"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// The number of buckets used for the plot of concurrent network waits.
+const networkGraphBuckets = 2000
+
+func networkTagsLabel(tags ptrace.SpanTags) string {
+	if tags == 0 {
+		return "untagged"
+	}
+	return strings.Join(spanTagStrings(tags), ", ")
+}
+
+// networkWaitSpans returns the spans of the network waits, sorted by start time.
+func networkWaitSpans(cv *Canvas, waits []ptrace.NetworkWait) Items[ptrace.Span] {
+	var bases []Items[ptrace.Span]
+	// Waits are sorted by goroutine, so we can collect runs of waits belonging to the same goroutine.
+	for i := 0; i < len(waits); {
+		g := waits[i].Goroutine
+		var subset []int
+		for ; i < len(waits) && waits[i].Goroutine == g; i++ {
+			subset = append(subset, waits[i].Span)
+		}
+
+		base := SimpleItems[ptrace.Span, any]{
+			items:    g.Spans,
+			subslice: true,
+		}
+		if tl, ok := cv.itemToTimeline[g]; ok {
+			base.container = ItemContainer{
+				Timeline: tl,
+				Track:    tl.tracks[0],
+			}
+		}
+		bases = append(bases, ItemsSubset[ptrace.Span]{Base: base, Subset: subset})
+	}
+	return MergeItems(bases, func(a, b *ptrace.Span) bool {
+		return a.Start < b.Start
+	})
+}
+
+func newNetworkGraph(tr *Trace, waits *ptrace.NetworkWaits) *Plot {
+	pl := &Plot{
+		Name: "Concurrent network waits",
+		Unit: "goroutines",
+	}
+	pl.AddSeries(PlotSeries{
+		Name:   "All",
+		Points: ptrace.NetworkWaitConcurrency(tr.Trace, waits.All, 0, networkGraphBuckets),
+		Style:  PlotStaircase,
+		Color:  colors[colorStateBlockedNet],
+	})
+
+	kinds := []struct {
+		name  string
+		tag   ptrace.SpanTags
+		color float32
+	}{
+		{"Dial", ptrace.SpanTagDial, 262.88},
+		{"TLS", ptrace.SpanTagTLS, 302.36},
+		{"Read", ptrace.SpanTagRead, 143.74},
+		{"Accept", ptrace.SpanTagAccept, 70.67},
+	}
+	for _, kind := range kinds {
+		var found bool
+		for _, group := range waits.ByTags {
+			if group.Tags&kind.tag != 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		pl.AddSeries(PlotSeries{
+			Name:   kind.name,
+			Points: ptrace.NetworkWaitConcurrency(tr.Trace, waits.All, kind.tag, networkGraphBuckets),
+			Style:  PlotStaircase,
+			Color:  oklch(colorsLightBase, colorsChromaBase, kind.color),
+		})
+	}
+	return pl
+}
+
+type networkData struct {
+	waits ptrace.NetworkWaits
+	graph *Plot
+}
+
+type networkWaitRow struct {
+	expanded bool
+	toggle   widget.Clickable
+	spans    widget.Clickable
+
+	text      Text
+	prevSpans []TextSpan
+}
+
+// networkWaitTable displays network waits grouped either by tags or by stack.
+type networkWaitTable struct {
+	byStack bool
+	total   float64
+
+	groups SortedIndices[ptrace.NetworkWaitGroup, []ptrace.NetworkWaitGroup]
+	// Per-row state, indexed by the index into groups.Items.
+	rows []networkWaitRow
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func (nt *networkWaitTable) reset(groups []ptrace.NetworkWaitGroup, total float64) {
+	nt.groups.Reset(groups)
+	nt.rows = make([]networkWaitRow, len(groups))
+	nt.total = total
+}
+
+func (nt *networkWaitTable) label(tr *Trace, group *ptrace.NetworkWaitGroup) string {
+	if nt.byStack {
+		return blockingFunction(tr, group.Stack)
+	}
+	return networkTagsLabel(group.Tags)
+}
+
+func (nt *networkWaitTable) initTable(win *theme.Window, gtx layout.Context) {
+	if nt.table != nil {
+		return
+	}
+	first := "Kind"
+	if nt.byStack {
+		first = "Blocked in"
+	}
+	nt.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: first, Alignment: text.Start, Clickable: true},
+		{Name: "Waits", Alignment: text.End, Clickable: true},
+		{Name: "Total", Alignment: text.End, Clickable: true},
+		{Name: "Avg.", Alignment: text.End, Clickable: true},
+		{Name: "Max.", Alignment: text.End, Clickable: true},
+		{Name: "Share", Alignment: text.End, Clickable: true},
+		{Name: "Spans", Alignment: text.Start},
+	}
+	nt.table.SetColumns(win, gtx, cols)
+	// ComputeNetworkWaits sorts groups by total duration.
+	nt.table.SortedBy = 2
+	nt.table.SortOrder = theme.SortDescending
+}
+
+func (nt *networkWaitTable) sort(tr *Trace) {
+	desc := nt.table.SortOrder == theme.SortDescending
+	switch nt.table.Columns[nt.table.SortedBy].Name {
+	case "Kind", "Blocked in":
+		nt.groups.Sort(func(a, b ptrace.NetworkWaitGroup) int {
+			return cmp(nt.label(tr, &a), nt.label(tr, &b), desc)
+		})
+	case "Waits":
+		nt.groups.Sort(func(a, b ptrace.NetworkWaitGroup) int {
+			return cmp(len(a.Waits), len(b.Waits), desc)
+		})
+	case "Total", "Share":
+		nt.groups.Sort(func(a, b ptrace.NetworkWaitGroup) int {
+			return cmp(a.Total, b.Total, desc)
+		})
+	case "Avg.":
+		nt.groups.Sort(func(a, b ptrace.NetworkWaitGroup) int {
+			return cmp(a.Avg(), b.Avg(), desc)
+		})
+	case "Max.":
+		nt.groups.Sort(func(a, b ptrace.NetworkWaitGroup) int {
+			return cmp(a.Max, b.Max, desc)
+		})
+	}
+}
+
+func (nt *networkWaitTable) Layout(win *theme.Window, gtx layout.Context, tr *Trace, cv *Canvas) layout.Dimensions {
+	nt.initTable(win, gtx)
+	nt.table.Update(gtx)
+	if _, ok := nt.table.SortByClickedColumn(); ok {
+		nt.sort(tr)
+	}
+
+	nt.cellFormatter.Update(win, gtx)
+	for i := range nt.rows {
+		row := &nt.rows[i]
+		group := &nt.groups.Items[i]
+		for {
+			if _, ok := row.toggle.Clicked(gtx); !ok {
+				break
+			}
+			row.expanded = !row.expanded
+		}
+		for {
+			if _, ok := row.spans.Clicked(gtx); !ok {
+				break
+			}
+			spans := networkWaitSpans(cv, group.Waits)
+			label := "Network waits: " + nt.label(tr, group)
+			cfg := SpansInfoConfig{
+				Title:         label,
+				Label:         label,
+				ShowHistogram: true,
+			}
+			win.EmitAction(&OpenPanelAction{NewSpansInfo(cfg, tr, win, theme.Immediate(spans), cv.AllTimelines())})
+		}
+		if row.expanded {
+			for _, ev := range row.text.Update(gtx, row.prevSpans) {
+				handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+			}
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, rowIdx, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := nt.groups.Ptr(rowIdx)
+		row := &nt.rows[nt.groups.Order[rowIdx]]
+		switch colName := nt.table.Columns[col].Name; colName {
+		case "Kind":
+			return nt.cellFormatter.Text(win, gtx, nt.label(tr, group))
+		case "Blocked in":
+			return row.toggle.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				indicator := "▶ "
+				if row.expanded {
+					indicator = "▼ "
+				}
+				return nt.cellFormatter.Text(win, gtx, indicator+nt.label(tr, group))
+			})
+		case "Waits":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return nt.cellFormatter.Number(win, gtx, len(group.Waits))
+			})
+		case "Total":
+			return nt.cellFormatter.Duration(win, gtx, group.Total, false)
+		case "Avg.":
+			return nt.cellFormatter.Duration(win, gtx, group.Avg(), false)
+		case "Max.":
+			return nt.cellFormatter.Duration(win, gtx, group.Max, false)
+		case "Share":
+			var pct float64
+			if nt.total != 0 {
+				pct = float64(group.Total) / nt.total * 100
+			}
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return nt.cellFormatter.Text(win, gtx, fmt.Sprintf("%.2f%%", pct))
+			})
+		case "Spans":
+			return row.spans.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, "Show spans", win.ColorMaterial(gtx, win.Theme.Palette.Link))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	rowFn := func(win *theme.Window, gtx layout.Context, rowIdx int) layout.Dimensions {
+		row := &nt.rows[nt.groups.Order[rowIdx]]
+		if !row.expanded {
+			return theme.TableSimpleRow(nt.table).Layout(win, gtx, rowIdx, cellFn)
+		}
+
+		return layout.Rigids(gtx, layout.Vertical,
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableSimpleRow(nt.table).Layout(win, gtx, rowIdx, cellFn)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableExpandedRow(nt.table).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(5).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						group := nt.groups.Ptr(rowIdx)
+						tb := TextBuilder{Window: win}
+						tb.Bold("Blocked at:\n")
+						tb.Span(formatStack(tr, group.Stack))
+						row.text.Reset(win.Theme)
+						row.prevSpans = tb.Spans
+						return row.text.Layout(win, gtx, row.prevSpans)
+					})
+				})
+			},
+		)
+	}
+
+	gtx.Constraints.Min = gtx.Constraints.Max
+	return theme.FairlySimpleTable(win, gtx, nt.table, &nt.scrollState, nt.groups.Len(), rowFn)
+}
+
+type NetworkComponent struct {
+	trace  *Trace
+	canvas *Canvas
+	data   *theme.Future[networkData]
+
+	showGraph widget.Bool
+	// Whether the tables have been populated with the result of data.
+	initialized bool
+
+	byTags  networkWaitTable
+	byStack networkWaitTable
+}
+
+func NewNetworkComponent(win *theme.Window, tr *Trace, cv *Canvas) *NetworkComponent {
+	return &NetworkComponent{
+		trace:  tr,
+		canvas: cv,
+		data: theme.NewFuture(win, func(cancelled <-chan struct{}) networkData {
+			waits := ptrace.ComputeNetworkWaits(tr.Trace)
+			return networkData{
+				waits: waits,
+				graph: newNetworkGraph(tr, &waits),
+			}
+		}),
+		byStack: networkWaitTable{byStack: true},
+	}
+}
+
+// Title implements theme.Component.
+func (*NetworkComponent) Title() string {
+	return "Network I/O"
+}
+
+// Transition implements theme.Component.
+func (*NetworkComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*NetworkComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+// Layout implements theme.Component.
+func (nc *NetworkComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.NetworkComponent.Layout").End()
+
+	data, ok := nc.data.Result()
+	if !ok {
+		return theme.Label(win.Theme, "Computing network waits…").Layout(win, gtx)
+	}
+	if len(data.waits.All) == 0 {
+		return theme.Label(win.Theme, "No goroutines blocked on network I/O.").Layout(win, gtx)
+	}
+
+	if !nc.initialized {
+		nc.byTags.reset(data.waits.ByTags, float64(data.waits.Total))
+		nc.byStack.reset(data.waits.ByStack, float64(data.waits.Total))
+		nc.initialized = true
+	}
+
+	// The plot may have been removed via its context menu.
+	nc.showGraph.Value = nc.canvas.HasPlot(plotKindByID("network"))
+	if nc.showGraph.Update(gtx) {
+		if nc.showGraph.Value {
+			win.EmitAction(&CanvasSetNetworkGraphAction{Plot: data.graph})
+		} else {
+			win.EmitAction(&CanvasSetNetworkGraphAction{})
+		}
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			label := local.Sprintf("%d network waits, totalling %s", len(data.waits.All), roundDuration(data.waits.Total))
+			return theme.Label(win.Theme, label).Layout(win, gtx)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return theme.CheckBox(win.Theme, &nc.showGraph, "Show concurrent network waits on the canvas").Layout(win, gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return nc.byTags.Layout(win, gtx, nc.trace, nc.canvas)
+		}),
+		layout.Rigid(layout.Spacer{Height: 5}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return nc.byStack.Layout(win, gtx, nc.trace, nc.canvas)
+		}),
+	)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/joonho3020/gotraceui/clip"
+	"github.com/joonho3020/gotraceui/gesture"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/unit"
+)
+
+const (
+	overviewHeightDp   unit.Dp = 24
+	overviewGCHeightDp unit.Dp = 4
+	// The number of buckets that processor utilization gets aggregated into for the overview.
+	overviewBuckets = 1000
+)
+
+// computeOverviewBusy returns the utilization of all processors over the whole trace, as fractions in the range [0, 1],
+// averaged across processors. It returns nil if it was cancelled.
+func computeOverviewBusy(tr *Trace, cancelled <-chan struct{}) []float32 {
+	end := tr.End()
+	if len(tr.Processors) == 0 || end <= 0 {
+		return nil
+	}
+	bucket := time.Duration(math.Ceil(float64(end) / overviewBuckets))
+	var out []float32
+	for _, p := range tr.Processors {
+		if TryRecv(cancelled) {
+			return nil
+		}
+		buckets := ptrace.ComputeProcessorBusy(tr.Trace, p, bucket)
+		if out == nil {
+			out = make([]float32, len(buckets))
+		}
+		for i, v := range buckets {
+			out[i] += float32(v) / 100
+		}
+	}
+	for i := range out {
+		out[i] /= float32(len(tr.Processors))
+	}
+	return out
+}
+
+// Overview is a thin strip showing the activity of the whole trace, with a rectangle marking the part of the trace
+// that is currently visible on the canvas. Clicking on it jumps to that point in time, and dragging the rectangle pans
+// the canvas.
+type Overview struct {
+	cv   *Canvas
+	drag gesture.Drag
+	// The utilization of all processors, computed in the background. Until it is done, only garbage collections and the
+	// viewport are drawn.
+	busy *theme.Future[[]float32]
+
+	dragging struct {
+		active bool
+		moved  bool
+		// The distance in pixels between the pointer and the start of the viewport rectangle.
+		offset  float32
+		nsPerPx float64
+		y       normalizedY
+		start   trace.Timestamp
+	}
+}
+
+func (o *Overview) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	cv := o.cv
+	size := image.Pt(gtx.Constraints.Max.X, gtx.Dp(overviewHeightDp))
+	end := cv.trace.End()
+	if end <= 0 || size.X <= 0 {
+		return layout.Dimensions{Size: size}
+	}
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	o.drag.Add(gtx.Ops)
+
+	width := float32(size.X)
+	height := float32(size.Y)
+	tsToPx := func(ts trace.Timestamp) float32 {
+		return float32(float64(ts) / float64(end) * float64(width))
+	}
+	pxToTs := func(px float32) trace.Timestamp {
+		return trace.Timestamp(math.Round(float64(px) / float64(width) * float64(end)))
+	}
+
+	minViewportWidth := float32(gtx.Dp(2))
+	viewport := func() (float32, float32) {
+		x0, x1 := tsToPx(cv.start), tsToPx(cv.End())
+		if x1-x0 < minViewportWidth {
+			c := (x0 + x1) / 2
+			x0, x1 = c-minViewportWidth/2, c+minViewportWidth/2
+		}
+		return x0, x1
+	}
+
+	for _, ev := range o.drag.Update(gtx.Metric, gtx.Queue, gesture.Horizontal) {
+		switch ev.Kind {
+		case pointer.Press:
+			x0, x1 := viewport()
+			d := &o.dragging
+			d.active = true
+			d.moved = false
+			d.nsPerPx = cv.nsPerPx
+			d.y = cv.y
+			if !cv.animate.Done() {
+				// Continue from where the current navigation is headed, not from where it currently is.
+				d.nsPerPx = cv.animate.EndValue.nsPerPx
+				d.y = cv.animate.EndValue.y
+			}
+			if ev.Position.X >= x0 && ev.Position.X <= x1 {
+				// Grab the viewport where the user clicked.
+				d.offset = ev.Position.X - x0
+			} else {
+				// Jump to the clicked point, centering the viewport on it.
+				d.offset = (x1 - x0) / 2
+				d.start = pxToTs(ev.Position.X - d.offset)
+				cv.navigateTo(gtx, d.start, d.nsPerPx, d.y)
+			}
+		case pointer.Drag:
+			d := &o.dragging
+			if !d.active {
+				continue
+			}
+			d.moved = true
+			d.start = pxToTs(ev.Position.X - d.offset)
+			cv.navigateToNoHistory(gtx, d.start, d.nsPerPx, d.y)
+		case pointer.Release, pointer.Cancel:
+			d := &o.dragging
+			if d.active && d.moved {
+				// Only record the final location of the drag, not every intermediate one.
+				cv.locationHistory.push(LocationHistoryEntry{
+					start:   d.start,
+					nsPerPx: d.nsPerPx,
+					y:       d.y,
+				})
+			}
+			d.active = false
+		}
+	}
+	if o.dragging.active {
+		pointer.CursorGrabbing.Add(gtx.Ops)
+	} else {
+		pointer.CursorPointer.Add(gtx.Ops)
+	}
+
+	if o.busy == nil {
+		tr := cv.trace
+		o.busy = theme.NewFuture(win, func(cancelled <-chan struct{}) []float32 {
+			return computeOverviewBusy(tr, cancelled)
+		})
+	}
+
+	// Draw the processor utilization as an area graph.
+	if busy, _ := o.busy.ResultNoWait(); len(busy) != 0 {
+		// Buckets are rounded up in size, so the last bucket may extend past the end of the trace.
+		bucketWidth := tsToPx(trace.Timestamp(math.Ceil(float64(end) / overviewBuckets)))
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		p.MoveTo(f32.Pt(0, height))
+		for i, v := range busy {
+			y := height - v*height
+			p.LineTo(f32.Pt(float32(i)*bucketWidth, y))
+			p.LineTo(f32.Pt(min(float32(i+1)*bucketWidth, width), y))
+		}
+		p.LineTo(f32.Pt(width, height))
+		p.Close()
+		theme.FillShape(win, gtx.Ops, colors[colorStateActive], clip.Outline{Path: p.End()}.Op())
+	}
+
+	// Mark garbage collections along the bottom edge.
+	if len(cv.trace.GC) != 0 {
+		gcHeight := float32(gtx.Dp(overviewGCHeightDp))
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		for _, s := range cv.trace.GC {
+			x0 := tsToPx(s.Start)
+			x1 := max(tsToPx(s.End), x0+1)
+			clip.FRect{
+				Min: f32.Pt(x0, height-gcHeight),
+				Max: f32.Pt(x1, height),
+			}.IntoPath(&p)
+		}
+		theme.FillShape(win, gtx.Ops, colors[colorStateGC], clip.Outline{Path: p.End()}.Op())
+	}
+
+	// Draw the viewport.
+	x0, x1 := viewport()
+	c := win.Theme.Palette.PrimarySelection
+	c.A = 0.35
+	theme.FillShape(win, gtx.Ops, c, clip.FRect{Min: f32.Pt(x0, 0), Max: f32.Pt(x1, height)}.Op(gtx.Ops))
+	w := float32(gtx.Dp(1))
+	c.A = 1
+	for _, px := range [2]float32{x0, x1} {
+		rect := clip.FRect{
+			Min: f32.Pt(px-w/2, 0),
+			Max: f32.Pt(px+w/2, height),
+		}
+		theme.FillShape(win, gtx.Ops, c, rect.Op(gtx.Ops))
+	}
+
+	// Separate the overview from the axis.
+	theme.FillShape(win, gtx.Ops, win.Theme.Palette.Border, clip.FRect{Min: f32.Pt(0, height-w), Max: f32.Pt(width, height)}.Op(gtx.Ops))
+
+	return layout.Dimensions{Size: size}
+}
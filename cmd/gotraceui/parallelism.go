@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"time"
+
+	"github.com/joonho3020/gotraceui/clip"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/text"
+)
+
+func newParallelismPlot(tr *Trace) *Plot {
+	par := ptrace.ComputeParallelism(tr.Trace, 0, tr.End())
+	pl := &Plot{
+		Name: "Parallelism",
+		Unit: "Ps",
+	}
+	pl.AddSeries(
+		PlotSeries{
+			Name:   "Running user goroutines",
+			Points: par.Points,
+			Style:  PlotFilled,
+			Color:  colors[colorStateActive],
+		},
+		PlotSeries{
+			Name:   "GOMAXPROCS",
+			Points: tr.GOMAXPROCS,
+			Style:  PlotStaircase,
+			Color:  colors[colorStateBlocked],
+		},
+	)
+	return pl
+}
+
+type parallelismRow struct {
+	highlight widget.Clickable
+}
+
+// ParallelismComponent displays how much time was spent with a given number of processors running user goroutines,
+// over the whole trace or over a range of it. Levels of parallelism can be highlighted on the canvas.
+type ParallelismComponent struct {
+	trace  *Trace
+	canvas *Canvas
+
+	par *theme.Future[ptrace.Parallelism]
+	// Per-row state, indexed by level.
+	rows []parallelismRow
+	// The level whose intervals are highlighted on the canvas, or -1.
+	highlighted int
+
+	useVisibleRange  widget.PrimaryClickable
+	useSelectedRange widget.PrimaryClickable
+	useEntireTrace   widget.PrimaryClickable
+
+	descriptionText Text
+	prevSpans       []TextSpan
+	hoveredLink     ObjectLink
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewParallelismComponent(win *theme.Window, tr *Trace, cv *Canvas, start, end trace.Timestamp) *ParallelismComponent {
+	pc := &ParallelismComponent{
+		trace:       tr,
+		canvas:      cv,
+		highlighted: -1,
+	}
+	pc.compute(win, start, end)
+	return pc
+}
+
+// compute computes the parallelism between start and end in the background, replacing the current result.
+func (pc *ParallelismComponent) compute(win *theme.Window, start, end trace.Timestamp) {
+	tr := pc.trace.Trace
+	pc.par = theme.NewFuture(win, func(cancelled <-chan struct{}) ptrace.Parallelism {
+		return ptrace.ComputeParallelism(tr, start, end)
+	})
+	pc.rows = nil
+	if pc.highlighted != -1 {
+		pc.highlighted = -1
+		pc.canvas.HighlightIntervals(nil)
+	}
+}
+
+// Title implements theme.Component.
+func (*ParallelismComponent) Title() string {
+	return "Parallelism"
+}
+
+// Transition implements theme.Component.
+func (*ParallelismComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*ParallelismComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (pc *ParallelismComponent) HoveredLink() ObjectLink {
+	return pc.hoveredLink
+}
+
+func (pc *ParallelismComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if pc.table != nil {
+		return
+	}
+	pc.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Running Ps", Alignment: text.Start},
+		{Name: "Time", Alignment: text.End},
+		{Name: "Share", Alignment: text.End},
+	}
+	pc.table.SetColumns(win, gtx, cols)
+}
+
+// Layout implements theme.Component.
+func (pc *ParallelismComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.ParallelismComponent.Layout").End()
+
+	pc.initTable(win, gtx)
+	pc.table.Update(gtx)
+	pc.cellFormatter.Update(win, gtx)
+
+	selected, haveSelected := pc.canvas.SelectedRange()
+	for pc.useVisibleRange.Clicked(gtx) {
+		pc.compute(win, max(pc.canvas.start, 0), min(pc.canvas.End(), pc.trace.End()))
+	}
+	for pc.useSelectedRange.Clicked(gtx) {
+		if haveSelected {
+			pc.compute(win, max(selected.Start, 0), min(selected.End, pc.trace.End()))
+		}
+	}
+	for pc.useEntireTrace.Clicked(gtx) {
+		pc.compute(win, 0, pc.trace.End())
+	}
+	if pc.highlighted != -1 && len(pc.canvas.highlightedIntervals) == 0 {
+		// The highlight has been removed via the canvas.
+		pc.highlighted = -1
+	}
+
+	buttons := func(gtx layout.Context) layout.Dimensions {
+		children := []layout.Widget{
+			theme.Dumb(win, theme.Button(win.Theme, &pc.useVisibleRange.Clickable, "Use visible range").Layout),
+			layout.Spacer{Width: 5}.Layout,
+		}
+		if haveSelected {
+			children = append(children,
+				theme.Dumb(win, theme.Button(win.Theme, &pc.useSelectedRange.Clickable, "Use selected range").Layout),
+				layout.Spacer{Width: 5}.Layout,
+			)
+		}
+		children = append(children, theme.Dumb(win, theme.Button(win.Theme, &pc.useEntireTrace.Clickable, "Use entire trace").Layout))
+		return layout.Rigids(gtx, layout.Horizontal, children...)
+	}
+
+	par, ok := pc.par.ResultNoWait()
+	if !ok {
+		return layout.Rigids(gtx, layout.Vertical,
+			buttons,
+			layout.Spacer{Height: 5}.Layout,
+			theme.Dumb(win, theme.Label(win.Theme, "Computing parallelism…").Layout),
+		)
+	}
+	if pc.rows == nil {
+		pc.rows = make([]parallelismRow, len(par.Durations))
+	}
+	for level := range pc.rows {
+		for {
+			if _, ok := pc.rows[level].highlight.Clicked(gtx); !ok {
+				break
+			}
+			if pc.highlighted == level {
+				pc.highlighted = -1
+				pc.canvas.HighlightIntervals(nil)
+			} else {
+				pc.highlighted = level
+				pc.canvas.HighlightIntervals(par.Intervals(level))
+			}
+		}
+	}
+
+	for _, ev := range pc.descriptionText.Update(gtx, pc.prevSpans) {
+		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+	}
+	pc.hoveredLink = pc.descriptionText.HoveredLink()
+
+	total := time.Duration(par.End - par.Start)
+	share := func(d time.Duration) float32 {
+		if total <= 0 {
+			return 0
+		}
+		return float32(d) / float32(total)
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, level, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		d := par.Durations[level]
+		switch colName := pc.table.Columns[col].Name; colName {
+		case "Running Ps":
+			return pc.rows[level].highlight.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				label := local.Sprintf("%d", level)
+				if pc.highlighted == level {
+					label += " (highlighted)"
+				}
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.Link))
+			})
+		case "Time":
+			return pc.cellFormatter.Duration(win, gtx, d, false)
+		case "Share":
+			// Display the share as a bar, turning the table into a histogram.
+			s := share(d)
+			bar := clip.FRect{Max: f32.Pt(float32(gtx.Constraints.Max.X)*s, float32(gtx.Constraints.Max.Y))}
+			theme.FillShape(win, gtx.Ops, colors[colorStateActive], bar.Op(gtx.Ops))
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return pc.cellFormatter.Text(win, gtx, local.Sprintf("%.2f%%", s*100))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		buttons,
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = image.Point{}
+			tb := TextBuilder{Window: win}
+			tb.Span("Between ")
+			tb.DefaultLink(formatTimestamp(nil, par.Start), "Parallelism", par.Start)
+			tb.Span(" and ")
+			tb.DefaultLink(formatTimestamp(nil, par.End), "Parallelism", par.End)
+			tb.Span(local.Sprintf(", on average %.2f processors were running user goroutines. Click on a number of processors to highlight the corresponding intervals.", par.Average()))
+			pc.descriptionText.Reset(win.Theme)
+			pc.prevSpans = tb.Spans
+			return pc.descriptionText.Layout(win, gtx, pc.prevSpans)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.SimpleTable(win, gtx, pc.table, &pc.scrollState, len(par.Durations), cellFn)
+		},
+	)
+}
+
+// HighlightIntervals highlights time intervals on the canvas, replacing any previous highlight. Passing nil removes the
+// highlight.
+func (cv *Canvas) HighlightIntervals(spans []ptrace.Span) {
+	cv.highlightedIntervals = spans
+}
@@ -37,14 +37,32 @@ type PlotSeries struct {
 	Points []ptrace.Point
 	Style  PlotStyle
 	Color  color.Oklch
+	// If set, the series uses its own unit instead of the plot's, and is scaled independently of the other series.
+	Unit string
 
 	decimated [][]ptrace.Point
 	disabled  bool
+	// The extents of series with their own unit.
+	min, max uint64
+}
+
+func (s *PlotSeries) unit(pl *Plot) string {
+	if s.Unit != "" {
+		return s.Unit
+	}
+	return pl.Unit
 }
 
 type Plot struct {
-	Name   string
-	Unit   string
+	Name string
+	Unit string
+	// If set, the series are stacked. Each series' points hold the sum of its own values and those of all series that
+	// were added after it, and all series share the same timestamps. Series should use the PlotFilled style.
+	Stacked bool
+	// OnClick, if set, gets called when the plot is clicked with the primary button. series is the series under the
+	// pointer, or nil.
+	OnClick func(ts trace.Timestamp, series *PlotSeries) theme.Action
+
 	series []PlotSeries
 
 	min uint64
@@ -84,6 +102,15 @@ func (pl *Plot) AddSeries(series ...PlotSeries) {
 	for i := range series {
 		s := &series[i]
 		s.decimated = decimate(s.Points)
+		if s.Unit != "" {
+			for _, p := range s.Points {
+				s.max = max(s.max, p.Value)
+			}
+			s.max += s.max / 10
+			if s.max == 0 {
+				s.max = 1
+			}
+		}
 	}
 
 	pl.series = append(pl.series, series...)
@@ -97,7 +124,7 @@ func (pl *Plot) computeExtents(start, end trace.Timestamp) (min, max uint64) {
 	max = 0
 
 	for _, s := range pl.series {
-		if s.disabled {
+		if s.disabled || s.Unit != "" {
 			continue
 		}
 		idx := sort.Search(len(s.Points), func(i int) bool {
@@ -151,6 +178,13 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 			clicked = true
 			break
 		}
+		if click.Kind == gesture.KindClick && click.Button == pointer.ButtonPrimary && pl.OnClick != nil {
+			ts := cv.pxToTs(float32(click.Position.X))
+			s := pl.seriesAt(gtx, ts, click.Position.Y)
+			if a := pl.OnClick(ts, s); a != nil {
+				win.EmitAction(a)
+			}
+		}
 	}
 
 	if clicked {
@@ -200,6 +234,10 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 			},
 		}
 		for i := range pl.series {
+			if pl.Stacked {
+				// Hiding individual series of a stacked plot would leave gaps.
+				break
+			}
 			s := &pl.series[i]
 			var label string
 			if s.disabled {
@@ -217,6 +255,7 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 			}
 			items = append(items, item)
 		}
+		items = append(items, cv.plotMenu(win, pl)...)
 		win.SetContextMenu(items)
 		r.End()
 	}
@@ -281,7 +320,7 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 			r := rtrace.StartRegion(context.Background(), "legends")
 			// Print legends
 			rec := theme.Record(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
-				return theme.Label(win.Theme, local.Sprintf("%d %s", pl.max, pl.Unit)).Layout(win, gtx)
+				return theme.Label(win.Theme, local.Sprintf("%s: %d %s", pl.Name, pl.max, pl.Unit)).Layout(win, gtx)
 			})
 			theme.FillShape(win, gtx.Ops, oklch(100, 0, 0), clip.Rect{Max: rec.Dimensions.Size}.Op())
 			paint.ColorOp{Color: win.ConvertColor(oklch(0, 0, 0))}.Add(gtx.Ops)
@@ -306,20 +345,17 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 		ts := cv.pxToTs(pl.hover.Pointer().X + 1)
 
 		lines := pl.scratchStrings[:0]
-		for _, s := range pl.series {
+		for i := range pl.series {
+			s := &pl.series[i]
 			if s.disabled {
 				continue
 			}
-			idx := sort.Search(len(s.Points), func(idx int) bool {
-				pt := s.Points[idx]
-				return pt.When > ts
-			})
-			idx--
+			idx := s.indexAt(ts)
 			if idx < 0 {
 				continue
 			}
 
-			lines = append(lines, local.Sprintf("%s: %d %s", s.Name, s.Points[idx].Value, pl.Unit))
+			lines = append(lines, local.Sprintf("%s: %d %s", s.Name, pl.valueAt(i, idx), s.unit(pl)))
 		}
 		pl.scratchStrings = lines[:0]
 
@@ -334,6 +370,45 @@ func (pl *Plot) Layout(win *theme.Window, gtx layout.Context, cv *Canvas) layout
 	return layout.Dimensions{Size: gtx.Constraints.Max}
 }
 
+// indexAt returns the index of the most recent point at or before ts, or -1.
+func (s *PlotSeries) indexAt(ts trace.Timestamp) int {
+	idx := sort.Search(len(s.Points), func(idx int) bool {
+		return s.Points[idx].When > ts
+	})
+	return idx - 1
+}
+
+// valueAt returns the value of the idx-th point of the i-th series. For stacked plots, this is the series' own value,
+// not the cumulative one.
+func (pl *Plot) valueAt(i, idx int) uint64 {
+	v := pl.series[i].Points[idx].Value
+	if pl.Stacked && i+1 < len(pl.series) {
+		v -= pl.series[i+1].Points[idx].Value
+	}
+	return v
+}
+
+// seriesAt returns the topmost filled series that covers the pixel at y at time ts, or nil.
+func (pl *Plot) seriesAt(gtx layout.Context, ts trace.Timestamp, y int) *PlotSeries {
+	h := float64(gtx.Constraints.Max.Y)
+	v := scale(h, 0, float64(pl.min), float64(pl.max), float64(y))
+	// Later series are drawn on top of earlier ones.
+	for i := len(pl.series) - 1; i >= 0; i-- {
+		s := &pl.series[i]
+		if s.disabled || s.Unit != "" || s.Style&PlotFilled == 0 {
+			continue
+		}
+		idx := s.indexAt(ts)
+		if idx < 0 {
+			continue
+		}
+		if v <= float64(s.Points[idx].Value) {
+			return s
+		}
+	}
+	return nil
+}
+
 func (pl *Plot) drawPoints(win *theme.Window, gtx layout.Context, cv *Canvas, s PlotSeries) {
 	if len(s.Points) < 2 {
 		return
@@ -352,8 +427,12 @@ func (pl *Plot) drawPoints(win *theme.Window, gtx layout.Context, cv *Canvas, s
 		drawLine = pl.drawOrthogonalLine
 	}
 
+	minValue, maxValue := pl.min, pl.max
+	if s.Unit != "" {
+		minValue, maxValue = s.min, s.max
+	}
 	scaleValue := func(v uint64) float32 {
-		y := float32(scale(float64(pl.min), float64(pl.max), float64(gtx.Constraints.Max.Y), 0, float64(v)))
+		y := float32(scale(float64(minValue), float64(maxValue), float64(gtx.Constraints.Max.Y), 0, float64(v)))
 		if y < 0 {
 			y = 0
 		}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+
+	"gioui.org/op/clip"
+	"gioui.org/x/component"
+)
+
+// plotKind describes a kind of plot that can be displayed above the timelines.
+type plotKind struct {
+	// ID identifies the kind in the settings. It must not change.
+	ID   string
+	Name string
+	New  func(tr *Trace) *Plot
+}
+
+var plotKinds = []*plotKind{
+	{ID: "memory", Name: "Memory usage", New: newMemoryPlot},
+	{ID: "goroutines", Name: "Goroutines", New: func(tr *Trace) *Plot { return newGoroutineStatePlot(tr.Trace) }},
+	{ID: "gomaxprocs", Name: "GOMAXPROCS", New: newGOMAXPROCSPlot},
+	{ID: "running-procs", Name: "Running processors", New: newRunningProcessorsPlot},
+	{ID: "syscalls", Name: "Threads in syscalls", New: newSyscallsPlot},
+	{ID: "parallelism", Name: "Parallelism", New: newParallelismPlot},
+	{ID: "network", Name: "Concurrent network waits", New: func(tr *Trace) *Plot {
+		waits := ptrace.ComputeNetworkWaits(tr.Trace)
+		return newNetworkGraph(tr, &waits)
+	}},
+}
+
+// The prefix of the IDs of plots of user metrics, followed by the metric's key.
+const userMetricPlotPrefix = "metric:"
+
+func plotKindByID(id string) *plotKind {
+	for _, kind := range plotKinds {
+		if kind.ID == id {
+			return kind
+		}
+	}
+	if key, ok := strings.CutPrefix(id, userMetricPlotPrefix); ok {
+		return &plotKind{
+			ID:   id,
+			Name: key,
+			New:  func(tr *Trace) *Plot { return newUserMetricPlot(tr, key) },
+		}
+	}
+	return nil
+}
+
+// availablePlotKinds returns the built-in kinds of plots as well as plots for all user metrics in the trace.
+func availablePlotKinds(tr *Trace) []*plotKind {
+	kinds := plotKinds[:len(plotKinds):len(plotKinds)]
+	for _, m := range tr.userMetrics {
+		kinds = append(kinds, plotKindByID(userMetricPlotPrefix+m.Key))
+	}
+	return kinds
+}
+
+func newMemoryPlot(tr *Trace) *Plot {
+	pt := tr.Trace
+	pl := &Plot{
+		Name: "Memory usage",
+		Unit: "bytes",
+	}
+	pl.AddSeries(
+		PlotSeries{
+			Name:   "Heap size",
+			Points: pt.HeapSize,
+			Style:  PlotFilled,
+			Color:  oklch(70.59, 0.102, 139.64),
+		},
+		PlotSeries{
+			Name:   "Heap goal",
+			Points: pt.HeapGoal,
+			Style:  PlotStaircase,
+			Color:  colors[colorStateBlockedGC],
+		},
+		// Derived series, hidden by default and enabled via the context menu.
+		PlotSeries{
+			Name:     "GC trigger",
+			Points:   ptrace.GCTriggers(pt),
+			Style:    PlotStaircase,
+			Color:    colors[colorStateGC],
+			disabled: true,
+		},
+		PlotSeries{
+			Name:     "Allocation rate",
+			Points:   ptrace.AllocationRates(pt),
+			Style:    PlotStaircase,
+			Color:    oklch(colorsLightBase, colorsChromaBase, 262.88),
+			Unit:     "bytes/s",
+			disabled: true,
+		},
+		PlotSeries{
+			Name:     "Heap size / goal",
+			Points:   ptrace.HeapGoalRatios(pt),
+			Style:    PlotStaircase,
+			Color:    oklch(colorsLightBase, colorsChromaBase, 70.67),
+			Unit:     "%",
+			disabled: true,
+		},
+	)
+	return pl
+}
+
+func newGOMAXPROCSPlot(tr *Trace) *Plot {
+	pl := &Plot{
+		Name: "GOMAXPROCS",
+		Unit: "Ps",
+	}
+	pl.AddSeries(PlotSeries{
+		Name:   "GOMAXPROCS",
+		Points: tr.GOMAXPROCS,
+		Style:  PlotStaircase,
+		Color:  colors[colorStateActive],
+	})
+	return pl
+}
+
+func newRunningProcessorsPlot(tr *Trace) *Plot {
+	pl := &Plot{
+		Name: "Running processors",
+		Unit: "Ps",
+	}
+	pl.AddSeries(
+		PlotSeries{
+			Name:   "Running",
+			Points: tr.RunningProcessors,
+			Style:  PlotFilled,
+			Color:  colors[colorStateActive],
+		},
+		PlotSeries{
+			Name:   "GOMAXPROCS",
+			Points: tr.GOMAXPROCS,
+			Style:  PlotStaircase,
+			Color:  colors[colorStateBlocked],
+		},
+	)
+	return pl
+}
+
+// newUserMetricPlot returns a plot of the numeric values logged under key. The plot is empty if the trace doesn't
+// contain the metric.
+func newUserMetricPlot(tr *Trace, key string) *Plot {
+	pl := &Plot{
+		Name: key,
+	}
+	var points []ptrace.Point
+	for _, m := range tr.userMetrics {
+		if m.Key == key {
+			points = m.Points
+			break
+		}
+	}
+	pl.AddSeries(PlotSeries{
+		Name:   key,
+		Points: points,
+		Style:  PlotStaircase,
+		Color:  colors[colorLogEvent],
+	})
+	return pl
+}
+
+func newSyscallsPlot(tr *Trace) *Plot {
+	pl := &Plot{
+		Name: "Threads in syscalls",
+		Unit: "threads",
+	}
+	pl.AddSeries(PlotSeries{
+		Name:   "Blocking syscalls",
+		Points: ptrace.GoroutinesInSyscalls(tr.Trace),
+		Style:  PlotStaircase,
+		Color:  colors[colorStateBlockedSyscall],
+	})
+	return pl
+}
+
+// canvasPlot is a plot displayed above the timelines.
+type canvasPlot struct {
+	kind *plotKind
+	plot *theme.Future[*Plot]
+	// Separates the plot from the plots below it.
+	resize component.Resize
+}
+
+// newCanvasPlots creates the plots described by settings, skipping unknown kinds. The plots are computed in the
+// background.
+func newCanvasPlots(win *theme.Window, tr *Trace, settings []PlotSettings) []*canvasPlot {
+	var out []*canvasPlot
+	for i, s := range settings {
+		kind := plotKindByID(s.Kind)
+		if kind == nil {
+			continue
+		}
+		ratio := s.Ratio
+		if ratio <= 0 || ratio > 1 {
+			ratio = 1 / float32(len(settings)-i)
+		}
+		out = append(out, &canvasPlot{
+			kind: kind,
+			plot: theme.NewFuture(win, func(cancelled <-chan struct{}) *Plot {
+				return kind.New(tr)
+			}),
+			resize: component.Resize{
+				Axis:  layout.Vertical,
+				Ratio: ratio,
+			},
+		})
+	}
+	return out
+}
+
+// plotIndex returns the index of the plot of the specified kind, or -1.
+func (cv *Canvas) plotIndex(kind *plotKind) int {
+	for i, p := range cv.plots {
+		if p.kind.ID == kind.ID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (cv *Canvas) HasPlot(kind *plotKind) bool {
+	return cv.plotIndex(kind) != -1
+}
+
+// AddPlot adds a plot to the bottom of the list of plots. If pl is nil, the plot is computed from the kind in the
+// background. If a plot of the kind already exists, it gets replaced.
+func (cv *Canvas) AddPlot(win *theme.Window, kind *plotKind, pl *Plot) {
+	var ft *theme.Future[*Plot]
+	if pl == nil {
+		tr := cv.trace
+		ft = theme.NewFuture(win, func(cancelled <-chan struct{}) *Plot {
+			return kind.New(tr)
+		})
+	} else {
+		ft = theme.Immediate(pl)
+	}
+	if i := cv.plotIndex(kind); i != -1 {
+		cv.plots[i].plot = ft
+		return
+	}
+	cv.plots = append(cv.plots, &canvasPlot{
+		kind:   kind,
+		plot:   ft,
+		resize: component.Resize{Axis: layout.Vertical},
+	})
+	cv.equalizePlots()
+	cv.savePlots()
+}
+
+func (cv *Canvas) RemovePlot(kind *plotKind) {
+	i := cv.plotIndex(kind)
+	if i == -1 {
+		return
+	}
+	cv.plots = append(cv.plots[:i], cv.plots[i+1:]...)
+	cv.equalizePlots()
+	cv.savePlots()
+}
+
+// MovePlot moves a plot up (negative delta) or down (positive delta) in the list of plots.
+func (cv *Canvas) MovePlot(kind *plotKind, delta int) {
+	i := cv.plotIndex(kind)
+	j := i + delta
+	if i == -1 || j < 0 || j >= len(cv.plots) {
+		return
+	}
+	// The ratios belong to positions, not to plots, so that moving a plot doesn't change the layout.
+	cv.plots[i].resize.Ratio, cv.plots[j].resize.Ratio = cv.plots[j].resize.Ratio, cv.plots[i].resize.Ratio
+	cv.plots[i], cv.plots[j] = cv.plots[j], cv.plots[i]
+	cv.savePlots()
+}
+
+// equalizePlots resizes all plots to have the same height.
+func (cv *Canvas) equalizePlots() {
+	for i, p := range cv.plots {
+		p.resize.Ratio = 1 / float32(len(cv.plots)-i)
+	}
+}
+
+// savePlots stores the current set of plots in the user's settings.
+func (cv *Canvas) savePlots() {
+	if cv.settings == nil {
+		return
+	}
+	plots := make([]PlotSettings, 0, len(cv.plots))
+	for _, p := range cv.plots {
+		plots = append(plots, PlotSettings{Kind: p.kind.ID, Ratio: p.resize.Ratio})
+	}
+	cv.settings.Plots = plots
+	cv.settings.PlotsRatio = cv.resizeMemoryTimelines.Ratio
+	if err := cv.settings.Save(); err != nil {
+		log.Println("couldn't save settings:", err)
+	}
+}
+
+// plotMenu returns the context menu items for managing the plot.
+func (cv *Canvas) plotMenu(win *theme.Window, pl *Plot) []*theme.MenuItem {
+	i := -1
+	for j, p := range cv.plots {
+		if res, ok := p.plot.ResultNoWait(); ok && res == pl {
+			i = j
+			break
+		}
+	}
+	if i == -1 {
+		return nil
+	}
+	kind := cv.plots[i].kind
+
+	items := []*theme.MenuItem{
+		{
+			Label:    PlainLabel("Move plot up"),
+			Disabled: func() bool { return i == 0 },
+			Action: func() theme.Action {
+				return theme.ExecuteAction(func(gtx layout.Context) {
+					cv.MovePlot(kind, -1)
+				})
+			},
+		},
+		{
+			Label:    PlainLabel("Move plot down"),
+			Disabled: func() bool { return i == len(cv.plots)-1 },
+			Action: func() theme.Action {
+				return theme.ExecuteAction(func(gtx layout.Context) {
+					cv.MovePlot(kind, 1)
+				})
+			},
+		},
+		{
+			Label: PlainLabel("Remove plot"),
+			Action: func() theme.Action {
+				return theme.ExecuteAction(func(gtx layout.Context) {
+					cv.RemovePlot(kind)
+				})
+			},
+		},
+	}
+	for _, kind := range availablePlotKinds(cv.trace) {
+		kind := kind
+		if cv.HasPlot(kind) {
+			continue
+		}
+		items = append(items, &theme.MenuItem{
+			Label: PlainLabel("Add plot: " + kind.Name),
+			Action: func() theme.Action {
+				return theme.ExecuteAction(func(gtx layout.Context) {
+					cv.AddPlot(win, kind, nil)
+				})
+			},
+		})
+	}
+	return items
+}
+
+// layoutPlots lays out the plots from top to bottom, each separated from the plots below it by its own resize handle.
+func (cv *Canvas) layoutPlots(win *theme.Window, gtx layout.Context, plots []*canvasPlot) layout.Dimensions {
+	plotFn := func(p *canvasPlot) theme.Widget {
+		return func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+			cv.drag.drag.Add(gtx.Ops)
+
+			pl, ok := p.plot.ResultNoWait()
+			if !ok {
+				theme.Label(win.Theme, "Computing plot: "+p.kind.Name+"…").Layout(win, gtx)
+				return layout.Dimensions{Size: gtx.Constraints.Max}
+			}
+			return pl.Layout(win, gtx, cv)
+		}
+	}
+
+	if len(plots) == 1 {
+		return plotFn(plots[0])(win, gtx)
+	}
+	return theme.Resize(win.Theme, &plots[0].resize).Layout(win, gtx,
+		plotFn(plots[0]),
+		func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			return cv.layoutPlots(win, gtx, plots[1:])
+		},
+	)
+}
@@ -3,13 +3,20 @@ package main
 import (
 	"context"
 	"fmt"
+	"image"
 	rtrace "runtime/trace"
 	"time"
 
+	"github.com/joonho3020/gotraceui/clip"
+	"github.com/joonho3020/gotraceui/gesture"
 	"github.com/joonho3020/gotraceui/layout"
 	"github.com/joonho3020/gotraceui/theme"
 	"github.com/joonho3020/gotraceui/trace"
 	"github.com/joonho3020/gotraceui/trace/ptrace"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/text"
 )
 
 type ProcessorTooltip struct {
@@ -149,5 +156,388 @@ func NewProcessorTimeline(tr *Trace, cv *Canvas, p *ptrace.Processor) *Timeline
 	tl.tracks[0].spanTooltip = processorTrackSpanTooltip
 	tl.tracks[0].spanContextMenu = processorTrackSpanContextMenu
 
+	if len(p.Lifecycle) != 0 {
+		// A second track that shows when the processor was started and stopped, with markers for the events.
+		track := NewTrack(tl, TrackKindProcessorLifecycle)
+		track.Start = p.Lifecycle[0].Start
+		track.End = p.Lifecycle[len(p.Lifecycle)-1].End
+		track.spans = theme.Immediate[Items[ptrace.Span]](SimpleItems[ptrace.Span, any]{
+			items: p.Lifecycle,
+			container: ItemContainer{
+				Timeline: tl,
+				Track:    track,
+			},
+			contiguous: true,
+			subslice:   true,
+		})
+		track.events = p.Events
+		track.spanLabel = processorLifecycleSpanLabel
+		track.spanTooltip = processorLifecycleSpanTooltip
+		tl.tracks = append(tl.tracks, track)
+	}
+
 	return tl
 }
+
+func processorLifecycleSpanLabel(spans Items[ptrace.Span], tr *Trace, out []string) []string {
+	if spans.Len() != 1 {
+		return out
+	}
+	if spans.AtPtr(0).State == ptrace.StateRunningP {
+		return append(out, "started")
+	}
+	return append(out, "stopped")
+}
+
+func processorLifecycleSpanTooltip(win *theme.Window, gtx layout.Context, tr *Trace, spans Items[ptrace.Span]) layout.Dimensions {
+	var label string
+	if spans.Len() == 1 {
+		if spans.AtPtr(0).State == ptrace.StateRunningP {
+			label = "Processor started\n"
+		} else {
+			label = "Processor stopped\n"
+		}
+	} else {
+		label = local.Sprintf("%d spans\n", spans.Len())
+	}
+	label += spansDurationForTooltip(spans)
+	return theme.Tooltip(win.Theme, label).Layout(win, gtx)
+}
+
+func NewProcessorInfo(tr *Trace, mwin *theme.Window, canvas *Canvas, p *ptrace.Processor, allTimelines []*Timeline) *SpansInfo {
+	total := time.Duration(tr.End())
+	var busy, gc time.Duration
+	for i := range p.Spans {
+		s := &p.Spans[i]
+		busy += s.Duration()
+		if s.Tags&ptrace.SpanTagGC != 0 {
+			gc += s.Duration()
+		}
+	}
+
+	var starts, stops int
+	var stopped time.Duration
+	for i := range p.Lifecycle {
+		if s := &p.Lifecycle[i]; s.State == ptrace.StateInactive {
+			stopped += s.Duration()
+		}
+	}
+	// Busy and idle times are relative to the time the processor was running, not to the whole trace.
+	lifetime := total - stopped
+	for _, ev := range p.Events {
+		if tr.Event(ev).Type == trace.EvProcStart {
+			starts++
+		} else {
+			stops++
+		}
+	}
+
+	// Utilization over time, in 100 intervals.
+	bucket := total / 100
+	var buckets []int
+	var busiest, idlest int
+	if bucket > 0 {
+		buckets = ptrace.ComputeProcessorBusy(tr.Trace, p, bucket)
+		for i, v := range buckets {
+			if v < buckets[idlest] {
+				idlest = i
+			}
+			if v > buckets[busiest] {
+				busiest = i
+			}
+		}
+	}
+
+	stats := theme.NewFuture(mwin, func(cancelled <-chan struct{}) ptrace.ProcessorStatistics {
+		return ptrace.ComputeProcessorStatistics(tr.Trace, p, tr.Migrations().all)
+	})
+	goroutines := &processorGoroutineTable{}
+	sparkline := &utilizationSparkline{cv: canvas, buckets: buckets, bucket: bucket}
+
+	buildDescription := func(win *theme.Window, gtx layout.Context) Description {
+		var attrs []DescriptionAttribute
+		tb := TextBuilder{Window: win}
+
+		pct := func(d, of time.Duration) string {
+			if of == 0 {
+				return roundDuration(d).String()
+			}
+			return local.Sprintf("%s (%.2f%%)", roundDuration(d), float64(d)/float64(of)*100)
+		}
+
+		attrs = append(attrs, DescriptionAttribute{
+			Key:   "Processor",
+			Value: *tb.Span(local.Sprintf("%d", p.ID)),
+		})
+		attrs = append(attrs, DescriptionAttribute{
+			Key:   "Busy",
+			Value: *tb.Span(pct(busy, lifetime)),
+		})
+		attrs = append(attrs, DescriptionAttribute{
+			Key:   "Running GC workers",
+			Value: *tb.Span(pct(gc, lifetime)),
+		})
+		attrs = append(attrs, DescriptionAttribute{
+			Key:   "Idle",
+			Value: *tb.Span(pct(lifetime-busy, lifetime)),
+		})
+		attrs = append(attrs, DescriptionAttribute{
+			Key:   "Starts / stops",
+			Value: *tb.Span(local.Sprintf("%d / %d", starts, stops)),
+		})
+		attrs = append(attrs, DescriptionAttribute{
+			Key:   "Stopped",
+			Value: *tb.Span(pct(stopped, total)),
+		})
+
+		if stats, ok := stats.Result(); ok {
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Goroutines",
+				Value: *tb.Span(local.Sprintf("%d", len(stats.Goroutines))),
+			})
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Goroutine switches",
+				Value: *tb.Span(local.Sprintf("%d", stats.Switches)),
+			})
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Migrations in / out",
+				Value: *tb.Span(local.Sprintf("%d / %d", stats.MigrationsIn, stats.MigrationsOut)),
+			})
+		} else {
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Goroutines",
+				Value: *tb.Span("computing…"),
+			})
+		}
+
+		if len(buckets) != 0 {
+			interval := func(idx int) TextSpan {
+				ts := trace.Timestamp(time.Duration(idx) * bucket)
+				return *tb.DefaultLink(local.Sprintf("%d%% at %s", buckets[idx], formatTimestamp(nil, ts)), "Utilization interval", ts)
+			}
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Interval length",
+				Value: *tb.Span(roundDuration(bucket).String()),
+			})
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Busiest interval",
+				Value: interval(busiest),
+			})
+			attrs = append(attrs, DescriptionAttribute{
+				Key:   "Idlest interval",
+				Value: interval(idlest),
+			})
+		}
+
+		var desc Description
+		desc.Attributes = attrs
+		return desc
+	}
+
+	cfg := SpansInfoConfig{
+		Title:  local.Sprintf("processor %d", p.ID),
+		Object: p,
+		Navigations: SpansInfoConfigNavigations{
+			Scroll: struct {
+				ButtonLabel string
+				Fn          func() theme.Action
+			}{
+				ButtonLabel: "Scroll to processor",
+				Fn: func() theme.Action {
+					return &ScrollToObjectAction{Object: p}
+				},
+			},
+
+			Zoom: struct {
+				ButtonLabel string
+				Fn          func() theme.Action
+			}{
+				ButtonLabel: "Zoom to processor",
+				Fn: func() theme.Action {
+					return &ZoomToObjectAction{Object: p}
+				},
+			},
+		},
+		DescriptionBuilder: buildDescription,
+		Tabs: []SpansInfoTab{
+			{
+				Name: "Goroutines",
+				Layout: func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					stats, ok := stats.Result()
+					if !ok {
+						return theme.Label(win.Theme, "Computing statistics…").Layout(win, gtx)
+					}
+					return goroutines.Layout(win, gtx, stats.Goroutines)
+				},
+				HoveredLink: func() ObjectLink { return goroutines.cellFormatter.HoveredLink() },
+			},
+			{
+				Name:   "Utilization",
+				Layout: sparkline.Layout,
+			},
+		},
+	}
+
+	tl := canvas.itemToTimeline[p]
+	ss := SimpleItems[ptrace.Span, any]{
+		items: p.Spans,
+		container: ItemContainer{
+			Timeline: tl,
+			Track:    tl.tracks[0],
+		},
+		subslice: true,
+	}
+	return NewSpansInfo(cfg, tr, mwin, theme.Immediate[Items[ptrace.Span]](ss), allTimelines)
+}
+
+// processorGoroutineTable lists the goroutines that ran on a processor.
+type processorGoroutineTable struct {
+	goroutines SortedIndices[ptrace.ProcessorGoroutine, []ptrace.ProcessorGoroutine]
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func (pt *processorGoroutineTable) init(win *theme.Window, gtx layout.Context, gs []ptrace.ProcessorGoroutine) {
+	pt.goroutines.Reset(gs)
+	pt.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Goroutine", Alignment: text.End, Clickable: true},
+		{Name: "Function", Alignment: text.Start, Clickable: true},
+		{Name: "Runs", Alignment: text.End, Clickable: true},
+		{Name: "Time", Alignment: text.End, Clickable: true},
+	}
+	pt.table.SetColumns(win, gtx, cols)
+	// ComputeProcessorStatistics sorts goroutines by time.
+	pt.table.SortedBy = 3
+	pt.table.SortOrder = theme.SortDescending
+}
+
+func (pt *processorGoroutineTable) sort() {
+	desc := pt.table.SortOrder == theme.SortDescending
+	switch pt.table.Columns[pt.table.SortedBy].Name {
+	case "Goroutine":
+		pt.goroutines.Sort(func(a, b ptrace.ProcessorGoroutine) int {
+			return cmp(a.Goroutine.ID, b.Goroutine.ID, desc)
+		})
+	case "Function":
+		pt.goroutines.Sort(func(a, b ptrace.ProcessorGoroutine) int {
+			return cmp(a.Goroutine.Function.Fn, b.Goroutine.Function.Fn, desc)
+		})
+	case "Runs":
+		pt.goroutines.Sort(func(a, b ptrace.ProcessorGoroutine) int {
+			return cmp(a.Runs, b.Runs, desc)
+		})
+	case "Time":
+		pt.goroutines.Sort(func(a, b ptrace.ProcessorGoroutine) int {
+			return cmp(a.Time, b.Time, desc)
+		})
+	}
+}
+
+func (pt *processorGoroutineTable) Layout(win *theme.Window, gtx layout.Context, gs []ptrace.ProcessorGoroutine) layout.Dimensions {
+	if pt.table == nil {
+		pt.init(win, gtx, gs)
+	}
+	pt.table.Update(gtx)
+	if _, ok := pt.table.SortByClickedColumn(); ok {
+		pt.sort()
+	}
+	pt.cellFormatter.Update(win, gtx)
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		g := pt.goroutines.Ptr(row)
+		switch colName := pt.table.Columns[col].Name; colName {
+		case "Goroutine":
+			return pt.cellFormatter.Goroutine(win, gtx, g.Goroutine, "")
+		case "Function":
+			return pt.cellFormatter.Function(win, gtx, g.Goroutine.Function)
+		case "Runs":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return pt.cellFormatter.Number(win, gtx, g.Runs)
+			})
+		case "Time":
+			return pt.cellFormatter.Duration(win, gtx, g.Time, false)
+		default:
+			panic(colName)
+		}
+	}
+
+	gtx.Constraints.Min = gtx.Constraints.Max
+	return theme.SimpleTable(win, gtx, pt.table, &pt.scrollState, pt.goroutines.Len(), cellFn)
+}
+
+// utilizationSparkline displays a processor's utilization over time as a bar per interval. Clicking on a bar zooms
+// the canvas to the interval.
+type utilizationSparkline struct {
+	cv *Canvas
+	// Utilization in percent, as computed by ptrace.ComputeProcessorBusy.
+	buckets []int
+	bucket  time.Duration
+
+	click gesture.Click
+	hover gesture.Hover
+}
+
+func (sl *utilizationSparkline) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.utilizationSparkline.Layout").End()
+
+	size := image.Pt(gtx.Constraints.Max.X, min(gtx.Dp(100), gtx.Constraints.Max.Y))
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	if len(sl.buckets) == 0 || size.X == 0 {
+		return layout.Dimensions{Size: size}
+	}
+
+	barWidth := float32(size.X) / float32(len(sl.buckets))
+	bucketAt := func(x float32) int {
+		return max(0, min(len(sl.buckets)-1, int(x/barWidth)))
+	}
+	interval := func(idx int) (trace.Timestamp, trace.Timestamp) {
+		start := trace.Timestamp(time.Duration(idx) * sl.bucket)
+		return start, start + trace.Timestamp(sl.bucket)
+	}
+
+	sl.hover.Update(gtx.Queue)
+	for _, ev := range sl.click.Update(gtx.Queue) {
+		if ev.Kind == gesture.KindClick && ev.Button == pointer.ButtonPrimary {
+			start, end := interval(bucketAt(float32(ev.Position.X)))
+			win.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+				sl.cv.navigateToStartAndEnd(gtx, start, end, sl.cv.y)
+			}))
+		}
+	}
+	sl.click.Add(gtx.Ops)
+	sl.hover.Add(gtx.Ops)
+
+	theme.Fill(win, gtx.Ops, colors[colorStateInactive])
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	for i, v := range sl.buckets {
+		if v == 0 {
+			continue
+		}
+		x0 := float32(i) * barWidth
+		x1 := x0 + barWidth
+		y := float32(size.Y) - float32(size.Y)*float32(v)/100
+		p.MoveTo(f32.Pt(x0, y))
+		p.LineTo(f32.Pt(x1, y))
+		p.LineTo(f32.Pt(x1, float32(size.Y)))
+		p.LineTo(f32.Pt(x0, float32(size.Y)))
+		p.Close()
+	}
+	theme.FillShape(win, gtx.Ops, colors[colorStateActive], clip.Outline{Path: p.End()}.Op())
+
+	if sl.click.Hovered() {
+		idx := bucketAt(sl.hover.Pointer().X)
+		start, end := interval(idx)
+		label := local.Sprintf("%d%% busy\nFrom %s to %s", sl.buckets[idx], formatTimestamp(nil, start), formatTimestamp(nil, end))
+		win.SetTooltip(func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+			return theme.Tooltip(win.Theme, label).Layout(win, gtx)
+		})
+	}
+
+	return layout.Dimensions{Size: size}
+}
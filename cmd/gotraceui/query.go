@@ -0,0 +1,650 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+)
+
+// The filter query language describes which spans to highlight. A query consists of predicates of the form
+// field:value that can be combined with and, or, not, and parentheses. Predicates that follow each other without an
+// operator are ANDed together. Values containing spaces or any of the characters ()":<>=~ have to be quoted.
+//
+// The following fields are supported:
+//
+//	state:blocked-net     the span's scheduling state, see queryStates
+//	fn:"main.main"        the function of the goroutine the span belongs to
+//	fn:~"net/http"        the same, but using a regular expression
+//	region:"db query"     user regions with the given name, and spans that overlap such regions. Also supports :~
+//	tag:tls               the span's tags, see queryTags
+//	g:123                 the ID of the goroutine the span belongs to
+//	p:4                   the ID of the processor the span belongs to
+//	dur>5ms               the span's duration. Also supports >=, <, <=, and =
+//
+// Queries are evaluated per span. A merged span matches if any of the spans it consists of matches.
+
+var queryStates = map[string]uint64{
+	"inactive":              1 << ptrace.StateInactive,
+	"active":                1<<ptrace.StateActive | 1<<ptrace.StateRunningG,
+	"gc-idle":               1 << ptrace.StateGCIdle,
+	"gc-dedicated":          1 << ptrace.StateGCDedicated,
+	"gc-fractional":         1 << ptrace.StateGCFractional,
+	"gc-mark-assist":        1 << ptrace.StateGCMarkAssist,
+	"gc-sweep":              1 << ptrace.StateGCSweep,
+	"blocked-other":         1 << ptrace.StateBlocked,
+	"blocked-send":          1 << ptrace.StateBlockedSend,
+	"blocked-recv":          1 << ptrace.StateBlockedRecv,
+	"blocked-select":        1 << ptrace.StateBlockedSelect,
+	"blocked-sync":          1 << ptrace.StateBlockedSync,
+	"blocked-sync-once":     1 << ptrace.StateBlockedSyncOnce,
+	"blocked-triggering-gc": 1 << ptrace.StateBlockedSyncTriggeringGC,
+	"blocked-cond":          1 << ptrace.StateBlockedCond,
+	"blocked-net":           1 << ptrace.StateBlockedNet,
+	"blocked-gc":            1 << ptrace.StateBlockedGC,
+	"blocked-syscall":       1 << ptrace.StateBlockedSyscall,
+	"stuck":                 1 << ptrace.StateStuck,
+	"ready":                 1 << ptrace.StateReady,
+	"created":               1 << ptrace.StateCreated,
+	"user-region":           1 << ptrace.StateUserRegion,
+	"stack":                 1 << ptrace.StateStack,
+
+	"gc": 1<<ptrace.StateGCIdle | 1<<ptrace.StateGCDedicated | 1<<ptrace.StateGCFractional |
+		1<<ptrace.StateGCMarkAssist | 1<<ptrace.StateGCSweep,
+	"blocked": 1<<ptrace.StateBlocked | 1<<ptrace.StateBlockedSend | 1<<ptrace.StateBlockedRecv |
+		1<<ptrace.StateBlockedSelect | 1<<ptrace.StateBlockedSync | 1<<ptrace.StateBlockedSyncOnce |
+		1<<ptrace.StateBlockedSyncTriggeringGC | 1<<ptrace.StateBlockedCond | 1<<ptrace.StateBlockedNet |
+		1<<ptrace.StateBlockedGC | 1<<ptrace.StateBlockedSyscall,
+}
+
+var queryTags = map[string]ptrace.SpanTags{
+	"network": ptrace.SpanTagNetwork,
+	"tcp":     ptrace.SpanTagTCP,
+	"tls":     ptrace.SpanTagTLS,
+	"read":    ptrace.SpanTagRead,
+	"accept":  ptrace.SpanTagAccept,
+	"dial":    ptrace.SpanTagDial,
+	"http":    ptrace.SpanTagHTTP,
+	"gc":      ptrace.SpanTagGC,
+}
+
+// FilterQuery is a parsed filter query. It must not be modified after it has been assigned to a filter.
+type FilterQuery struct {
+	source string
+	root   queryNode
+}
+
+// String returns the query as written by the user.
+func (q *FilterQuery) String() string {
+	return q.source
+}
+
+// match reports whether any of the spans matches the query.
+func (q *FilterQuery) match(spans ptrace.Spans, container ItemContainer) bool {
+	if container.Track.kind == TrackKindProcessorLifecycle {
+		return false
+	}
+	qc := queryContext{tr: container.Timeline.cv.trace, container: container}
+	for i := 0; i < spans.Len(); i++ {
+		if q.root.matchSpan(&qc, spans.AtPtr(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// couldMatch checks if the query could possibly match spans in the container.
+func (q *FilterQuery) couldMatch(container ItemContainer) bool {
+	if container.Track.kind == TrackKindProcessorLifecycle {
+		return false
+	}
+	return q.root.couldMatch(container)
+}
+
+type QueryError struct {
+	// The column, starting at 1, at which the error occurred
+	Column int
+	Msg    string
+}
+
+func (err *QueryError) Error() string {
+	return fmt.Sprintf("column %d: %s", err.Column, err.Msg)
+}
+
+type queryContext struct {
+	tr        *Trace
+	container ItemContainer
+}
+
+// goroutine returns the goroutine that a span belongs to, or nil if it doesn't belong to a goroutine.
+func (qc *queryContext) goroutine(s *ptrace.Span) *ptrace.Goroutine {
+	switch item := qc.container.Timeline.item.(type) {
+	case *ptrace.Goroutine:
+		return item
+	case *ptrace.Processor:
+		// Spans of processors are owned by the goroutines running on them, with the exception of spans that belong
+		// to g0, which isn't a goroutine we track.
+		if gid := qc.tr.Event(s.Event).G; gid != 0 {
+			return qc.tr.G(gid)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+type queryNode interface {
+	matchSpan(qc *queryContext, s *ptrace.Span) bool
+	couldMatch(container ItemContainer) bool
+}
+
+type queryAnd struct{ lhs, rhs queryNode }
+type queryOr struct{ lhs, rhs queryNode }
+type queryNot struct{ x queryNode }
+type queryState struct{ states uint64 }
+type queryFunction struct{ m *queryStringMatcher }
+type queryRegion struct{ m *queryStringMatcher }
+type queryTag struct{ tags ptrace.SpanTags }
+type queryGoroutine struct{ id uint64 }
+type queryProcessor struct{ id int32 }
+type queryDuration struct {
+	op string
+	d  trace.Timestamp
+}
+
+func (n *queryAnd) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	return n.lhs.matchSpan(qc, s) && n.rhs.matchSpan(qc, s)
+}
+
+func (n *queryAnd) couldMatch(c ItemContainer) bool {
+	return n.lhs.couldMatch(c) && n.rhs.couldMatch(c)
+}
+
+func (n *queryOr) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	return n.lhs.matchSpan(qc, s) || n.rhs.matchSpan(qc, s)
+}
+
+func (n *queryOr) couldMatch(c ItemContainer) bool {
+	return n.lhs.couldMatch(c) || n.rhs.couldMatch(c)
+}
+
+func (n *queryNot) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	return !n.x.matchSpan(qc, s)
+}
+
+func (n *queryNot) couldMatch(c ItemContainer) bool {
+	// Negating a predicate that can't match anything matches everything.
+	return true
+}
+
+func (n *queryState) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	return n.states&(1<<s.State) != 0
+}
+
+func (n *queryState) couldMatch(c ItemContainer) bool {
+	return couldMatchStates(n.states, c)
+}
+
+func (n *queryFunction) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	g := qc.goroutine(s)
+	if g == nil || g.Function == nil {
+		return false
+	}
+	return n.m.match(g.Function.Fn)
+}
+
+func (n *queryFunction) couldMatch(c ItemContainer) bool {
+	return couldMatchGoroutine(c)
+}
+
+func (n *queryRegion) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	tr := qc.tr
+	regionName := func(s *ptrace.Span) string {
+		return tr.Strings[tr.Event(s.Event).Args[trace.ArgUserRegionTypeID]]
+	}
+
+	if s.State == ptrace.StateUserRegion {
+		return n.m.match(regionName(s))
+	}
+	g := qc.goroutine(s)
+	if g == nil {
+		return false
+	}
+	// Treat empty spans as having a duration of 1 ns so that they overlap the regions they're in.
+	end := max(s.End, s.Start+1)
+	for _, regions := range g.UserRegions {
+		// Regions of the same depth don't overlap and are sorted by their start.
+		i := sort.Search(len(regions), func(i int) bool {
+			return regions[i].End > s.Start
+		})
+		for ; i < len(regions) && regions[i].Start < end; i++ {
+			if n.m.match(regionName(&regions[i])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (n *queryRegion) couldMatch(c ItemContainer) bool {
+	return couldMatchGoroutine(c)
+}
+
+func (n *queryTag) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	return s.Tags&n.tags != 0
+}
+
+func (n *queryTag) couldMatch(c ItemContainer) bool {
+	switch c.Timeline.item.(type) {
+	case *ptrace.Goroutine:
+		return c.Track.kind == TrackKindUnspecified
+	case *ptrace.Processor:
+		return true
+	default:
+		return false
+	}
+}
+
+func (n *queryGoroutine) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	g := qc.goroutine(s)
+	return g != nil && g.ID == n.id
+}
+
+func (n *queryGoroutine) couldMatch(c ItemContainer) bool {
+	return couldMatchGoroutine(c)
+}
+
+func (n *queryProcessor) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	switch item := qc.container.Timeline.item.(type) {
+	case *ptrace.Processor:
+		return item.ID == n.id
+	case *ptrace.Machine:
+		// Trace.P panics for unknown processors, which can't match anyway.
+		pid := qc.tr.Event(s.Event).P
+		return pid == n.id && processorExists(qc.tr, pid)
+	default:
+		return false
+	}
+}
+
+// processorExists reports whether the trace has a processor with the ID.
+func processorExists(tr *Trace, pid int32) bool {
+	_, found := sort.Find(len(tr.Processors), func(i int) int {
+		return int(pid) - int(tr.Processors[i].ID)
+	})
+	return found
+}
+
+func (n *queryProcessor) couldMatch(c ItemContainer) bool {
+	switch c.Timeline.item.(type) {
+	case *ptrace.Processor, *ptrace.Machine:
+		return true
+	default:
+		return false
+	}
+}
+
+func (n *queryDuration) matchSpan(qc *queryContext, s *ptrace.Span) bool {
+	d := s.End - s.Start
+	switch n.op {
+	case "<":
+		return d < n.d
+	case "<=":
+		return d <= n.d
+	case ">":
+		return d > n.d
+	case ">=":
+		return d >= n.d
+	case "=", ":":
+		return d == n.d
+	default:
+		panic(fmt.Sprintf("unexpected operator %q", n.op))
+	}
+}
+
+func (n *queryDuration) couldMatch(c ItemContainer) bool {
+	return true
+}
+
+// couldMatchGoroutine reports whether spans in the container can belong to goroutines.
+func couldMatchGoroutine(c ItemContainer) bool {
+	switch c.Timeline.item.(type) {
+	case *ptrace.Goroutine, *ptrace.Processor:
+		return true
+	default:
+		return false
+	}
+}
+
+// queryStringMatcher matches strings either exactly or using a regular expression.
+type queryStringMatcher struct {
+	s  string
+	re *regexp.Regexp
+
+	// Results of matching the regular expression, mapping from string to bool. There are only as many distinct strings
+	// as there are functions and region names, but we match them for millions of spans.
+	cache sync.Map
+}
+
+func (m *queryStringMatcher) match(s string) bool {
+	if m.re == nil {
+		return s == m.s
+	}
+	if v, ok := m.cache.Load(s); ok {
+		return v.(bool)
+	}
+	b := m.re.MatchString(s)
+	m.cache.Store(s, b)
+	return b
+}
+
+type queryTokenKind uint8
+
+const (
+	queryTokenEOF queryTokenKind = iota
+	queryTokenWord
+	queryTokenString
+	queryTokenLParen
+	queryTokenRParen
+	queryTokenOperator
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	// Byte offset of the token in the query
+	pos int
+}
+
+func (tok queryToken) String() string {
+	switch tok.kind {
+	case queryTokenEOF:
+		return "end of query"
+	case queryTokenString:
+		return strconv.Quote(tok.text)
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
+
+type queryParser struct {
+	source string
+	tokens []queryToken
+	idx    int
+}
+
+// ParseFilterQuery parses a filter query. It returns nil if the query is empty.
+func ParseFilterQuery(s string) (*FilterQuery, error) {
+	p := &queryParser{source: s}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 1 {
+		return nil, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != queryTokenEOF {
+		return nil, p.errorf(tok.pos, "unexpected %s", tok)
+	}
+	return &FilterQuery{source: s, root: root}, nil
+}
+
+func (p *queryParser) errorf(pos int, format string, args ...any) error {
+	return &QueryError{
+		Column: utf8.RuneCountInString(p.source[:pos]) + 1,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func isQuerySpecial(r rune) bool {
+	return strings.ContainsRune(`()":<>=~`, r) || unicode.IsSpace(r)
+}
+
+func (p *queryParser) lex() error {
+	s := p.source
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			p.tokens = append(p.tokens, queryToken{queryTokenLParen, "(", i})
+			i++
+		case r == ')':
+			p.tokens = append(p.tokens, queryToken{queryTokenRParen, ")", i})
+			i++
+		case r == ':' || r == '<' || r == '>' || r == '=':
+			op := s[i : i+1]
+			if i+1 < len(s) && (r == ':' && s[i+1] == '~' || (r == '<' || r == '>') && s[i+1] == '=') {
+				op = s[i : i+2]
+			}
+			p.tokens = append(p.tokens, queryToken{queryTokenOperator, op, i})
+			i += len(op)
+		case r == '"':
+			// Backslashes only escape quotes and backslashes, so that regular expressions don't need to be escaped
+			// twice.
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i == len(s) {
+					return p.errorf(start, "unterminated string")
+				}
+				if s[i] == '"' {
+					i++
+					break
+				}
+				if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+					i++
+				}
+				sb.WriteByte(s[i])
+				i++
+			}
+			p.tokens = append(p.tokens, queryToken{queryTokenString, sb.String(), start})
+		case r == '~':
+			return p.errorf(i, "unexpected %q", r)
+		default:
+			start := i
+			for i < len(s) {
+				r, size := utf8.DecodeRuneInString(s[i:])
+				if isQuerySpecial(r) {
+					break
+				}
+				i += size
+			}
+			p.tokens = append(p.tokens, queryToken{queryTokenWord, s[start:i], start})
+		}
+	}
+	p.tokens = append(p.tokens, queryToken{queryTokenEOF, "", len(s)})
+	return nil
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.idx]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.tokens[p.idx]
+	if tok.kind != queryTokenEOF {
+		p.idx++
+	}
+	return tok
+}
+
+func (p *queryParser) peekKeyword(kw string) bool {
+	tok := p.peek()
+	return tok.kind == queryTokenWord && strings.EqualFold(tok.text, kw)
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	lhs, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.next()
+		rhs, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &queryOr{lhs, rhs}
+	}
+	return lhs, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.peekKeyword("and") {
+			p.next()
+		} else if tok := p.peek(); p.peekKeyword("or") || (tok.kind != queryTokenWord && tok.kind != queryTokenLParen) {
+			return lhs, nil
+		}
+		rhs, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &queryAnd{lhs, rhs}
+	}
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.peekKeyword("not") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNot{x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case queryTokenLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if end := p.next(); end.kind != queryTokenRParen {
+			return nil, p.errorf(end.pos, "expected \")\", found %s", end)
+		}
+		return x, nil
+	case queryTokenWord:
+		if strings.EqualFold(tok.text, "and") || strings.EqualFold(tok.text, "or") {
+			return nil, p.errorf(tok.pos, "expected predicate, found %s", tok)
+		}
+		return p.parsePredicate(tok)
+	default:
+		return nil, p.errorf(tok.pos, "expected predicate, found %s", tok)
+	}
+}
+
+func (p *queryParser) parsePredicate(field queryToken) (queryNode, error) {
+	op := p.next()
+	if op.kind != queryTokenOperator {
+		return nil, p.errorf(op.pos, "expected operator after %s, found %s", field, op)
+	}
+	value := p.next()
+	if value.kind != queryTokenWord && value.kind != queryTokenString {
+		return nil, p.errorf(value.pos, "expected value after %s, found %s", op, value)
+	}
+
+	checkOp := func(ops ...string) error {
+		for _, o := range ops {
+			if op.text == o {
+				return nil
+			}
+		}
+		return p.errorf(op.pos, "operator %s isn't supported by field %s", op, field)
+	}
+
+	switch name := strings.ToLower(field.text); name {
+	case "state":
+		if err := checkOp(":"); err != nil {
+			return nil, err
+		}
+		states, ok := queryStates[strings.ToLower(value.text)]
+		if !ok {
+			return nil, p.errorf(value.pos, "unknown state %s", value)
+		}
+		return &queryState{states}, nil
+
+	case "fn", "func", "function", "region":
+		if err := checkOp(":", ":~"); err != nil {
+			return nil, err
+		}
+		m := &queryStringMatcher{s: value.text}
+		if op.text == ":~" {
+			re, err := regexp.Compile(value.text)
+			if err != nil {
+				return nil, p.errorf(value.pos, "invalid regular expression: %s", err)
+			}
+			m.re = re
+		}
+		if name == "region" {
+			return &queryRegion{m}, nil
+		}
+		return &queryFunction{m}, nil
+
+	case "tag":
+		if err := checkOp(":"); err != nil {
+			return nil, err
+		}
+		tags, ok := queryTags[strings.ToLower(value.text)]
+		if !ok {
+			return nil, p.errorf(value.pos, "unknown tag %s", value)
+		}
+		return &queryTag{tags}, nil
+
+	case "g", "goroutine":
+		if err := checkOp(":"); err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseUint(value.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf(value.pos, "invalid goroutine ID %s", value)
+		}
+		return &queryGoroutine{id}, nil
+
+	case "p", "processor":
+		if err := checkOp(":"); err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseInt(value.text, 10, 32)
+		if err != nil {
+			return nil, p.errorf(value.pos, "invalid processor ID %s", value)
+		}
+		return &queryProcessor{int32(id)}, nil
+
+	case "dur", "duration":
+		if err := checkOp(":", "=", "<", "<=", ">", ">="); err != nil {
+			return nil, err
+		}
+		d, err := time.ParseDuration(value.text)
+		if err != nil {
+			return nil, p.errorf(value.pos, "invalid duration %s", value)
+		}
+		return &queryDuration{op.text, trace.Timestamp(d)}, nil
+
+	default:
+		return nil, p.errorf(field.pos, "unknown field %s", field)
+	}
+}
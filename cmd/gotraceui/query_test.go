@@ -0,0 +1,308 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+)
+
+// dumpQuery formats a query's syntax tree as an S-expression, so that tests can check how the query was parsed.
+func dumpQuery(n queryNode) string {
+	switch n := n.(type) {
+	case *queryAnd:
+		return fmt.Sprintf("(and %s %s)", dumpQuery(n.lhs), dumpQuery(n.rhs))
+	case *queryOr:
+		return fmt.Sprintf("(or %s %s)", dumpQuery(n.lhs), dumpQuery(n.rhs))
+	case *queryNot:
+		return fmt.Sprintf("(not %s)", dumpQuery(n.x))
+	case *queryGoroutine:
+		return fmt.Sprintf("g:%d", n.id)
+	case *queryProcessor:
+		return fmt.Sprintf("p:%d", n.id)
+	case *queryFunction:
+		if n.m.re != nil {
+			return fmt.Sprintf("fn:~%q", n.m.s)
+		}
+		return fmt.Sprintf("fn:%q", n.m.s)
+	case *queryRegion:
+		if n.m.re != nil {
+			return fmt.Sprintf("region:~%q", n.m.s)
+		}
+		return fmt.Sprintf("region:%q", n.m.s)
+	case *queryDuration:
+		return fmt.Sprintf("dur%s%s", n.op, time.Duration(n.d))
+	default:
+		return fmt.Sprintf("%T", n)
+	}
+}
+
+func TestParseFilterQueryPrecedence(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		want  string
+	}{
+		{"g:1", "g:1"},
+		{"g:1 or g:2 and g:3", "(or g:1 (and g:2 g:3))"},
+		{"g:1 and g:2 or g:3", "(or (and g:1 g:2) g:3)"},
+		{"g:1 or g:2 or g:3", "(or (or g:1 g:2) g:3)"},
+		{"g:1 and g:2 and g:3", "(and (and g:1 g:2) g:3)"},
+		{"not g:1 and g:2", "(and (not g:1) g:2)"},
+		{"not g:1 or g:2", "(or (not g:1) g:2)"},
+		{"not not g:1", "(not (not g:1))"},
+		{"not (g:1 or g:2)", "(not (or g:1 g:2))"},
+		{"g:1 and (g:2 or g:3)", "(and g:1 (or g:2 g:3))"},
+		// Juxtaposed predicates are ANDed together, binding tighter than or.
+		{"g:1 g:2", "(and g:1 g:2)"},
+		{"g:1 g:2 or g:3", "(or (and g:1 g:2) g:3)"},
+		{"g:1 or g:2 g:3", "(or g:1 (and g:2 g:3))"},
+		{"(g:1 or g:2) p:3", "(and (or g:1 g:2) p:3)"},
+		{"g:1 not p:2", "(and g:1 (not p:2))"},
+		// Keywords and field names are case-insensitive.
+		{"G:1 OR NOT P:2 AND g:3", "(or g:1 (and (not p:2) g:3))"},
+		{"((g:1))", "g:1"},
+	} {
+		q, err := ParseFilterQuery(tt.query)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.query, err)
+			continue
+		}
+		if got := dumpQuery(q.root); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseFilterQueryEmpty(t *testing.T) {
+	for _, query := range []string{"", "   ", "\t\n"} {
+		q, err := ParseFilterQuery(query)
+		if q != nil || err != nil {
+			t.Errorf("%q: got (%v, %v), want (nil, nil)", query, q, err)
+		}
+	}
+}
+
+func TestParseFilterQueryValues(t *testing.T) {
+	for _, tt := range []struct {
+		query    string
+		want     string
+		match    []string
+		mismatch []string
+	}{
+		{
+			query:    `fn:main.main`,
+			want:     `fn:"main.main"`,
+			match:    []string{"main.main"},
+			mismatch: []string{"main.main.func1", "main.mainX"},
+		},
+		{
+			query:    `fn:"main.main"`,
+			want:     `fn:"main.main"`,
+			match:    []string{"main.main"},
+			mismatch: []string{"main.main.func1"},
+		},
+		{
+			// Quoted values can contain spaces and special characters.
+			query:    `region:"db query (slow)"`,
+			want:     `region:"db query (slow)"`,
+			match:    []string{"db query (slow)"},
+			mismatch: []string{"db query"},
+		},
+		{
+			// Backslashes escape quotes and backslashes.
+			query: `fn:"a\"b\\c"`,
+			want:  `fn:"a\"b\\c"`,
+			match: []string{`a"b\c`},
+		},
+		{
+			query:    `fn:~"net/http"`,
+			want:     `fn:~"net/http"`,
+			match:    []string{"net/http.(*conn).serve", "net/http"},
+			mismatch: []string{"net/rpc.(*Server).ServeConn"},
+		},
+		{
+			// Other backslashes are kept, so regular expressions don't have to be escaped twice.
+			query:    `fn:~"^main\.f"`,
+			want:     `fn:~"^main\\.f"`,
+			match:    []string{"main.foo"},
+			mismatch: []string{"mainxfoo", "pkg.main.foo"},
+		},
+		{
+			query:    `region:~"^db "`,
+			want:     `region:~"^db "`,
+			match:    []string{"db query"},
+			mismatch: []string{"a db query"},
+		},
+		{
+			// Unquoted regular expressions end at the first special character.
+			query:    `fn:~^runtime\.gc`,
+			want:     `fn:~"^runtime\\.gc"`,
+			match:    []string{"runtime.gcBgMarkWorker"},
+			mismatch: []string{"runtime.bgsweep"},
+		},
+	} {
+		q, err := ParseFilterQuery(tt.query)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.query, err)
+			continue
+		}
+		if got := dumpQuery(q.root); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.query, got, tt.want)
+		}
+
+		var m *queryStringMatcher
+		switch n := q.root.(type) {
+		case *queryFunction:
+			m = n.m
+		case *queryRegion:
+			m = n.m
+		default:
+			t.Errorf("%q: got node of type %T, want string predicate", tt.query, n)
+			continue
+		}
+		// Match twice to exercise the cache of regular expression results.
+		for i := 0; i < 2; i++ {
+			for _, s := range tt.match {
+				if !m.match(s) {
+					t.Errorf("%q: expected %q to match", tt.query, s)
+				}
+			}
+			for _, s := range tt.mismatch {
+				if m.match(s) {
+					t.Errorf("%q: expected %q not to match", tt.query, s)
+				}
+			}
+		}
+	}
+}
+
+func TestQueryDuration(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		d     time.Duration
+		want  bool
+	}{
+		{"dur>5ms", 5 * time.Millisecond, false},
+		{"dur>5ms", 5*time.Millisecond + 1, true},
+		{"dur>=5ms", 5 * time.Millisecond, true},
+		{"dur>=5ms", 5*time.Millisecond - 1, false},
+		{"dur<1us", 999 * time.Nanosecond, true},
+		{"dur<1us", time.Microsecond, false},
+		{"dur<=1us", time.Microsecond, true},
+		{"dur<=1us", time.Microsecond + 1, false},
+		{"dur=2s", 2 * time.Second, true},
+		{"dur=2s", 2*time.Second + 1, false},
+		{"dur:1.5s", 1500 * time.Millisecond, true},
+		{"duration>1m", time.Hour, true},
+		{"dur>0s", 0, false},
+		{"not dur>1ms", time.Millisecond, true},
+		{"dur>1ms and dur<2ms", 1500 * time.Microsecond, true},
+		{"dur>1ms and dur<2ms", 2 * time.Millisecond, false},
+		{"dur<1ms or dur>2ms", 3 * time.Millisecond, true},
+		{"dur<1ms or dur>2ms", 1500 * time.Microsecond, false},
+	} {
+		q, err := ParseFilterQuery(tt.query)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.query, err)
+			continue
+		}
+		s := &ptrace.Span{Start: 1000, End: 1000 + trace.Timestamp(tt.d)}
+		if got := q.root.matchSpan(&queryContext{}, s); got != tt.want {
+			t.Errorf("%q: span of %s: got %t, want %t", tt.query, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestQueryProcessorOnMachine(t *testing.T) {
+	tr := &Trace{Trace: &ptrace.Trace{
+		Trace: trace.Trace{
+			Events: []trace.Event{
+				{P: 1},
+				{P: 2},
+				// A processor that isn't part of the trace.
+				{P: 7},
+			},
+		},
+		Processors: []*ptrace.Processor{{ID: 1}, {ID: 2}},
+	}}
+	qc := &queryContext{
+		tr:        tr,
+		container: ItemContainer{Timeline: &Timeline{item: &ptrace.Machine{ID: 1}}},
+	}
+	for _, tt := range []struct {
+		query string
+		ev    ptrace.EventID
+		want  bool
+	}{
+		{"p:1", 0, true},
+		{"p:1", 1, false},
+		{"p:2", 1, true},
+		{"p:2", 2, false},
+		{"p:7", 2, false},
+		{"not p:7", 2, true},
+	} {
+		q, err := ParseFilterQuery(tt.query)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.query, err)
+			continue
+		}
+		s := &ptrace.Span{Event: tt.ev}
+		if got := q.root.matchSpan(qc, s); got != tt.want {
+			t.Errorf("%q: span of event %d: got %t, want %t", tt.query, tt.ev, got, tt.want)
+		}
+	}
+}
+
+func TestParseFilterQueryErrors(t *testing.T) {
+	for _, tt := range []struct {
+		query  string
+		column int
+		msg    string
+	}{
+		{"foo:bar", 1, `unknown field "foo"`},
+		{"g:", 3, `expected value after ":", found end of query`},
+		{"g 1", 3, `expected operator after "g", found "1"`},
+		{"g:x", 3, `invalid goroutine ID "x"`},
+		{"p:-", 3, `invalid processor ID "-"`},
+		{"state:running", 7, `unknown state "running"`},
+		{"tag:udp", 5, `unknown tag "udp"`},
+		{"state>ready", 6, `operator ">" isn't supported by field "state"`},
+		{"g:~1", 2, `operator ":~" isn't supported by field "g"`},
+		{"dur>5 parsecs", 5, `invalid duration "5"`},
+		{"dur>5x", 5, `invalid duration "5x"`},
+		{"g:1 and", 8, `expected predicate, found end of query`},
+		{"g:1 or or g:2", 8, `expected predicate, found "or"`},
+		{"and g:1", 1, `expected predicate, found "and"`},
+		{"not", 4, `expected predicate, found end of query`},
+		{"(g:1", 5, `expected ")", found end of query`},
+		{"(g:1 g:2 p", 11, `expected operator after "p", found end of query`},
+		{"g:1 )", 5, `unexpected ")"`},
+		{"~g:1", 1, `unexpected '~'`},
+		{`fn:"abc`, 4, `unterminated string`},
+		{`fn:~"("`, 5, "invalid regular expression"},
+		// Columns count runes, not bytes.
+		{`fn:"ä" bogus:1`, 8, `unknown field "bogus"`},
+		{`region:"日本語" g:`, 16, `expected value after ":", found end of query`},
+	} {
+		q, err := ParseFilterQuery(tt.query)
+		if err == nil {
+			t.Errorf("%q: expected error, got query %s", tt.query, dumpQuery(q.root))
+			continue
+		}
+		var qerr *QueryError
+		if !errors.As(err, &qerr) {
+			t.Errorf("%q: got error of type %T, want *QueryError", tt.query, err)
+			continue
+		}
+		if qerr.Column != tt.column {
+			t.Errorf("%q: got column %d, want %d (%s)", tt.query, qerr.Column, tt.column, qerr.Msg)
+		}
+		if !strings.HasPrefix(qerr.Msg, tt.msg) {
+			t.Errorf("%q: got message %q, want prefix %q", tt.query, qerr.Msg, tt.msg)
+		}
+	}
+}
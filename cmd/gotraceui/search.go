@@ -0,0 +1,587 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/font"
+)
+
+type searchKind uint8
+
+const (
+	searchKindGoroutine searchKind = iota
+	searchKindFunction
+	searchKindStackFrame
+	searchKindRegion
+	searchKindTask
+	searchKindLog
+
+	searchKindLast
+)
+
+var searchKindNames = [searchKindLast]string{
+	searchKindGoroutine:  "Goroutines",
+	searchKindFunction:   "Functions",
+	searchKindStackFrame: "Stack frames",
+	searchKindRegion:     "Regions",
+	searchKindTask:       "Tasks",
+	searchKindLog:        "Logs",
+}
+
+// searchEntry is a searchable object in the trace.
+type searchEntry struct {
+	kind  searchKind
+	label string
+	// Lowercase strings that the query gets matched against.
+	keys []string
+	// *ptrace.Goroutine, *ptrace.Function, or *ptrace.Task for goroutines, functions, and tasks. The function, region,
+	// or log message for stack frames, regions, and logs.
+	obj any
+}
+
+type searchIndex struct {
+	entries [searchKindLast][]searchEntry
+}
+
+func userLogLabel(tr *Trace, ev *trace.Event) string {
+	cat := tr.Strings[ev.Args[trace.ArgUserLogKeyID]]
+	msg := tr.Strings[ev.Args[trace.ArgUserLogMessage]]
+	if cat == "" {
+		return msg
+	}
+	return cat + ": " + msg
+}
+
+func userRegionName(tr *Trace, s *ptrace.Span) string {
+	return tr.Strings[tr.Event(s.Event).Args[trace.ArgUserRegionTypeID]]
+}
+
+// buildSearchIndex collects all searchable objects in the trace.
+func buildSearchIndex(tr *Trace, cancelled <-chan struct{}) (*searchIndex, bool) {
+	defer rtrace.StartRegion(context.Background(), "main.buildSearchIndex").End()
+
+	idx := &searchIndex{}
+	add := func(kind searchKind, label string, obj any, keys ...string) {
+		for i, k := range keys {
+			keys[i] = strings.ToLower(k)
+		}
+		idx.entries[kind] = append(idx.entries[kind], searchEntry{kind: kind, label: label, keys: keys, obj: obj})
+	}
+
+	regions := map[string]struct{}{}
+	for i, g := range tr.Goroutines {
+		if i%1000 == 0 && TryRecv(cancelled) {
+			return nil, false
+		}
+		id := strconv.FormatUint(g.ID, 10)
+		if g.Function != nil && g.Function.Fn != "" {
+			add(searchKindGoroutine, local.Sprintf("goroutine %d: %s", g.ID, g.Function.Fn), g, id, g.Function.Fn)
+		} else {
+			add(searchKindGoroutine, local.Sprintf("goroutine %d", g.ID), g, id)
+		}
+		for _, level := range g.UserRegions {
+			for j := range level {
+				regions[userRegionName(tr, &level[j])] = struct{}{}
+			}
+		}
+	}
+	for name := range regions {
+		add(searchKindRegion, name, name, name)
+	}
+
+	for _, fn := range tr.Functions {
+		add(searchKindFunction, fn.Fn, fn, fn.Fn)
+	}
+
+	frames := map[string]struct{}{}
+	for _, frame := range tr.PCs {
+		if frame.Fn != "" {
+			frames[frame.Fn] = struct{}{}
+		}
+	}
+	for fn := range frames {
+		add(searchKindStackFrame, fn, fn, fn)
+	}
+
+	for _, task := range tr.Tasks {
+		id := strconv.FormatUint(task.ID, 10)
+		if task.Stub() {
+			add(searchKindTask, local.Sprintf("task %d", task.ID), task, id)
+		} else {
+			add(searchKindTask, local.Sprintf("task %d: %s", task.ID, task.Name), task, id, task.Name)
+		}
+	}
+
+	logs := map[string]struct{}{}
+	for i := range tr.Events {
+		if i%100000 == 0 && TryRecv(cancelled) {
+			return nil, false
+		}
+		ev := &tr.Events[i]
+		if ev.Type == trace.EvUserLog {
+			logs[userLogLabel(tr, ev)] = struct{}{}
+		}
+	}
+	for label := range logs {
+		add(searchKindLog, label, label, label)
+	}
+
+	return idx, true
+}
+
+// searchScore rates how well the lowercase query q matches the key. Lower scores are better, -1 means that the key
+// doesn't match.
+func searchScore(key, q string) int {
+	i := strings.Index(key, q)
+	switch {
+	case i == -1:
+		return -1
+	case len(key) == len(q):
+		return 0
+	case i == 0:
+		return 1
+	case strings.ContainsRune("./ :_-()*", rune(key[i-1])):
+		// The match starts at a word boundary, for example the function name of a fully qualified function.
+		return 2
+	default:
+		return 3
+	}
+}
+
+type searchResult struct {
+	entry *searchEntry
+	score int
+}
+
+type searchResults struct {
+	query  string
+	byKind [searchKindLast][]searchResult
+}
+
+func (idx *searchIndex) search(query string, cancelled <-chan struct{}) (searchResults, bool) {
+	defer rtrace.StartRegion(context.Background(), "main.searchIndex.search").End()
+
+	res := searchResults{query: query}
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return res, true
+	}
+	for kind := range idx.entries {
+		var out []searchResult
+		for i := range idx.entries[kind] {
+			if i%10000 == 0 && TryRecv(cancelled) {
+				return searchResults{}, false
+			}
+			e := &idx.entries[kind][i]
+			best := -1
+			for _, key := range e.keys {
+				if score := searchScore(key, q); score != -1 && (best == -1 || score < best) {
+					best = score
+				}
+			}
+			if best != -1 {
+				out = append(out, searchResult{e, best})
+			}
+		}
+		sort.Slice(out, func(i, j int) bool {
+			a, b := out[i], out[j]
+			if a.score != b.score {
+				return a.score < b.score
+			}
+			if len(a.entry.label) != len(b.entry.label) {
+				return len(a.entry.label) < len(b.entry.label)
+			}
+			return a.entry.label < b.entry.label
+		})
+		res.byKind[kind] = out
+	}
+	return res, true
+}
+
+// searchOccurrence is a location on the canvas that matches a search result.
+type searchOccurrence struct {
+	// The item of the timeline the occurrence is on
+	item  any
+	start trace.Timestamp
+	end   trace.Timestamp
+}
+
+// searchOccurrences finds the spans and events matching a search result, sorted by time.
+func searchOccurrences(tr *Trace, e *searchEntry, cancelled <-chan struct{}) ([]searchOccurrence, bool) {
+	defer rtrace.StartRegion(context.Background(), "main.searchOccurrences").End()
+
+	var out []searchOccurrence
+	goroutine := func(g *ptrace.Goroutine) {
+		out = append(out, searchOccurrence{g, g.EffectiveStart(), g.EffectiveEnd()})
+	}
+	// regions adds all user regions for which fn returns true.
+	regions := func(fn func(s *ptrace.Span) bool) bool {
+		for i, g := range tr.Goroutines {
+			if i%1000 == 0 && TryRecv(cancelled) {
+				return false
+			}
+			for _, level := range g.UserRegions {
+				for j := range level {
+					s := &level[j]
+					if fn(s) {
+						end := s.End
+						if end < s.Start {
+							// The region didn't end before the end of the trace.
+							end = tr.End()
+						}
+						out = append(out, searchOccurrence{g, s.Start, end})
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	switch e.kind {
+	case searchKindGoroutine:
+		goroutine(e.obj.(*ptrace.Goroutine))
+
+	case searchKindFunction:
+		for _, g := range e.obj.(*ptrace.Function).Goroutines {
+			goroutine(g)
+		}
+
+	case searchKindStackFrame:
+		fn := e.obj.(string)
+		// Whether a stack contains the function, keyed by stack ID.
+		stacks := map[uint32]bool{}
+		for i, g := range tr.Goroutines {
+			if i%100 == 0 && TryRecv(cancelled) {
+				return nil, false
+			}
+			for j := range g.Spans {
+				s := &g.Spans[j]
+				stkID := tr.Event(s.Event).StkID
+				if stkID == 0 {
+					continue
+				}
+				found, ok := stacks[stkID]
+				if !ok {
+					for _, pc := range tr.Stacks[stkID] {
+						if tr.PCs[pc].Fn == fn {
+							found = true
+							break
+						}
+					}
+					stacks[stkID] = found
+				}
+				if found {
+					out = append(out, searchOccurrence{g, s.Start, s.End})
+				}
+			}
+		}
+
+	case searchKindRegion:
+		name := e.obj.(string)
+		if !regions(func(s *ptrace.Span) bool { return userRegionName(tr, s) == name }) {
+			return nil, false
+		}
+
+	case searchKindTask:
+		task := e.obj.(*ptrace.Task)
+		if !task.Stub() {
+			ev := tr.Event(task.Event)
+			out = append(out, searchOccurrence{tr.G(ev.G), ev.Ts, ev.Ts})
+		}
+		if !regions(func(s *ptrace.Span) bool {
+			return tr.Event(s.Event).Args[trace.ArgUserRegionTaskID] == task.ID
+		}) {
+			return nil, false
+		}
+
+	case searchKindLog:
+		label := e.obj.(string)
+		for i := range tr.Events {
+			if i%100000 == 0 && TryRecv(cancelled) {
+				return nil, false
+			}
+			ev := &tr.Events[i]
+			if ev.Type == trace.EvUserLog && userLogLabel(tr, ev) == label {
+				out = append(out, searchOccurrence{tr.G(ev.G), ev.Ts, ev.Ts})
+			}
+		}
+
+	default:
+		panic(fmt.Sprintf("unexpected search kind %d", e.kind))
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].start < out[j].start })
+	return out, true
+}
+
+// showSearchOccurrence scrolls to the timeline of the occurrence and centers it, zooming out if it doesn't fit into
+// the current view.
+func (cv *Canvas) showSearchOccurrence(gtx layout.Context, occ searchOccurrence) {
+	cv.ensureObjectDisplayed(gtx, occ.item)
+	y := cv.objectY(gtx, occ.item)
+	d := cv.End() - cv.start
+	if l := occ.end - occ.start; l > d*9/10 {
+		cv.navigateToStartAndEnd(gtx, occ.start-l/10, occ.end+l/10, y)
+	} else {
+		cv.navigateTo(gtx, occ.start+l/2-d/2, cv.nsPerPx, y)
+	}
+}
+
+// The maximum number of results to display per kind.
+const maxSearchResultsPerKind = 50
+
+// searchRow is a row in the list of results. It is either the header of a kind, a result, or a note about omitted
+// results.
+type searchRow struct {
+	kind    searchKind
+	result  *searchResult
+	omitted int
+}
+
+// SearchComponent searches goroutines, functions, stack frames, user regions, tasks and logs, and steps through the
+// occurrences of the selected result on the canvas.
+type SearchComponent struct {
+	trace  *Trace
+	canvas *Canvas
+
+	editor      widget.Editor
+	focusEditor bool
+
+	index   *theme.Future[*searchIndex]
+	compute *theme.Future[searchResults]
+	results searchResults
+	rows    []searchRow
+	clicks  []widget.Clickable
+
+	selected    *searchEntry
+	occurrences *theme.Future[[]searchOccurrence]
+	// The index of the occurrence displayed on the canvas, or -1 if none has been displayed yet.
+	current int
+
+	prev widget.PrimaryClickable
+	next widget.PrimaryClickable
+	list widget.List
+}
+
+func NewSearchComponent(tr *Trace, cv *Canvas) *SearchComponent {
+	sc := &SearchComponent{
+		trace:       tr,
+		canvas:      cv,
+		focusEditor: true,
+	}
+	sc.editor.SingleLine = true
+	sc.list.Axis = layout.Vertical
+	return sc
+}
+
+// Title implements theme.Component.
+func (*SearchComponent) Title() string {
+	return "Search"
+}
+
+// Transition implements theme.Component.
+func (*SearchComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*SearchComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+// Focus focuses the search field during the next call to Layout.
+func (sc *SearchComponent) Focus() {
+	sc.focusEditor = true
+}
+
+func (sc *SearchComponent) setResults(res searchResults) {
+	sc.results = res
+	sc.rows = sc.rows[:0]
+	for kind, results := range res.byKind {
+		if len(results) == 0 {
+			continue
+		}
+		sc.rows = append(sc.rows, searchRow{kind: searchKind(kind)})
+		for i := range results[:min(len(results), maxSearchResultsPerKind)] {
+			sc.rows = append(sc.rows, searchRow{kind: searchKind(kind), result: &results[i]})
+		}
+		if n := len(results) - maxSearchResultsPerKind; n > 0 {
+			sc.rows = append(sc.rows, searchRow{kind: searchKind(kind), omitted: n})
+		}
+	}
+	if cap(sc.clicks) >= len(sc.rows) {
+		sc.clicks = sc.clicks[:len(sc.rows)]
+		clear(sc.clicks)
+	} else {
+		sc.clicks = make([]widget.Clickable, len(sc.rows))
+	}
+}
+
+func (sc *SearchComponent) selectEntry(win *theme.Window, e *searchEntry) {
+	sc.selected = e
+	sc.current = -1
+	tr := sc.trace
+	sc.occurrences = theme.NewFuture(win, func(cancelled <-chan struct{}) []searchOccurrence {
+		occs, _ := searchOccurrences(tr, e, cancelled)
+		return occs
+	})
+}
+
+// Step displays the next occurrence of the selected result if delta is 1, or the previous one if delta is -1. It
+// reports whether there was an occurrence to display.
+func (sc *SearchComponent) Step(gtx layout.Context, delta int) bool {
+	if sc.occurrences == nil {
+		return false
+	}
+	occs, ok := sc.occurrences.ResultNoWait()
+	if !ok || len(occs) == 0 {
+		return false
+	}
+	if sc.current == -1 && delta < 0 {
+		sc.current = len(occs) - 1
+	} else {
+		sc.current = ((sc.current+delta)%len(occs) + len(occs)) % len(occs)
+	}
+	sc.canvas.showSearchOccurrence(gtx, occs[sc.current])
+	return true
+}
+
+// Layout implements theme.Component.
+func (sc *SearchComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.SearchComponent.Layout").End()
+
+	if sc.index == nil {
+		tr := sc.trace
+		sc.index = theme.NewFuture(win, func(cancelled <-chan struct{}) *searchIndex {
+			idx, _ := buildSearchIndex(tr, cancelled)
+			return idx
+		})
+	}
+	if sc.focusEditor {
+		sc.editor.Focus()
+		sc.focusEditor = false
+	}
+
+	// Keep reading the futures so that they don't get cancelled.
+	idx, idxOk := sc.index.ResultNoWait()
+	if idxOk && idx == nil {
+		// Building the index was cancelled, try again.
+		sc.index = nil
+		idxOk = false
+	}
+	if idxOk && sc.editor.Text() != sc.results.query && sc.compute == nil {
+		q := sc.editor.Text()
+		sc.compute = theme.NewFuture(win, func(cancelled <-chan struct{}) searchResults {
+			res, _ := idx.search(q, cancelled)
+			return res
+		})
+	}
+	if sc.compute != nil {
+		if res, ok := sc.compute.ResultNoWait(); ok {
+			sc.compute = nil
+			sc.setResults(res)
+		}
+	}
+	var occs []searchOccurrence
+	occsOk := false
+	if sc.occurrences != nil {
+		occs, occsOk = sc.occurrences.ResultNoWait()
+		if occsOk && sc.current == -1 && len(occs) != 0 {
+			// Jump to the first occurrence as soon as we know about it.
+			sc.Step(gtx, 1)
+		}
+	}
+
+	for i := range sc.clicks {
+		for {
+			if _, ok := sc.clicks[i].Clicked(gtx); !ok {
+				break
+			}
+			if r := sc.rows[i].result; r != nil {
+				sc.selectEntry(win, r.entry)
+			}
+		}
+	}
+	for sc.prev.Clicked(gtx) {
+		sc.Step(gtx, -1)
+	}
+	for sc.next.Clicked(gtx) {
+		sc.Step(gtx, 1)
+	}
+
+	status := func(gtx layout.Context) layout.Dimensions {
+		var s string
+		switch {
+		case !idxOk:
+			s = "Building search index…"
+		case sc.selected == nil:
+			s = "Select a result to step through its occurrences on the canvas."
+		case !occsOk:
+			s = local.Sprintf("Finding occurrences of %s…", sc.selected.label)
+		case len(occs) == 0:
+			s = local.Sprintf("%s has no occurrences on the canvas.", sc.selected.label)
+		case sc.current == -1:
+			s = local.Sprintf("%s: %d occurrences. N: next, Shift+N: previous", sc.selected.label, len(occs))
+		default:
+			s = local.Sprintf("%s: occurrence %d of %d. N: next, Shift+N: previous", sc.selected.label, sc.current+1, len(occs))
+		}
+		return theme.Label(win.Theme, s).Layout(win, gtx)
+	}
+
+	rowFn := func(gtx layout.Context, i int) layout.Dimensions {
+		row := &sc.rows[i]
+		switch {
+		case row.result == nil && row.omitted == 0:
+			l := theme.LineLabel(win.Theme, local.Sprintf("%s (%d)", searchKindNames[row.kind], len(sc.results.byKind[row.kind])))
+			l.Font = font.Font{Weight: font.Bold}
+			return layout.Inset{Top: 5}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return l.Layout(win, gtx)
+			})
+		case row.result == nil:
+			return theme.LineLabel(win.Theme, local.Sprintf("  and %d more", row.omitted)).Layout(win, gtx)
+		default:
+			return sc.clicks[i].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				label := func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					return theme.LineLabel(win.Theme, "  "+row.result.entry.label).Layout(win, gtx)
+				}
+				if row.result.entry == sc.selected {
+					return theme.Background{Color: win.Theme.Palette.PrimarySelection}.Layout(win, gtx, label)
+				}
+				return label(win, gtx)
+			})
+		}
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return theme.TextBox(win.Theme, &sc.editor, "Search goroutines, functions, stack frames, regions, tasks and logs").Layout(win, gtx)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				theme.Dumb(win, theme.Button(win.Theme, &sc.prev.Clickable, "Previous").Layout),
+				layout.Spacer{Width: 5}.Layout,
+				theme.Dumb(win, theme.Button(win.Theme, &sc.next.Clickable, "Next").Layout),
+				layout.Spacer{Width: 10}.Layout,
+				status,
+			)
+		},
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			if sc.editor.Text() != "" && len(sc.rows) == 0 && sc.compute == nil && idxOk {
+				return theme.Label(win.Theme, "No results.").Layout(win, gtx)
+			}
+			return theme.List(win.Theme, &sc.list).Layout(win, gtx, len(sc.rows), rowFn)
+		},
+	)
+}
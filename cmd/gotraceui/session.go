@@ -0,0 +1,685 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/joonho3020/gotraceui/container"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+
+	"gioui.org/x/explorer"
+)
+
+// Session captures the state of an investigation of a trace, so that it can be resumed after restarting gotraceui.
+// Objects are identified by stable IDs, such as goroutine IDs, not by pointers.
+type Session struct {
+	// Trace identifies the trace the session belongs to. It is used for rejecting sessions of other traces.
+	Trace SessionTrace `json:"trace"`
+
+	Location        SessionLocation   `json:"location"`
+	LocationHistory []SessionLocation `json:"location_history"`
+	// The index of the current location in LocationHistory.
+	LocationHistoryCursor int             `json:"location_history_cursor"`
+	AxisAnchor            AxisAnchor      `json:"axis_anchor"`
+	SelectedRange         *TimeSpan       `json:"selected_range,omitempty"`
+	Display               SessionDisplay  `json:"display"`
+	Filter                SessionFilter   `json:"filter"`
+	Timelines             SessionTimeline `json:"timelines"`
+
+	// Closable tabs, in order. The fixed tabs aren't included.
+	Tabs []SessionView `json:"tabs"`
+	// The index of the selected tab, counting the fixed tabs.
+	CurrentTab int `json:"current_tab"`
+	// The panel history, followed by the current panel.
+	Panels []SessionView `json:"panels"`
+}
+
+// SessionTrace identifies the trace a session belongs to.
+type SessionTrace struct {
+	End        trace.Timestamp `json:"end"`
+	Goroutines int             `json:"goroutines"`
+	Events     int             `json:"events"`
+}
+
+type SessionLocation struct {
+	Start   trace.Timestamp `json:"start"`
+	NsPerPx float64         `json:"ns_per_px"`
+	Y       normalizedY     `json:"y"`
+}
+
+type SessionDisplay struct {
+	Compact          bool           `json:"compact"`
+	StackTracks      bool           `json:"stack_tracks"`
+	Flows            bool           `json:"flows,omitempty"`
+	AllLabels        bool           `json:"all_labels"`
+	Tooltips         showTooltips   `json:"tooltips"`
+	GCOverlays       showGCOverlays `json:"gc_overlays"`
+	HideNonMatching  bool           `json:"hide_non_matching"`
+	Grouping         string         `json:"grouping"`
+	ExpandedGroups   []string       `json:"expanded_groups,omitempty"`
+	GoroutinePath    uint64         `json:"goroutine_path,omitempty"`
+	HasGoroutinePath bool           `json:"has_goroutine_path,omitempty"`
+}
+
+type SessionFilter struct {
+	Mode   FilterMode `json:"mode"`
+	States uint64     `json:"states"`
+
+	ProcessorGoroutine  uint64          `json:"processor_goroutine,omitempty"`
+	ProcessorStartAfter trace.Timestamp `json:"processor_start_after,omitempty"`
+	ProcessorEndBefore  trace.Timestamp `json:"processor_end_before,omitempty"`
+	MachineProcessor    int32           `json:"machine_processor,omitempty"`
+
+	Spans []ptrace.EventID `json:"spans,omitempty"`
+	Query string           `json:"query,omitempty"`
+}
+
+// SessionTimeline describes the arrangement of timelines. Timelines are identified by timelineKey.
+type SessionTimeline struct {
+	// All arrangeable timelines, in the user's chosen order.
+	Order  []string `json:"order"`
+	Hidden []string `json:"hidden,omitempty"`
+	Pinned []string `json:"pinned,omitempty"`
+	// The timelines limited to by ShowOnlyTimelines, or nil if all timelines are being displayed.
+	Only []string `json:"only"`
+}
+
+// SessionView identifies a tab or panel.
+type SessionView struct {
+	Kind string `json:"kind"`
+	// The ID of the goroutine or processor the view displays, or the index of the GC cycle.
+	ID uint64 `json:"id,omitempty"`
+	// The name of the function the view displays, or the query of a search.
+	Name  string          `json:"name,omitempty"`
+	Start trace.Timestamp `json:"start,omitempty"`
+	End   trace.Timestamp `json:"end,omitempty"`
+}
+
+// sessionPath returns the path of the session file that is stored alongside the trace at tracePath.
+func sessionPath(tracePath string) string {
+	return tracePath + ".session.json"
+}
+
+// ReadSession reads a session. It returns nil and no error if there is no session file.
+func ReadSession(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeSession(f)
+}
+
+func DecodeSession(r io.Reader) (*Session, error) {
+	var s Session
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Session) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(s)
+}
+
+// Write stores the session at path.
+func (s *Session) Write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that we never leave behind a partially written session.
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := s.Encode(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// sessionGoroutine returns the goroutine with the given ID, or nil if there is none. Unlike Trace.G, it doesn't panic
+// for unknown goroutines, which sessions may refer to if they have been edited by hand.
+func sessionGoroutine(tr *Trace, gid uint64) *ptrace.Goroutine {
+	for _, g := range tr.Goroutines {
+		if g.ID == gid {
+			return g
+		}
+	}
+	return nil
+}
+
+// sessionProcessor is like sessionGoroutine, but for processors.
+func sessionProcessor(tr *Trace, pid int32) *ptrace.Processor {
+	for _, p := range tr.Processors {
+		if p.ID == pid {
+			return p
+		}
+	}
+	return nil
+}
+
+func sessionTrace(tr *Trace) SessionTrace {
+	return SessionTrace{End: tr.End(), Goroutines: len(tr.Goroutines), Events: len(tr.Events)}
+}
+
+func timelineKeys(tls []*Timeline) []string {
+	var out []string
+	for _, tl := range tls {
+		if key := timelineKey(tl.item); key != "" {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func timelineSetKeys(cv *Canvas, set map[*Timeline]struct{}) []string {
+	if set == nil {
+		return nil
+	}
+	// Iterate over allTimelines to get a deterministic order.
+	out := []string{}
+	for _, tl := range cv.allTimelines {
+		if _, ok := set[tl]; ok {
+			if key := timelineKey(tl.item); key != "" {
+				out = append(out, key)
+			}
+		}
+	}
+	return out
+}
+
+// session captures the canvas's part of a session.
+func (cv *Canvas) session(s *Session) {
+	s.Location = SessionLocation{Start: cv.start, NsPerPx: cv.nsPerPx, Y: cv.y}
+	for _, e := range cv.locationHistory.items {
+		s.LocationHistory = append(s.LocationHistory, SessionLocation{Start: e.start, NsPerPx: e.nsPerPx, Y: e.y})
+	}
+	s.LocationHistoryCursor = cv.locationHistory.cursor
+	s.AxisAnchor = cv.axis.anchor
+	if r, ok := cv.selectedRange.Get(); ok {
+		s.SelectedRange = &r
+	}
+
+	s.Display = SessionDisplay{
+		Compact:         cv.timeline.compact,
+		StackTracks:     cv.timeline.displayStackTracks,
+		Flows:           cv.timeline.displayFlows,
+		AllLabels:       cv.timeline.displayAllLabels,
+		Tooltips:        cv.timeline.showTooltips,
+		GCOverlays:      cv.timeline.showGCOverlays,
+		HideNonMatching: cv.timeline.hideNonMatching,
+		Grouping:        timelineGroupingNames[cv.groups.by],
+	}
+	for key := range cv.groups.expanded {
+		s.Display.ExpandedGroups = append(s.Display.ExpandedGroups, key)
+	}
+	sort.Strings(s.Display.ExpandedGroups)
+	if g := cv.goroutinePath.goroutine; g != nil {
+		s.Display.GoroutinePath = g.ID
+		s.Display.HasGoroutinePath = true
+	}
+
+	f := cv.timeline.filter
+	s.Filter = SessionFilter{
+		Mode:                f.Mode,
+		States:              f.States,
+		ProcessorGoroutine:  f.Processor.Goroutine,
+		ProcessorStartAfter: f.Processor.StartAfter,
+		ProcessorEndBefore:  f.Processor.EndBefore,
+		MachineProcessor:    f.Machine.Processor,
+	}
+	if f.Spans != nil {
+		for ev := range *f.Spans {
+			s.Filter.Spans = append(s.Filter.Spans, ev)
+		}
+		sort.Slice(s.Filter.Spans, func(i, j int) bool { return s.Filter.Spans[i] < s.Filter.Spans[j] })
+	}
+	if f.Query != nil {
+		s.Filter.Query = f.Query.String()
+	}
+
+	s.Timelines = SessionTimeline{
+		Order:  timelineKeys(cv.allTimelines),
+		Hidden: timelineSetKeys(cv, cv.hiddenTimelines),
+		Pinned: timelineKeys(cv.pinnedTimelines),
+		Only:   timelineSetKeys(cv, cv.onlyTimelines),
+	}
+}
+
+// restoreSession applies the canvas's part of a session. Timelines and objects that no longer exist are ignored.
+func (cv *Canvas) restoreSession(s *Session) {
+	byKey := make(map[string]*Timeline, len(cv.allTimelines))
+	for _, tl := range cv.allTimelines {
+		if key := timelineKey(tl.item); key != "" {
+			byKey[key] = tl
+		}
+	}
+	lookup := func(keys []string) []*Timeline {
+		var out []*Timeline
+		for _, key := range keys {
+			if tl, ok := byKey[key]; ok {
+				out = append(out, tl)
+			}
+		}
+		return out
+	}
+	toSet := func(tls []*Timeline) map[*Timeline]struct{} {
+		set := make(map[*Timeline]struct{}, len(tls))
+		for _, tl := range tls {
+			set[tl] = struct{}{}
+		}
+		return set
+	}
+
+	// Restore the order of timelines. Timelines missing from the session keep their relative order and go after the
+	// ones that are in the session, while fixed timelines stay at the top.
+	rank := map[*Timeline]int{}
+	for i, tl := range lookup(s.Timelines.Order) {
+		rank[tl] = i
+	}
+	sort.SliceStable(cv.allTimelines, func(i, j int) bool {
+		a, b := cv.allTimelines[i], cv.allTimelines[j]
+		if fa, fb := isFixedTimeline(a), isFixedTimeline(b); fa || fb {
+			return fa && !fb
+		}
+		ra, oka := rank[a]
+		rb, okb := rank[b]
+		if oka && okb {
+			return ra < rb
+		}
+		return oka && !okb
+	})
+
+	cv.hiddenTimelines = toSet(lookup(s.Timelines.Hidden))
+	cv.setPinnedTimelines(lookup(s.Timelines.Pinned))
+	if s.Timelines.Only != nil {
+		cv.onlyTimelines = toSet(lookup(s.Timelines.Only))
+	} else {
+		cv.onlyTimelines = nil
+	}
+
+	cv.groups = timelineGroups{}
+	for by, name := range timelineGroupingNames {
+		if name == s.Display.Grouping {
+			cv.groups.by = timelineGrouping(by)
+			break
+		}
+	}
+	if cv.groups.by != timelineGroupingNone {
+		cv.groups.expanded = map[string]struct{}{}
+		for _, key := range s.Display.ExpandedGroups {
+			cv.groups.expanded[key] = struct{}{}
+		}
+	}
+	cv.updateDisplayedTimelines()
+
+	cv.timeline.compact = s.Display.Compact
+	cv.timeline.displayStackTracks = s.Display.StackTracks
+	cv.timeline.displayFlows = s.Display.Flows
+	cv.timeline.displayAllLabels = s.Display.AllLabels
+	cv.timeline.showTooltips = s.Display.Tooltips
+	cv.timeline.showGCOverlays = s.Display.GCOverlays
+	cv.timeline.hideNonMatching = s.Display.HideNonMatching
+	cv.goroutinePath.goroutine = nil
+	cv.goroutinePath.runs = nil
+	if s.Display.HasGoroutinePath {
+		if g := sessionGoroutine(cv.trace, s.Display.GoroutinePath); g != nil {
+			cv.ShowGoroutinePath(g)
+		}
+	}
+
+	f := Filter{
+		Mode:   s.Filter.Mode,
+		States: s.Filter.States,
+	}
+	f.Processor.Goroutine = s.Filter.ProcessorGoroutine
+	f.Processor.StartAfter = s.Filter.ProcessorStartAfter
+	f.Processor.EndBefore = s.Filter.ProcessorEndBefore
+	f.Machine.Processor = s.Filter.MachineProcessor
+	if len(s.Filter.Spans) != 0 {
+		set := container.Set[ptrace.EventID]{}
+		for _, ev := range s.Filter.Spans {
+			set[ev] = struct{}{}
+		}
+		f.Spans = &set
+	}
+	if s.Filter.Query != "" {
+		// The query was valid when the session was saved, but the query language may have changed since.
+		if q, err := ParseFilterQuery(s.Filter.Query); err == nil {
+			f.Query = q
+		}
+	}
+	cv.timeline.filter = f
+
+	cv.cancelNavigation()
+	if s.Location.NsPerPx > 0 {
+		cv.start = s.Location.Start
+		cv.nsPerPx = s.Location.NsPerPx
+		cv.y = s.Location.Y
+	}
+	cv.locationHistory = locationHistory{cursor: -1}
+	for _, e := range s.LocationHistory {
+		cv.locationHistory.items = append(cv.locationHistory.items, LocationHistoryEntry{start: e.Start, nsPerPx: e.NsPerPx, y: e.Y})
+	}
+	if len(cv.locationHistory.items) > maxLocationHistoryEntries {
+		cv.locationHistory.items = cv.locationHistory.items[len(cv.locationHistory.items)-maxLocationHistoryEntries:]
+	}
+	cv.locationHistory.cursor = min(s.LocationHistoryCursor, len(cv.locationHistory.items)-1)
+	cv.axis.anchor = s.AxisAnchor
+	if s.SelectedRange != nil {
+		cv.selectedRange = container.Some(*s.SelectedRange)
+	} else {
+		cv.selectedRange = container.None[TimeSpan]()
+	}
+}
+
+// sessionView returns the description of a tab's or panel's component, or false if the component cannot be restored.
+func (mwin *MainWindow) sessionView(c theme.Component) (SessionView, bool) {
+	switch c := c.(type) {
+	case *SpansInfo:
+		switch obj := c.cfg.Object.(type) {
+		case *ptrace.Goroutine:
+			return SessionView{Kind: "goroutine", ID: obj.ID}, true
+		case *ptrace.Processor:
+			return SessionView{Kind: "processor", ID: uint64(obj.ID)}, true
+		}
+	case *FunctionInfo:
+		return SessionView{Kind: "function", Name: c.fn.Fn}, true
+	case *GoroutineSnapshot:
+		return SessionView{Kind: "goroutine-snapshot", Start: c.ts}, true
+	case *TimeRangeComponent:
+		return SessionView{Kind: "time-range", Start: c.start, End: c.end}, true
+	case *BookmarksComponent:
+		return SessionView{Kind: "bookmarks"}, true
+	case *FlameGraphComponent:
+		if r, ok := c.timeRange.Get(); ok {
+			return SessionView{Kind: "time-range-flame-graph", Start: r.Start, End: r.End}, true
+		} else if c.g != nil {
+			return SessionView{Kind: "goroutine-flame-graph", ID: c.g.ID}, true
+		} else {
+			return SessionView{Kind: "flame-graph"}, true
+		}
+	case *SearchComponent:
+		return SessionView{Kind: "search", Name: c.editor.Text()}, true
+	case *HeatmapComponent:
+		return SessionView{Kind: "heatmap"}, true
+	case *PossibleLeaksComponent:
+		return SessionView{Kind: "possible-leaks"}, true
+	case *GoroutineTreeComponent:
+		return SessionView{Kind: "goroutine-tree"}, true
+	case *NetworkComponent:
+		return SessionView{Kind: "network"}, true
+	case *GCCyclesComponent:
+		return SessionView{Kind: "gc-cycles"}, true
+	case *GCAssistsComponent:
+		if c.cycle != nil {
+			return SessionView{Kind: "gc-cycle-assists", ID: uint64(c.cycle.Index)}, true
+		}
+		return SessionView{Kind: "gc-assists"}, true
+	case *MigrationsComponent:
+		return SessionView{Kind: "migrations"}, true
+	case *HiddenTimelinesComponent:
+		return SessionView{Kind: "hidden-timelines"}, true
+	case *ParallelismComponent:
+		return SessionView{Kind: "parallelism"}, true
+	case *SyscallsComponent:
+		return SessionView{Kind: "syscalls"}, true
+	}
+	return SessionView{}, false
+}
+
+// sessionComponent recreates the component described by v. It returns nil if the view's object doesn't exist.
+func (mwin *MainWindow) sessionComponent(v SessionView) theme.Component {
+	tr := mwin.trace
+	cv := &mwin.canvas
+	switch v.Kind {
+	case "goroutine":
+		if g := sessionGoroutine(tr, v.ID); g != nil {
+			return NewGoroutineInfo(tr, mwin.twin, cv, g, cv.AllTimelines())
+		}
+	case "processor":
+		if p := sessionProcessor(tr, int32(v.ID)); p != nil {
+			return NewProcessorInfo(tr, mwin.twin, cv, p, cv.AllTimelines())
+		}
+	case "function":
+		if fn, ok := tr.Functions[v.Name]; ok {
+			return NewFunctionInfo(tr, mwin.twin, fn)
+		}
+	case "goroutine-snapshot":
+		return NewGoroutineSnapshot(tr, mwin.twin, cv, v.Start)
+	case "time-range":
+		return NewTimeRangeComponent(tr, mwin.twin, cv, v.Start, v.End)
+	case "bookmarks":
+		return NewBookmarksComponent(mwin.twin, cv)
+	case "flame-graph":
+		return NewFlameGraphComponent(mwin.twin, tr.Trace, nil)
+	case "goroutine-flame-graph":
+		if g := sessionGoroutine(tr, v.ID); g != nil {
+			return NewFlameGraphComponent(mwin.twin, tr.Trace, g)
+		}
+	case "time-range-flame-graph":
+		return NewTimeRangeFlameGraphComponent(mwin.twin, tr.Trace, v.Start, v.End)
+	case "search":
+		mwin.search = NewSearchComponent(tr, cv)
+		mwin.search.editor.SetText(v.Name)
+		return mwin.search
+	case "heatmap":
+		return NewHeatmapComponent(tr)
+	case "possible-leaks":
+		return NewPossibleLeaksComponent(tr)
+	case "goroutine-tree":
+		return NewGoroutineTreeComponent(mwin.twin, tr)
+	case "network":
+		return NewNetworkComponent(mwin.twin, tr, cv)
+	case "gc-cycles":
+		return NewGCCyclesComponent(tr, cv)
+	case "gc-assists":
+		return NewGCAssistsComponent(tr, nil)
+	case "gc-cycle-assists":
+		if cycles := ptrace.ComputeGCCycles(tr.Trace); v.ID < uint64(len(cycles)) {
+			return NewGCAssistsComponent(tr, &cycles[v.ID])
+		}
+	case "migrations":
+		return NewMigrationsComponent(mwin.twin, tr)
+	case "hidden-timelines":
+		return NewHiddenTimelinesComponent(cv)
+	case "parallelism":
+		return NewParallelismComponent(mwin.twin, tr, cv, 0, tr.End())
+	case "syscalls":
+		return NewSyscallsComponent(mwin.twin, tr, cv)
+	}
+	return nil
+}
+
+// Session captures the current state of the investigation.
+func (mwin *MainWindow) Session() *Session {
+	s := &Session{Trace: sessionTrace(mwin.trace)}
+	mwin.canvas.session(s)
+
+	for i, tab := range mwin.tabs {
+		if tab.Unclosable {
+			if i == mwin.tabbedState.Current {
+				s.CurrentTab = i
+			}
+			continue
+		}
+		v, ok := mwin.sessionView(tab.Component)
+		if !ok {
+			continue
+		}
+		if i == mwin.tabbedState.Current {
+			s.CurrentTab = mwin.numFixedTabs() + len(s.Tabs)
+		}
+		s.Tabs = append(s.Tabs, v)
+	}
+
+	for _, p := range mwin.panelHistory {
+		if v, ok := mwin.sessionView(p); ok {
+			s.Panels = append(s.Panels, v)
+		}
+	}
+	if mwin.panel != nil {
+		if v, ok := mwin.sessionView(mwin.panel); ok {
+			s.Panels = append(s.Panels, v)
+		}
+	}
+	return s
+}
+
+func (mwin *MainWindow) numFixedTabs() int {
+	n := 0
+	for _, tab := range mwin.tabs {
+		if tab.Unclosable {
+			n++
+		}
+	}
+	return n
+}
+
+// RestoreSession applies a session to the current trace. It fails if the session belongs to a different trace.
+func (mwin *MainWindow) RestoreSession(s *Session) error {
+	if s.Trace != sessionTrace(mwin.trace) {
+		return errors.New("the session belongs to a different trace")
+	}
+
+	mwin.canvas.restoreSession(s)
+
+	mwin.tabs = mwin.tabs[:mwin.numFixedTabs()]
+	mwin.search = nil
+	for _, v := range s.Tabs {
+		c := mwin.sessionComponent(v)
+		if c == nil {
+			continue
+		}
+		if _, ok := c.(Panel); ok {
+			c.Transition(theme.ComponentStateTab)
+		}
+		mwin.openTabBg(Tab{Component: c})
+	}
+	mwin.tabbedState.Current = 0
+	if s.CurrentTab >= 0 && s.CurrentTab < len(mwin.tabs) {
+		mwin.tabbedState.Current = s.CurrentTab
+	}
+
+	mwin.panel = nil
+	mwin.panelHistory = nil
+	for _, v := range s.Panels {
+		if c, ok := mwin.sessionComponent(v).(Panel); ok {
+			mwin.openPanel(c)
+		}
+	}
+	return nil
+}
+
+// saveSessionAlongsideTrace stores the current session next to the trace file, if the trace was loaded from a file.
+func (mwin *MainWindow) saveSessionAlongsideTrace() error {
+	if mwin.trace == nil || mwin.tracePath == "" {
+		return errors.New("the trace wasn't loaded from a file")
+	}
+	return mwin.Session().Write(sessionPath(mwin.tracePath))
+}
+
+// saveSession stores the session alongside the trace and notifies the user of the outcome.
+func (mwin *MainWindow) saveSession(win *theme.Window, gtx layout.Context) {
+	if err := mwin.saveSessionAlongsideTrace(); err != nil {
+		win.ShowNotification(gtx, fmt.Sprintf("Couldn't save session: %s", err))
+	} else {
+		win.ShowNotification(gtx, fmt.Sprintf("Saved session to %s", sessionPath(mwin.tracePath)))
+	}
+}
+
+// autosaveSession updates the session stored alongside the trace, so that it can be restored the next time the trace
+// is opened. Only sessions that the user has saved before get updated; we don't want to litter the trace's directory
+// with files the user didn't ask for.
+func (mwin *MainWindow) autosaveSession() {
+	if mwin.state != "main" || mwin.tracePath == "" {
+		return
+	}
+	if _, err := os.Stat(sessionPath(mwin.tracePath)); err != nil {
+		return
+	}
+	if err := mwin.saveSessionAlongsideTrace(); err != nil {
+		log.Println("couldn't save session:", err)
+	}
+}
+
+// showSessionSaveDialog lets the user choose where to save the current session.
+func (mwin *MainWindow) showSessionSaveDialog() {
+	// Capture the session now, on the UI goroutine.
+	s := mwin.Session()
+	if mwin.showingExplorer.CompareAndSwap(false, true) {
+		go func() {
+			w, err := mwin.explorer.CreateFile("session.json")
+			mwin.showingExplorer.Store(false)
+			if err == nil {
+				err = s.Encode(w)
+				if cerr := w.Close(); err == nil {
+					err = cerr
+				}
+			}
+			mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+				switch err {
+				case nil:
+					mwin.twin.ShowNotification(gtx, "Saved session")
+				case explorer.ErrUserDecline:
+				default:
+					mwin.twin.ShowNotification(gtx, fmt.Sprintf("Couldn't save session: %s", err))
+				}
+			}))
+		}()
+	}
+}
+
+// showSessionOpenDialog lets the user choose a session to restore.
+func (mwin *MainWindow) showSessionOpenDialog() {
+	if mwin.showingExplorer.CompareAndSwap(false, true) {
+		go func() {
+			r, err := mwin.explorer.ChooseFile(".json")
+			mwin.showingExplorer.Store(false)
+			var s *Session
+			if err == nil {
+				s, err = DecodeSession(r)
+				r.Close()
+			}
+			mwin.twin.EmitAction(theme.ExecuteAction(func(gtx layout.Context) {
+				if err == nil {
+					err = mwin.RestoreSession(s)
+				}
+				switch err {
+				case nil:
+					mwin.twin.ShowNotification(gtx, "Restored session")
+				case explorer.ErrUserDecline:
+				default:
+					mwin.twin.ShowNotification(gtx, fmt.Sprintf("Couldn't open session: %s", err))
+				}
+			}))
+		}()
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Settings are user preferences that persist between sessions. They are stored as JSON in
+// <config dir>/gotraceui/settings.json, where the config dir is the one returned by os.UserConfigDir, e.g.
+// ~/.config on Linux. Settings without a UI, such as MetricPrefixes, can be changed by editing the file.
+type Settings struct {
+	// The plots displayed above the timelines, from top to bottom.
+	Plots []PlotSettings `json:"plots"`
+	// The share of the canvas's height used by the plots.
+	PlotsRatio float32 `json:"plots_ratio"`
+	// User logs whose keys start with one of these prefixes are plotted as metrics, even if not all of their messages
+	// are numbers. Changes take effect the next time a trace is loaded.
+	MetricPrefixes []string `json:"metric_prefixes"`
+}
+
+type PlotSettings struct {
+	// The ID of the plot's kind, see plotKinds.
+	Kind string `json:"kind"`
+	// The plot's share of the height left over by the plots above it.
+	Ratio float32 `json:"ratio"`
+}
+
+func defaultSettings() Settings {
+	return Settings{
+		Plots: []PlotSettings{
+			{Kind: "memory", Ratio: 1},
+		},
+		PlotsRatio: 0.1,
+	}
+}
+
+func settingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotraceui", "settings.json"), nil
+}
+
+// LoadSettings loads the user's settings. It returns the default settings if there are no stored settings or if they
+// cannot be loaded.
+func LoadSettings() Settings {
+	s := defaultSettings()
+	path, err := settingsPath()
+	if err != nil {
+		log.Println("couldn't load settings:", err)
+		return s
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Println("couldn't load settings:", err)
+		}
+		return s
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		log.Println("couldn't load settings:", err)
+		return defaultSettings()
+	}
+	return s
+}
+
+// Save stores the settings in the user's configuration directory.
+func (s *Settings) Save() error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that we never leave behind partially written settings.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"image"
+	rtrace "runtime/trace"
+	"sort"
+	"time"
+
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// goroutineSnapshotGroup contains all goroutines that were in the same state and started in the same function.
+type goroutineSnapshotGroup struct {
+	state    ptrace.SchedulingState
+	function *ptrace.Function
+	spans    []ptrace.GoroutineSpan
+
+	expanded bool
+	toggle   widget.Clickable
+
+	text      Text
+	prevSpans []TextSpan
+}
+
+// GoroutineSnapshot is a panel that lists the states of all goroutines at a point in time.
+type GoroutineSnapshot struct {
+	trace  *Trace
+	canvas *Canvas
+	mwin   *theme.Window
+	ts     trace.Timestamp
+
+	total  int
+	groups []*goroutineSnapshotGroup
+
+	descriptionText Text
+	prevSpans       []TextSpan
+	hoveredLink     ObjectLink
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+
+	theme.ComponentButtons
+}
+
+func NewGoroutineSnapshot(tr *Trace, mwin *theme.Window, cv *Canvas, ts trace.Timestamp) *GoroutineSnapshot {
+	gs := &GoroutineSnapshot{
+		trace:  tr,
+		canvas: cv,
+		mwin:   mwin,
+		ts:     ts,
+	}
+
+	type key struct {
+		state    ptrace.SchedulingState
+		function *ptrace.Function
+	}
+	groups := map[key]*goroutineSnapshotGroup{}
+	for _, s := range ptrace.GoroutinesAt(tr.Trace, ts) {
+		k := key{s.Goroutine.Spans[s.Span].State, s.Goroutine.Function}
+		group, ok := groups[k]
+		if !ok {
+			group = &goroutineSnapshotGroup{state: k.state, function: k.function}
+			groups[k] = group
+			gs.groups = append(gs.groups, group)
+		}
+		group.spans = append(group.spans, s)
+		gs.total++
+	}
+	sort.SliceStable(gs.groups, func(i, j int) bool {
+		a, b := gs.groups[i], gs.groups[j]
+		if a.state != b.state {
+			return stateNamesCapitalized[a.state] < stateNamesCapitalized[b.state]
+		}
+		return len(a.spans) > len(b.spans)
+	})
+
+	return gs
+}
+
+func (gs *GoroutineSnapshot) HoveredLink() ObjectLink {
+	return gs.hoveredLink
+}
+
+func (gs *GoroutineSnapshot) Title() string {
+	return "Goroutines at " + formatTimestamp(nil, gs.ts)
+}
+
+// spanItems returns the span as items belonging to the goroutine's timeline.
+func (gs *GoroutineSnapshot) spanItems(s ptrace.GoroutineSpan) Items[ptrace.Span] {
+	items := SimpleItems[ptrace.Span, any]{
+		items:    s.Goroutine.Spans[s.Span : s.Span+1],
+		subslice: true,
+	}
+	if tl, ok := gs.canvas.itemToTimeline[s.Goroutine]; ok {
+		items.container = ItemContainer{
+			Timeline: tl,
+			Track:    tl.tracks[0],
+		}
+	}
+	return items
+}
+
+func (gs *GoroutineSnapshot) topFrame(span *ptrace.Span) string {
+	stk := gs.trace.Stacks[gs.trace.Event(span.Event).StkID]
+	if len(stk) == 0 {
+		return ""
+	}
+	return gs.trace.PCs[stk[0]].Fn
+}
+
+func (gs *GoroutineSnapshot) buildDetails(win *theme.Window, group *goroutineSnapshotGroup) []TextSpan {
+	// Display at most this many goroutines, to keep the text manageable.
+	const maxGoroutines = 100
+
+	tb := TextBuilder{Window: win}
+	for i, s := range group.spans {
+		if i != 0 {
+			tb.Span("\n")
+		}
+		if i == maxGoroutines {
+			tb.Span(local.Sprintf("and %d more", len(group.spans)-maxGoroutines))
+			break
+		}
+		span := &s.Goroutine.Spans[s.Span]
+		tb.DefaultLink(local.Sprintf("Goroutine %d", s.Goroutine.ID), "Goroutine snapshot", s.Goroutine)
+		tb.Span(" since ")
+		tb.DefaultLink(formatTimestamp(nil, span.Start), "Goroutine snapshot", span.Start)
+		tb.Span(local.Sprintf(" (%s in state)", roundDuration(time.Duration(gs.ts-span.Start))))
+		if fn := gs.topFrame(span); fn != "" {
+			tb.Span(" in " + fn)
+		}
+		tb.Span(" ")
+		tb.Link("<Span>", &SpansObjectLink{Spans: gs.spanItems(s)})
+	}
+	return tb.Spans
+}
+
+func (gs *GoroutineSnapshot) initTable(win *theme.Window, gtx layout.Context) {
+	if gs.table != nil {
+		return
+	}
+	gs.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "State", Alignment: text.Start},
+		{Name: "Function", Alignment: text.Start},
+		{Name: "Goroutines", Alignment: text.End},
+	}
+	gs.table.SetColumns(win, gtx, cols)
+}
+
+func (gs *GoroutineSnapshot) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.GoroutineSnapshot.Layout").End()
+
+	gs.initTable(win, gtx)
+	gs.table.Update(gtx)
+	gs.cellFormatter.Update(win, gtx)
+
+	for _, ev := range gs.descriptionText.Update(gtx, gs.prevSpans) {
+		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+	}
+	firstNonNil := func(els ...ObjectLink) ObjectLink {
+		for _, el := range els {
+			if el != nil {
+				return el
+			}
+		}
+		return nil
+	}
+	gs.hoveredLink = firstNonNil(gs.descriptionText.HoveredLink(), gs.cellFormatter.HoveredLink())
+	for _, group := range gs.groups {
+		for {
+			if _, ok := group.toggle.Clicked(gtx); !ok {
+				break
+			}
+			group.expanded = !group.expanded
+		}
+		if !group.expanded {
+			continue
+		}
+		for _, ev := range group.text.Update(gtx, group.prevSpans) {
+			handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+		}
+		gs.hoveredLink = firstNonNil(gs.hoveredLink, group.text.HoveredLink())
+	}
+
+	for gs.ComponentButtons.Backed(gtx) {
+		gs.mwin.EmitAction(&PrevPanelAction{})
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, row, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := gs.groups[row]
+		switch colName := gs.table.Columns[col].Name; colName {
+		case "State":
+			return group.toggle.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				indicator := "▶ "
+				if group.expanded {
+					indicator = "▼ "
+				}
+				return gs.cellFormatter.Text(win, gtx, indicator+stateNamesCapitalized[group.state])
+			})
+		case "Function":
+			return gs.cellFormatter.Function(win, gtx, group.function)
+		case "Goroutines":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return gs.cellFormatter.Number(win, gtx, len(group.spans))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	rowFn := func(win *theme.Window, gtx layout.Context, row int) layout.Dimensions {
+		group := gs.groups[row]
+		if !group.expanded {
+			return theme.TableSimpleRow(gs.table).Layout(win, gtx, row, cellFn)
+		}
+
+		return layout.Rigids(gtx, layout.Vertical,
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableSimpleRow(gs.table).Layout(win, gtx, row, cellFn)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableExpandedRow(gs.table).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(5).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						group.text.Reset(win.Theme)
+						group.prevSpans = gs.buildDetails(win, group)
+						return group.text.Layout(win, gtx, group.prevSpans)
+					})
+				})
+			},
+		)
+	}
+
+	// Inset of 5 pixels on all sides. We can't use layout.Inset because it doesn't decrease the minimum constraint,
+	// which we do care about here.
+	gtx.Constraints.Min = gtx.Constraints.Min.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints.Max = gtx.Constraints.Max.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints = layout.Normalize(gtx.Constraints)
+	defer op.Offset(image.Pt(5, 5)).Push(gtx.Ops).Pop()
+
+	nothing := func(gtx layout.Context) layout.Dimensions {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Flexed(1, nothing),
+				layout.Rigid(theme.Dumb(win, gs.ComponentButtons.Layout)),
+			)
+		},
+
+		func(gtx layout.Context) layout.Dimensions { return layout.Spacer{Height: 10}.Layout(gtx) },
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = image.Point{}
+			tb := TextBuilder{Window: win}
+			tb.Span(local.Sprintf("%d goroutines alive at ", gs.total))
+			tb.DefaultLink(formatTimestamp(nil, gs.ts), "Goroutine snapshot", gs.ts)
+			gs.descriptionText.Reset(win.Theme)
+			gs.prevSpans = tb.Spans
+			return gs.descriptionText.Layout(win, gtx, gs.prevSpans)
+		},
+
+		func(gtx layout.Context) layout.Dimensions { return layout.Spacer{Height: 10}.Layout(gtx) },
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.FairlySimpleTable(win, gtx, gs.table, &gs.scrollState, len(gs.groups), rowFn)
+		},
+	)
+}
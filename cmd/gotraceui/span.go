@@ -105,6 +105,17 @@ type SpansInfoConfig struct {
 	Statistics         func(win *theme.Window) *theme.Future[*SpansStats]
 	Navigations        SpansInfoConfigNavigations
 	ShowHistogram      bool
+	// Additional tabs, displayed after the built-in ones.
+	Tabs []SpansInfoTab
+	// The object the spans belong to, such as a goroutine, if any. It is used for identifying the panel in sessions.
+	Object any
+}
+
+type SpansInfoTab struct {
+	Name   string
+	Layout func(win *theme.Window, gtx layout.Context) layout.Dimensions
+	// HoveredLink, if set, returns the link that has been hovered during the last call to Layout.
+	HoveredLink func() ObjectLink
 }
 
 type SpansInfoConfigNavigations struct {
@@ -375,6 +386,14 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 		si.eventList.HoveredLink(),
 		si.spanList.HoveredLink(),
 	)
+	for _, tab := range si.cfg.Tabs {
+		if si.hoveredLink != nil {
+			break
+		}
+		if tab.HoveredLink != nil {
+			si.hoveredLink = tab.HoveredLink()
+		}
+	}
 
 	for si.buttons.scrollAndPanToSpans.Clicked(gtx) {
 		si.scrollAndPanToSpans(win)
@@ -518,6 +537,9 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 				if si.cfg.ShowHistogram {
 					tabs = append(tabs, "Histogram")
 				}
+				for _, tab := range si.cfg.Tabs {
+					tabs = append(tabs, tab.Name)
+				}
 				return theme.Tabbed(&si.tabbedState, tabs).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
 					gtx.Constraints.Min = gtx.Constraints.Max
 					switch tabs[si.tabbedState.Current] {
@@ -561,6 +583,11 @@ func (si *SpansInfo) Layout(win *theme.Window, gtx layout.Context) layout.Dimens
 						return si.hist.Layout(win, gtx)
 
 					default:
+						for _, tab := range si.cfg.Tabs {
+							if tab.Name == tabs[si.tabbedState.Current] {
+								return tab.Layout(win, gtx)
+							}
+						}
 						panic("impossible")
 					}
 				})
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+	"sort"
+	"time"
+
+	"github.com/joonho3020/gotraceui/container"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/font"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+type syscallGoroutine struct {
+	g     *ptrace.Goroutine
+	count int
+	total time.Duration
+}
+
+// syscallGoroutines returns the goroutines that made the group's syscalls, sorted by the number of syscalls, in
+// descending order.
+func syscallGoroutines(group *ptrace.SyscallGroup) []syscallGoroutine {
+	var out []syscallGoroutine
+	idx := map[*ptrace.Goroutine]int{}
+	for _, s := range group.Syscalls {
+		i, ok := idx[s.Goroutine]
+		if !ok {
+			i = len(out)
+			idx[s.Goroutine] = i
+			out = append(out, syscallGoroutine{g: s.Goroutine})
+		}
+		out[i].count++
+		out[i].total += s.Duration()
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		return out[i].total > out[j].total
+	})
+	return out
+}
+
+type syscallRow struct {
+	expanded  bool
+	toggle    widget.Clickable
+	highlight widget.Clickable
+	// The blocking syscall spans of the group, computed when the group is first highlighted.
+	spans *container.Set[ptrace.EventID]
+	// The goroutines that made the syscalls, computed when the row is first expanded.
+	goroutines []syscallGoroutine
+
+	text      Text
+	prevSpans []TextSpan
+}
+
+// SyscallsComponent groups syscalls by the wrapper function that made them and by their call site.
+type SyscallsComponent struct {
+	trace  *Trace
+	canvas *Canvas
+
+	computed *theme.Future[[]ptrace.SyscallGroup]
+	// Whether groups and rows have been populated with the result of computed.
+	initialized bool
+
+	groups SortedIndices[ptrace.SyscallGroup, []ptrace.SyscallGroup]
+	// Per-row state, indexed by the index into groups.Items.
+	rows []syscallRow
+
+	hoveredLink ObjectLink
+
+	table         *theme.Table
+	scrollState   theme.YScrollableListState
+	cellFormatter CellFormatter
+}
+
+func NewSyscallsComponent(win *theme.Window, tr *Trace, cv *Canvas) *SyscallsComponent {
+	return &SyscallsComponent{
+		trace:  tr,
+		canvas: cv,
+		computed: theme.NewFuture(win, func(cancelled <-chan struct{}) []ptrace.SyscallGroup {
+			return ptrace.ComputeSyscalls(tr.Trace)
+		}),
+	}
+}
+
+// Title implements theme.Component.
+func (*SyscallsComponent) Title() string {
+	return "Syscalls"
+}
+
+// Transition implements theme.Component.
+func (*SyscallsComponent) Transition(state theme.ComponentState) {}
+
+// WantsTransition implements theme.Component.
+func (*SyscallsComponent) WantsTransition(gtx layout.Context) theme.ComponentState {
+	return theme.ComponentStateNone
+}
+
+func (sc *SyscallsComponent) HoveredLink() ObjectLink {
+	return sc.hoveredLink
+}
+
+func syscallFunctionLabel(group *ptrace.SyscallGroup) string {
+	if group.Function == "" {
+		return "unknown"
+	}
+	return group.Function
+}
+
+func syscallCallSiteLabel(group *ptrace.SyscallGroup) string {
+	if group.CallSite.Fn == "" {
+		return "unknown"
+	}
+	return group.CallSite.Fn
+}
+
+func (sc *SyscallsComponent) initTable(win *theme.Window, gtx layout.Context) {
+	if sc.table != nil {
+		return
+	}
+	sc.table = &theme.Table{}
+	cols := []theme.Column{
+		{Name: "Function", Alignment: text.Start, Clickable: true},
+		{Name: "Call site", Alignment: text.Start, Clickable: true},
+		{Name: "Count", Alignment: text.End, Clickable: true},
+		{Name: "Blocking", Alignment: text.End, Clickable: true},
+		{Name: "Total", Alignment: text.End, Clickable: true},
+		{Name: "p50", Alignment: text.End, Clickable: true},
+		{Name: "p90", Alignment: text.End, Clickable: true},
+		{Name: "p99", Alignment: text.End, Clickable: true},
+		{Name: "Max", Alignment: text.End, Clickable: true},
+		{Name: "Goroutines", Alignment: text.End, Clickable: true},
+		{Name: "Canvas", Alignment: text.Start},
+	}
+	sc.table.SetColumns(win, gtx, cols)
+	// ComputeSyscalls sorts groups by total time.
+	sc.table.SortedBy = 4
+	sc.table.SortOrder = theme.SortDescending
+}
+
+func (sc *SyscallsComponent) sort() {
+	desc := sc.table.SortOrder == theme.SortDescending
+	byDuration := func(fn func(group *ptrace.SyscallGroup) time.Duration) {
+		sc.groups.Sort(func(a, b ptrace.SyscallGroup) int {
+			return cmp(fn(&a), fn(&b), desc)
+		})
+	}
+	switch sc.table.Columns[sc.table.SortedBy].Name {
+	case "Function":
+		sc.groups.Sort(func(a, b ptrace.SyscallGroup) int {
+			return cmp(syscallFunctionLabel(&a), syscallFunctionLabel(&b), desc)
+		})
+	case "Call site":
+		sc.groups.Sort(func(a, b ptrace.SyscallGroup) int {
+			return cmp(syscallCallSiteLabel(&a), syscallCallSiteLabel(&b), desc)
+		})
+	case "Count":
+		sc.groups.Sort(func(a, b ptrace.SyscallGroup) int {
+			return cmp(len(a.Syscalls), len(b.Syscalls), desc)
+		})
+	case "Blocking":
+		sc.groups.Sort(func(a, b ptrace.SyscallGroup) int {
+			return cmp(a.Blocking, b.Blocking, desc)
+		})
+	case "Goroutines":
+		sc.groups.Sort(func(a, b ptrace.SyscallGroup) int {
+			return cmp(a.Goroutines, b.Goroutines, desc)
+		})
+	case "Total":
+		byDuration(func(group *ptrace.SyscallGroup) time.Duration { return group.Total })
+	case "p50":
+		byDuration(func(group *ptrace.SyscallGroup) time.Duration { return group.P50 })
+	case "p90":
+		byDuration(func(group *ptrace.SyscallGroup) time.Duration { return group.P90 })
+	case "p99":
+		byDuration(func(group *ptrace.SyscallGroup) time.Duration { return group.P99 })
+	case "Max":
+		byDuration(func(group *ptrace.SyscallGroup) time.Duration { return group.Max })
+	}
+}
+
+// highlightedBy reports whether the canvas is currently highlighting the row's spans.
+func (sc *SyscallsComponent) highlightedBy(row *syscallRow) bool {
+	return row.spans != nil && sc.canvas.timeline.filter.Spans == row.spans
+}
+
+func (sc *SyscallsComponent) toggleHighlight(row *syscallRow, group *ptrace.SyscallGroup) {
+	if sc.highlightedBy(row) {
+		sc.canvas.timeline.filter = Filter{}
+		return
+	}
+	if row.spans == nil {
+		spans := container.Set[ptrace.EventID]{}
+		for _, s := range group.Syscalls {
+			if s.Blocking() {
+				spans.Add(s.Goroutine.Spans[s.Span].Event)
+			}
+		}
+		row.spans = &spans
+	}
+	sc.canvas.timeline.filter = Filter{Spans: row.spans}
+}
+
+func (sc *SyscallsComponent) buildDetails(win *theme.Window, group *ptrace.SyscallGroup, row *syscallRow) []TextSpan {
+	// Display at most this many goroutines, to keep the text manageable.
+	const maxGoroutines = 100
+
+	tb := TextBuilder{Window: win}
+	tb.Bold("Call site: ")
+	if group.CallSite.Fn == "" {
+		tb.Span("unknown")
+	} else {
+		tb.Span(fmt.Sprintf("%s\n        %s:%d", group.CallSite.Fn, group.CallSite.File, group.CallSite.Line))
+	}
+	tb.Span("\n")
+	if group.Blocking != len(group.Syscalls) {
+		tb.Span(local.Sprintf("%d of %d syscalls didn't block. Their durations aren't recorded in the trace and they aren't included in the latencies.\n", len(group.Syscalls)-group.Blocking, len(group.Syscalls)))
+	}
+	tb.Span("\n")
+
+	if row.goroutines == nil {
+		row.goroutines = syscallGoroutines(group)
+	}
+	gss := row.goroutines
+
+	tb.Bold("Goroutines:\n")
+	for i, gs := range gss {
+		if i == maxGoroutines {
+			tb.Span(local.Sprintf("and %d more", len(gss)-maxGoroutines))
+			break
+		}
+		tb.DefaultLink(local.Sprintf("Goroutine %d", gs.g.ID), "Syscalls", gs.g)
+		if gs.g.Function != nil {
+			tb.Span(local.Sprintf(" (%s)", gs.g.Function.Fn))
+		}
+		tb.Span(local.Sprintf(": %d syscalls, %s blocked\n", gs.count, roundDuration(gs.total)))
+	}
+	return tb.Spans
+}
+
+// Layout implements theme.Component.
+func (sc *SyscallsComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.SyscallsComponent.Layout").End()
+
+	groups, ok := sc.computed.Result()
+	if !ok {
+		return theme.Label(win.Theme, "Computing syscalls…").Layout(win, gtx)
+	}
+	if !sc.initialized {
+		sc.rows = make([]syscallRow, len(groups))
+		sc.groups.Reset(groups)
+		sc.initialized = true
+	}
+	if sc.groups.Len() == 0 {
+		return theme.Label(win.Theme, "The trace contains no syscalls.").Layout(win, gtx)
+	}
+
+	sc.initTable(win, gtx)
+	sc.table.Update(gtx)
+	if _, ok := sc.table.SortByClickedColumn(); ok {
+		sc.sort()
+	}
+
+	sc.cellFormatter.Update(win, gtx)
+	sc.hoveredLink = sc.cellFormatter.HoveredLink()
+	for i := range sc.rows {
+		row := &sc.rows[i]
+		for {
+			if _, ok := row.toggle.Clicked(gtx); !ok {
+				break
+			}
+			row.expanded = !row.expanded
+		}
+		for {
+			if _, ok := row.highlight.Clicked(gtx); !ok {
+				break
+			}
+			sc.toggleHighlight(row, &sc.groups.Items[i])
+		}
+		if row.expanded {
+			for _, ev := range row.text.Update(gtx, row.prevSpans) {
+				handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+			}
+			if sc.hoveredLink == nil {
+				sc.hoveredLink = row.text.HoveredLink()
+			}
+		}
+	}
+
+	cellFn := func(win *theme.Window, gtx layout.Context, rowIdx, col int) layout.Dimensions {
+		defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+
+		group := sc.groups.Ptr(rowIdx)
+		row := &sc.rows[sc.groups.Order[rowIdx]]
+		switch colName := sc.table.Columns[col].Name; colName {
+		case "Function":
+			return row.toggle.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				indicator := "▶ "
+				if row.expanded {
+					indicator = "▼ "
+				}
+				return sc.cellFormatter.Text(win, gtx, indicator+syscallFunctionLabel(group))
+			})
+		case "Call site":
+			return sc.cellFormatter.Text(win, gtx, syscallCallSiteLabel(group))
+		case "Count":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return sc.cellFormatter.Number(win, gtx, len(group.Syscalls))
+			})
+		case "Blocking":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return sc.cellFormatter.Number(win, gtx, group.Blocking)
+			})
+		case "Total":
+			return sc.cellFormatter.Duration(win, gtx, group.Total, false)
+		case "p50":
+			return sc.cellFormatter.Duration(win, gtx, group.P50, false)
+		case "p90":
+			return sc.cellFormatter.Duration(win, gtx, group.P90, false)
+		case "p99":
+			return sc.cellFormatter.Duration(win, gtx, group.P99, false)
+		case "Max":
+			return sc.cellFormatter.Duration(win, gtx, group.Max, false)
+		case "Goroutines":
+			return layout.RightAligned(gtx, func(gtx layout.Context) layout.Dimensions {
+				return sc.cellFormatter.Number(win, gtx, group.Goroutines)
+			})
+		case "Canvas":
+			if group.Blocking == 0 {
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			}
+			label := "Highlight spans"
+			if sc.highlightedBy(row) {
+				label = "Remove highlight"
+			}
+			return row.highlight.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widget.Label{MaxLines: 1}.Layout(gtx, win.Theme.Shaper, font.Font{}, 12, label, win.ColorMaterial(gtx, win.Theme.Palette.Link))
+			})
+		default:
+			panic(colName)
+		}
+	}
+
+	rowFn := func(win *theme.Window, gtx layout.Context, rowIdx int) layout.Dimensions {
+		row := &sc.rows[sc.groups.Order[rowIdx]]
+		if !row.expanded {
+			return theme.TableSimpleRow(sc.table).Layout(win, gtx, rowIdx, cellFn)
+		}
+
+		return layout.Rigids(gtx, layout.Vertical,
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableSimpleRow(sc.table).Layout(win, gtx, rowIdx, cellFn)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return theme.TableExpandedRow(sc.table).Layout(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(5).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						row.text.Reset(win.Theme)
+						row.prevSpans = sc.buildDetails(win, sc.groups.Ptr(rowIdx), row)
+						return row.text.Layout(win, gtx, row.prevSpans)
+					})
+				})
+			},
+		)
+	}
+
+	gtx.Constraints.Min = gtx.Constraints.Max
+	return theme.FairlySimpleTable(win, gtx, sc.table, &sc.scrollState, sc.groups.Len(), rowFn)
+}
@@ -50,6 +50,9 @@ const (
 	TrackKindUnspecified TrackKind = iota
 	TrackKindStack
 	TrackKindUserRegions
+	// The track of a processor timeline that shows when the processor was started and stopped. Its spans belong to
+	// g0, not to any user goroutine.
+	TrackKindProcessorLifecycle
 )
 
 type Timeline struct {
@@ -209,6 +212,7 @@ type TrackWidget struct {
 	tinyOps         [colorStateLast]mem.ReusableOps
 	eventsOps       mem.ReusableOps
 	mergedEventsOps mem.ReusableOps
+	logEventsOps    mem.ReusableOps
 
 	// cached state
 	prevFrame struct {
@@ -1018,14 +1022,19 @@ func (tsi *trackSpanInteractivity) Handle(win *theme.Window, gtx layout.Context,
 		tsi.track.widget.clickedSpans = spans
 	}
 	if tsi.trackContextMenuSpans {
+		var items []*theme.MenuItem
 		if tsi.track.spanContextMenu != nil {
-			win.SetContextMenu(tsi.track.spanContextMenu(spans, cv))
+			items = tsi.track.spanContextMenu(spans, cv)
 		} else {
-			win.SetContextMenu([]*theme.MenuItem{
+			items = []*theme.MenuItem{
 				newZoomMenuItem(cv, spans),
 				newOpenSpansMenuItem(spans),
-			})
+			}
+		}
+		if item := newBookmarkSpanMenuItem(cv, spans); item != nil {
+			items = append(items, item)
 		}
+		win.SetContextMenu(items)
 	}
 
 	spanTooltip := tsi.track.spanTooltip
@@ -1163,9 +1172,10 @@ func (track *Track) layoutEvents(win *theme.Window, gtx layout.Context, cv *Canv
 
 	track.widget.eventsHover.Update(gtx.Queue)
 
-	var eventsPath, mergedEventsPath clip.Path
+	var eventsPath, mergedEventsPath, logEventsPath clip.Path
 	eventsPath.Begin(track.widget.eventsOps.Get())
 	mergedEventsPath.Begin(track.widget.mergedEventsOps.Get())
+	logEventsPath.Begin(track.widget.logEventsOps.Get())
 	for _, dspSpans := range track.widget.prevFrame.dspSpans {
 		eventIDs := Events(dspSpans.dspSpans, cv.trace)
 		iter := renderedSpansIterator[eventWithGetters, *eventWithGetters]{
@@ -1265,7 +1275,12 @@ func (track *Track) layoutEvents(win *theme.Window, gtx layout.Context, cv *Canv
 
 				theme.FillShape(win, gtx.Ops, colors[colorSpanHighlightedPrimaryOutline], clip.Outline{Path: clip.FRect{Min: min, Max: max}.Path(gtx.Ops)}.Op())
 			} else if events.Len() == 1 {
-				p = &eventsPath
+				if ev := events.AtPtr(0); ev.Type == trace.EvUserLog && !cv.trace.isMetricLog(ev) {
+					// Highlight log messages, except for those that we already display as metrics.
+					p = &logEventsPath
+				} else {
+					p = &eventsPath
+				}
 			} else {
 				p = &mergedEventsPath
 			}
@@ -1281,6 +1296,7 @@ func (track *Track) layoutEvents(win *theme.Window, gtx layout.Context, cv *Canv
 	}
 	theme.FillShape(win, gtx.Ops, colors[colorEvent], clip.Outline{Path: eventsPath.End()}.Op())
 	theme.FillShape(win, gtx.Ops, colors[colorMergedEvents], clip.Outline{Path: mergedEventsPath.End()}.Op())
+	theme.FillShape(win, gtx.Ops, colors[colorLogEvent], clip.Outline{Path: logEventsPath.End()}.Op())
 }
 
 func singleSpanLabel(label string) func(spans Items[ptrace.Span], tr *Trace, out []string) []string {
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"image"
+	rtrace "runtime/trace"
+	"sort"
+	"time"
+
+	"github.com/joonho3020/gotraceui/clip"
+	"github.com/joonho3020/gotraceui/container"
+	"github.com/joonho3020/gotraceui/layout"
+	"github.com/joonho3020/gotraceui/theme"
+	"github.com/joonho3020/gotraceui/trace"
+	"github.com/joonho3020/gotraceui/trace/ptrace"
+	"github.com/joonho3020/gotraceui/widget"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/op"
+)
+
+func (cv *Canvas) startRangeSelection(pos f32.Point) {
+	cv.rangeSelection.active = true
+	cv.rangeSelection.clickAt = pos
+}
+
+func (cv *Canvas) abortRangeSelection() {
+	cv.rangeSelection.active = false
+}
+
+func (cv *Canvas) endRangeSelection(win *theme.Window, gtx layout.Context, pos f32.Point) {
+	cv.rangeSelection.active = false
+	start, end := cv.selectionToTs(win, gtx, cv.rangeSelection.clickAt.X, pos.X)
+	if start == end {
+		return
+	}
+	cv.SelectRange(start, end)
+	win.EmitAction(&OpenTimeRangeAction{Start: start, End: end})
+}
+
+// SelectRange highlights the time range between start and end.
+func (cv *Canvas) SelectRange(start, end trace.Timestamp) {
+	cv.selectedRange = container.Some(TimeSpan{start, end})
+}
+
+// ClearSelectedRange undoes the effect of SelectRange.
+func (cv *Canvas) ClearSelectedRange() {
+	cv.selectedRange = container.None[TimeSpan]()
+}
+
+// SelectedRange returns the selected time range, if any.
+func (cv *Canvas) SelectedRange() (TimeSpan, bool) {
+	return cv.selectedRange.Get()
+}
+
+// drawSelectedRange draws the time range that is currently being selected, as well as the selected time range, across
+// all timelines.
+func (cv *Canvas) drawSelectedRange(win *theme.Window, gtx layout.Context) {
+	c := win.Theme.Palette.PrimarySelection
+	c.A = 0.35
+	if cv.rangeSelection.active {
+		one := cv.rangeSelection.clickAt.X
+		two := cv.pointerAt.X
+		rect := clip.FRect{
+			Min: f32.Pt(min(one, two), 0),
+			Max: f32.Pt(max(one, two), float32(gtx.Constraints.Max.Y)),
+		}
+		theme.FillShape(win, gtx.Ops, c, rect.Op(gtx.Ops))
+	}
+
+	r, ok := cv.selectedRange.Get()
+	if !ok {
+		return
+	}
+	startPx, endPx := cv.tsToPx(r.Start), cv.tsToPx(r.End)
+	if endPx < 0 || startPx > float32(gtx.Constraints.Max.X) {
+		return
+	}
+	rect := clip.FRect{
+		Min: f32.Pt(startPx, 0),
+		Max: f32.Pt(endPx, float32(gtx.Constraints.Max.Y)),
+	}
+	theme.FillShape(win, gtx.Ops, c, rect.Op(gtx.Ops))
+
+	// Draw the edges of the range
+	w := float32(gtx.Dp(1))
+	for _, px := range [2]float32{startPx, endPx} {
+		rect := clip.FRect{
+			Min: f32.Pt(px-w/2, 0),
+			Max: f32.Pt(px+w/2, float32(gtx.Constraints.Max.Y)),
+		}
+		theme.FillShape(win, gtx.Ops, win.Theme.Palette.Foreground, rect.Op(gtx.Ops))
+	}
+}
+
+// drawSelectedRange marks the selected time range on the axis and labels it with its duration.
+func (axis *Axis) drawSelectedRange(win *theme.Window, gtx layout.Context, tickHeight int) {
+	r, ok := axis.cv.selectedRange.Get()
+	if !ok {
+		return
+	}
+	startPx, endPx := axis.cv.tsToPx(r.Start), axis.cv.tsToPx(r.End)
+	if endPx < 0 || startPx > float32(gtx.Constraints.Max.X) {
+		return
+	}
+
+	c := win.Theme.Palette.PrimarySelection
+	c.A = 0.35
+	rect := clip.FRect{
+		Min: f32.Pt(startPx, 0),
+		Max: f32.Pt(endPx, float32(gtx.Constraints.Max.Y)),
+	}
+	theme.FillShape(win, gtx.Ops, c, rect.Op(gtx.Ops))
+
+	rec := theme.Record(win, gtx, func(win *theme.Window, gtx layout.Context) layout.Dimensions {
+		ls := theme.LineLabel(win.Theme, "Δ "+roundDuration(r.Duration()).String())
+		ls.Font = font.Font{Weight: font.Bold}
+		return ls.Layout(win, gtx)
+	})
+	// Center the label on the visible portion of the range.
+	visStart := max(startPx, 0)
+	visEnd := min(endPx, float32(gtx.Constraints.Max.X))
+	x := int(round32((visStart+visEnd)/2)) - rec.Dimensions.Size.X/2
+	x = max(0, min(x, gtx.Constraints.Max.X-rec.Dimensions.Size.X))
+	defer op.Offset(image.Pt(x, tickHeight)).Push(gtx.Ops).Pop()
+	theme.FillShape(win, gtx.Ops, win.Theme.Palette.Background, clip.Rect{Max: rec.Dimensions.Size}.Op())
+	rec.Layout(win, gtx)
+}
+
+// timeRangeStatistics computes statistics of the goroutine states in the time range. Spans are clipped to the range.
+func timeRangeStatistics(tr *Trace, start, end trace.Timestamp, cancelled <-chan struct{}) (ptrace.Statistics, bool) {
+	defer rtrace.StartRegion(context.Background(), "main.timeRangeStatistics").End()
+
+	var spans []ptrace.Span
+	for i, g := range tr.Goroutines {
+		if i%1000 == 0 && TryRecv(cancelled) {
+			return ptrace.Statistics{}, false
+		}
+		j := sort.Search(len(g.Spans), func(j int) bool {
+			return g.Spans[j].End > start
+		})
+		for ; j < len(g.Spans) && g.Spans[j].Start < end; j++ {
+			s := g.Spans[j]
+			s.Start = max(s.Start, start)
+			s.End = min(s.End, end)
+			spans = append(spans, s)
+		}
+	}
+	return ptrace.ComputeStatistics(ptrace.ToSpans(spans)), true
+}
+
+// spansInRange returns the spans that overlap the time range. The spans must be sorted and mustn't overlap.
+func spansInRange(spans []ptrace.Span, start, end trace.Timestamp) []ptrace.Span {
+	i := sort.Search(len(spans), func(i int) bool {
+		return spans[i].End > start
+	})
+	j := sort.Search(len(spans), func(j int) bool {
+		return spans[j].Start >= end
+	})
+	if i >= j {
+		return nil
+	}
+	return spans[i:j]
+}
+
+func gcsAndSTWsToCSV(gcs, stws []ptrace.Span) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"Kind", "Start", "End", "Duration"})
+	write := func(kind string, spans []ptrace.Span) {
+		for _, s := range spans {
+			w.Write([]string{
+				kind,
+				fmt.Sprintf("%d", s.Start),
+				fmt.Sprintf("%d", s.End),
+				fmt.Sprintf("%d", s.End-s.Start),
+			})
+		}
+	}
+	write("GC", gcs)
+	write("STW", stws)
+
+	w.Flush()
+	return buf.String()
+}
+
+// TimeRangeComponent is a panel that describes a time range selected on the canvas.
+type TimeRangeComponent struct {
+	trace  *Trace
+	canvas *Canvas
+	mwin   *theme.Window
+	start  trace.Timestamp
+	end    trace.Timestamp
+
+	statistics *theme.Future[*SpansStats]
+	gcs        []ptrace.Span
+	stws       []ptrace.Span
+
+	buttons struct {
+		zoom       widget.PrimaryClickable
+		clear      widget.PrimaryClickable
+		flameGraph widget.PrimaryClickable
+		copyStats  widget.PrimaryClickable
+		copyGCs    widget.PrimaryClickable
+	}
+
+	descriptionText Text
+	prevSpans       []TextSpan
+	gcText          Text
+	gcPrevSpans     []TextSpan
+	gcList          widget.List
+	hoveredLink     ObjectLink
+
+	theme.ComponentButtons
+}
+
+func NewTimeRangeComponent(tr *Trace, mwin *theme.Window, cv *Canvas, start, end trace.Timestamp) *TimeRangeComponent {
+	trc := &TimeRangeComponent{
+		trace:  tr,
+		canvas: cv,
+		mwin:   mwin,
+		start:  start,
+		end:    end,
+		gcs:    spansInRange(tr.GC, start, end),
+		stws:   spansInRange(tr.STW, start, end),
+	}
+	trc.gcList.Axis = layout.Vertical
+	trc.statistics = theme.NewFuture(mwin, func(cancelled <-chan struct{}) *SpansStats {
+		stats, ok := timeRangeStatistics(tr, start, end, cancelled)
+		if !ok {
+			return nil
+		}
+		return NewStats(stats)
+	})
+	return trc
+}
+
+func (trc *TimeRangeComponent) HoveredLink() ObjectLink {
+	return trc.hoveredLink
+}
+
+// Title implements theme.Component.
+func (trc *TimeRangeComponent) Title() string {
+	return local.Sprintf("Time range %s to %s", formatTimestamp(nil, trc.start), formatTimestamp(nil, trc.end))
+}
+
+func (trc *TimeRangeComponent) buildGCs(win *theme.Window) []TextSpan {
+	tb := TextBuilder{Window: win}
+	if len(trc.gcs) == 0 && len(trc.stws) == 0 {
+		tb.Span("There were no garbage collections or stop-the-world pauses in this time range.")
+		return tb.Spans
+	}
+	d := time.Duration(trc.end - trc.start)
+	var gcTotal, stwTotal time.Duration
+	for _, s := range trc.gcs {
+		gcTotal += time.Duration(min(s.End, trc.end) - max(s.Start, trc.start))
+	}
+	for _, s := range trc.stws {
+		stwTotal += time.Duration(min(s.End, trc.end) - max(s.Start, trc.start))
+	}
+	tb.Span(local.Sprintf("GC was active for %s (%.2f%%) and the world was stopped for %s (%.2f%%) of the time range.\n\n",
+		roundDuration(gcTotal), float64(gcTotal)/float64(d)*100, roundDuration(stwTotal), float64(stwTotal)/float64(d)*100))
+
+	write := func(kind string, spans []ptrace.Span) {
+		for _, s := range spans {
+			tb.Span(kind + " at ")
+			tb.DefaultLink(formatTimestamp(nil, s.Start), "Time range", s.Start)
+			tb.Span(local.Sprintf(", lasting %s\n", roundDuration(s.Duration())))
+		}
+	}
+	write("GC", trc.gcs)
+	write("STW", trc.stws)
+	return tb.Spans
+}
+
+// Layout implements theme.Component.
+func (trc *TimeRangeComponent) Layout(win *theme.Window, gtx layout.Context) layout.Dimensions {
+	defer rtrace.StartRegion(context.Background(), "main.TimeRangeComponent.Layout").End()
+
+	for _, ev := range trc.descriptionText.Update(gtx, trc.prevSpans) {
+		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+	}
+	for _, ev := range trc.gcText.Update(gtx, trc.gcPrevSpans) {
+		handleLinkClick(win, ev.Event, ev.Span.ObjectLink)
+	}
+	trc.hoveredLink = trc.descriptionText.HoveredLink()
+	if trc.hoveredLink == nil {
+		trc.hoveredLink = trc.gcText.HoveredLink()
+	}
+
+	for trc.ComponentButtons.Backed(gtx) {
+		trc.mwin.EmitAction(&PrevPanelAction{})
+	}
+	for trc.buttons.zoom.Clicked(gtx) {
+		trc.canvas.navigateToStartAndEnd(gtx, trc.start, trc.end, trc.canvas.y)
+	}
+	for trc.buttons.clear.Clicked(gtx) {
+		if r, ok := trc.canvas.SelectedRange(); ok && r == (TimeSpan{trc.start, trc.end}) {
+			trc.canvas.ClearSelectedRange()
+		}
+		trc.mwin.EmitAction(&PrevPanelAction{})
+	}
+	for trc.buttons.flameGraph.Clicked(gtx) {
+		trc.mwin.EmitAction(&OpenTimeRangeFlameGraphAction{Start: trc.start, End: trc.end})
+	}
+	stats, statsOk := trc.statistics.ResultNoWait()
+	for trc.buttons.copyStats.Clicked(gtx) {
+		if statsOk && stats != nil {
+			win.AppWindow.WriteClipboard(statisticsToCSV(stats.stats.Items))
+		}
+	}
+	for trc.buttons.copyGCs.Clicked(gtx) {
+		win.AppWindow.WriteClipboard(gcsAndSTWsToCSV(trc.gcs, trc.stws))
+	}
+
+	// Inset of 5 pixels on all sides. We can't use layout.Inset because it doesn't decrease the minimum constraint,
+	// which we do care about here.
+	gtx.Constraints.Min = gtx.Constraints.Min.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints.Max = gtx.Constraints.Max.Sub(image.Pt(2*5, 2*5))
+	gtx.Constraints = layout.Normalize(gtx.Constraints)
+	defer op.Offset(image.Pt(5, 5)).Push(gtx.Ops).Pop()
+
+	nothing := func(gtx layout.Context) layout.Dimensions {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+	heading := func(s string) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			l := theme.LineLabel(win.Theme, s)
+			l.Font = font.Font{Weight: font.Bold}
+			return l.Layout(win, gtx)
+		}
+	}
+	button := func(b *widget.PrimaryClickable, label string, enabled bool) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			if !enabled {
+				gtx.Queue = nil
+			}
+			return theme.Button(win.Theme, &b.Clickable, label).Layout(win, gtx)
+		}
+	}
+
+	return layout.Rigids(gtx, layout.Vertical,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Flexed(1, nothing),
+				layout.Rigid(theme.Dumb(win, trc.ComponentButtons.Layout)),
+			)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = image.Point{}
+			tb := TextBuilder{Window: win}
+			tb.Span("Time range from ")
+			tb.DefaultLink(formatTimestamp(nil, trc.start), "Time range", trc.start)
+			tb.Span(" to ")
+			tb.DefaultLink(formatTimestamp(nil, trc.end), "Time range", trc.end)
+			tb.Span(local.Sprintf(", lasting %s", roundDuration(time.Duration(trc.end-trc.start))))
+			trc.descriptionText.Reset(win.Theme)
+			trc.prevSpans = tb.Spans
+			return trc.descriptionText.Layout(win, gtx, trc.prevSpans)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Rigids(gtx, layout.Horizontal,
+				button(&trc.buttons.zoom, "Zoom to range", true),
+				layout.Spacer{Width: 5}.Layout,
+				button(&trc.buttons.clear, "Clear selection", true),
+				layout.Spacer{Width: 5}.Layout,
+				button(&trc.buttons.flameGraph, "CPU flame graph", trc.trace.HasCPUSamples),
+				layout.Spacer{Width: 5}.Layout,
+				button(&trc.buttons.copyStats, "Copy statistics as CSV", statsOk && stats != nil),
+				layout.Spacer{Width: 5}.Layout,
+				button(&trc.buttons.copyGCs, "Copy GCs and STWs as CSV", len(trc.gcs) != 0 || len(trc.stws) != 0),
+			)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		heading("Goroutine states"),
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.Y = 0
+			gtx.Constraints.Max.Y /= 2
+			if statsOk && stats != nil {
+				gtx.Constraints.Min = gtx.Constraints.Max
+				return stats.Layout(win, gtx)
+			}
+			return theme.Label(win.Theme, "Computing statistics…").Layout(win, gtx)
+		},
+
+		layout.Spacer{Height: 10}.Layout,
+		heading("Garbage collections and stop-the-world pauses"),
+		layout.Spacer{Height: 5}.Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return theme.List(win.Theme, &trc.gcList).Layout(win, gtx, 1, func(gtx layout.Context, index int) layout.Dimensions {
+				trc.gcText.Reset(win.Theme)
+				trc.gcPrevSpans = trc.buildGCs(win)
+				return trc.gcText.Layout(win, gtx, trc.gcPrevSpans)
+			})
+		},
+	)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"sync"
+
 	"github.com/joonho3020/gotraceui/trace"
 	"github.com/joonho3020/gotraceui/trace/ptrace"
 )
@@ -30,6 +32,41 @@ type Trace struct {
 
 	allGoroutineSpanLabels [][]string
 	allProcessorSpanLabels [][]string
+
+	// Numeric time series extracted from user logs.
+	userMetrics []ptrace.UserMetric
+	// The string IDs of the keys of userMetrics.
+	userMetricKeys map[uint64]struct{}
+
+	// Computed the first time they're needed, see Migrations.
+	migrationsOnce sync.Once
+	migrations     migrationData
+}
+
+type migrationData struct {
+	// All migrations of goroutines between processors, sorted by time.
+	all []ptrace.Migration
+	// Migration statistics of all goroutines, indexed by Goroutine.SeqID.
+	goroutines []ptrace.GoroutineMigrations
+}
+
+// Migrations returns the migrations of goroutines between processors, computing them on the first call. Computing them
+// takes a while for large traces, so callers should call it from a theme.Future.
+func (t *Trace) Migrations() *migrationData {
+	t.migrationsOnce.Do(func() {
+		t.migrations.all = ptrace.ComputeMigrations(t.Trace)
+		t.migrations.goroutines = ptrace.ComputeGoroutineMigrations(t.Trace, t.migrations.all)
+	})
+	return &t.migrations
+}
+
+// isMetricLog reports whether ev is a user log that is part of a metric.
+func (t *Trace) isMetricLog(ev *trace.Event) bool {
+	if _, ok := t.userMetricKeys[ev.Args[trace.ArgUserLogKeyID]]; !ok {
+		return false
+	}
+	_, ok := ptrace.ParseMetricValue(t.Strings[ev.Args[trace.ArgUserLogMessage]])
+	return ok
 }
 
 func (t *Trace) goroutineSpanLabels(g *ptrace.Goroutine) []string {
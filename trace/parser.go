@@ -1547,6 +1547,7 @@ const (
 	ArgGoCreateStack        = 1
 	ArgGoStartLabelLabelID  = 2
 	ArgGoUnblockG           = 0
+	ArgGomaxprocsValue      = 0
 	ArgUserLogKeyID         = 1
 	ArgUserLogMessage       = 3
 	ArgUserRegionMode       = 1
@@ -0,0 +1,278 @@
+package ptrace
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+// GCCycle describes a single garbage collection cycle.
+type GCCycle struct {
+	// Index into Trace.GC.
+	Index int
+	Start trace.Timestamp
+	End   trace.Timestamp
+
+	// Indices into Trace.STW of the sweep termination and mark termination pauses, or -1 if the trace doesn't contain
+	// the pause.
+	SweepTermination int
+	MarkTermination  int
+
+	// Total time goroutines spent assisting the GC during this cycle. Assists that extend beyond the cycle are clipped
+	// to it.
+	MarkAssist time.Duration
+	// The goroutines that assisted the GC, sorted by the time they spent assisting, in descending order.
+	Assists []GCAssist
+
+	// The heap size at the start and at the end of the cycle, and the heap goal in effect when the cycle started.
+	HeapBefore uint64
+	HeapAfter  uint64
+	HeapGoal   uint64
+}
+
+func (c *GCCycle) Duration() time.Duration {
+	return time.Duration(c.End - c.Start)
+}
+
+type GCAssist struct {
+	Goroutine *Goroutine
+	Duration  time.Duration
+}
+
+// pointAt returns the value of the last point at or before ts, or zero if there is no such point.
+func pointAt(points []Point, ts trace.Timestamp) uint64 {
+	idx := sort.Search(len(points), func(i int) bool {
+		return points[i].When > ts
+	})
+	if idx == 0 {
+		return 0
+	}
+	return points[idx-1].Value
+}
+
+// ComputeGCCycles computes per-cycle information for all GC cycles in the trace.
+func ComputeGCCycles(tr *Trace) []GCCycle {
+	cycles := make([]GCCycle, len(tr.GC))
+	for i := range tr.GC {
+		span := &tr.GC[i]
+		c := &cycles[i]
+		c.Index = i
+		c.Start = span.Start
+		c.End = span.End
+		if c.End == 0 {
+			// The trace ended during the cycle.
+			c.End = tr.End()
+		}
+		c.SweepTermination = -1
+		c.MarkTermination = -1
+		c.HeapBefore = pointAt(tr.HeapSize, c.Start)
+		c.HeapAfter = pointAt(tr.HeapSize, c.End)
+		c.HeapGoal = pointAt(tr.HeapGoal, c.Start)
+	}
+
+	// cycleAt returns the index of the cycle that is active at ts, or -1.
+	cycleAt := func(ts trace.Timestamp) int {
+		idx := sort.Search(len(cycles), func(i int) bool {
+			return cycles[i].Start > ts
+		}) - 1
+		if idx < 0 || ts > cycles[idx].End {
+			return -1
+		}
+		return idx
+	}
+
+	for i := range tr.STW {
+		stw := &tr.STW[i]
+		// Depending on the Go version, the sweep termination pause may begin before the cycle's start event, and the
+		// mark termination pause usually ends after the cycle's end event.
+		ci := cycleAt(stw.Start)
+		if ci == -1 && stw.End != 0 {
+			ci = cycleAt(stw.End)
+		}
+		if ci == -1 {
+			continue
+		}
+		c := &cycles[ci]
+		switch tr.STWReason(tr.Event(stw.Event).Args[trace.ArgSTWStartKind]) {
+		case trace.STWGCSweepTermination:
+			c.SweepTermination = i
+		case trace.STWGCMarkTermination:
+			c.MarkTermination = i
+		case trace.STWUnknown:
+			// We don't know the reasons for newer trace versions. Assume that the pauses are the ones belonging to
+			// the cycle, in order.
+			if c.SweepTermination == -1 && c.MarkTermination == -1 {
+				c.SweepTermination = i
+			} else if c.MarkTermination == -1 {
+				c.MarkTermination = i
+			}
+		}
+	}
+
+	assists := make([]map[*Goroutine]time.Duration, len(cycles))
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			if s.State != StateGCMarkAssist {
+				continue
+			}
+			// Attribute the assist to every cycle it overlaps, clipped to the cycle, the same way ComputeGCAssists
+			// clips spans to its range.
+			ci := sort.Search(len(cycles), func(i int) bool {
+				return cycles[i].End > s.Start
+			})
+			for ; ci < len(cycles) && cycles[ci].Start < s.End; ci++ {
+				c := &cycles[ci]
+				d := time.Duration(min(s.End, c.End) - max(s.Start, c.Start))
+				c.MarkAssist += d
+				if assists[ci] == nil {
+					assists[ci] = map[*Goroutine]time.Duration{}
+				}
+				assists[ci][g] += d
+			}
+		}
+	}
+	for i, m := range assists {
+		c := &cycles[i]
+		c.Assists = make([]GCAssist, 0, len(m))
+		for g, d := range m {
+			c.Assists = append(c.Assists, GCAssist{Goroutine: g, Duration: d})
+		}
+		sort.Slice(c.Assists, func(i, j int) bool {
+			a, b := c.Assists[i], c.Assists[j]
+			if a.Duration != b.Duration {
+				return a.Duration > b.Duration
+			}
+			return a.Goroutine.ID < b.Goroutine.ID
+		})
+	}
+
+	return cycles
+}
+
+// GCAssistGroup aggregates the time goroutines spent assisting the GC or being blocked on it, grouped either by the
+// goroutines' functions or by the stacks at which they assisted.
+type GCAssistGroup struct {
+	// The function of the goroutines. Only set for groups grouped by function.
+	Function *Function
+	// The stack of the assists. Only set for groups grouped by stack.
+	Stack uint32
+
+	// The number of spans of assisting or being blocked on the GC.
+	Count int
+	// Time spent in StateGCMarkAssist.
+	Assist time.Duration
+	// Time spent in StateBlockedGC.
+	BlockedGC time.Duration
+}
+
+func (g *GCAssistGroup) Total() time.Duration {
+	return g.Assist + g.BlockedGC
+}
+
+// ComputeGCAssists aggregates the time goroutines spent assisting the GC or being blocked on it between start and end,
+// grouped by the goroutines' functions and by the stacks at which the goroutines assisted. Spans that extend beyond the
+// range are clipped to it. The groups are sorted by total time, in descending order.
+func ComputeGCAssists(tr *Trace, start, end trace.Timestamp) (byFunction, byStack []GCAssistGroup) {
+	fnIdx := map[*Function]int{}
+	stkIdx := map[uint32]int{}
+
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			if s.State != StateGCMarkAssist && s.State != StateBlockedGC {
+				continue
+			}
+			if s.End <= start || s.Start >= end {
+				continue
+			}
+			d := time.Duration(min(s.End, end) - max(s.Start, start))
+
+			add := func(group *GCAssistGroup) {
+				group.Count++
+				if s.State == StateGCMarkAssist {
+					group.Assist += d
+				} else {
+					group.BlockedGC += d
+				}
+			}
+
+			idx, ok := fnIdx[g.Function]
+			if !ok {
+				idx = len(byFunction)
+				fnIdx[g.Function] = idx
+				byFunction = append(byFunction, GCAssistGroup{Function: g.Function})
+			}
+			add(&byFunction[idx])
+
+			stk := tr.Event(s.Event).StkID
+			idx, ok = stkIdx[stk]
+			if !ok {
+				idx = len(byStack)
+				stkIdx[stk] = idx
+				byStack = append(byStack, GCAssistGroup{Stack: stk})
+			}
+			add(&byStack[idx])
+		}
+	}
+
+	sortGroups := func(groups []GCAssistGroup) {
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groups[i].Total() > groups[j].Total()
+		})
+	}
+	sortGroups(byFunction)
+	sortGroups(byStack)
+
+	return byFunction, byStack
+}
+
+// GCTriggers returns the heap size at the start of each GC cycle.
+func GCTriggers(tr *Trace) []Point {
+	out := make([]Point, 0, len(tr.GC))
+	for i := range tr.GC {
+		ts := tr.GC[i].Start
+		out = append(out, Point{When: ts, Value: pointAt(tr.HeapSize, ts)})
+	}
+	return out
+}
+
+// AllocationRates returns the average allocation rate, in bytes per second, between the end of each GC cycle and the
+// start of the next one. The first interval begins at the start of the trace.
+func AllocationRates(tr *Trace) []Point {
+	var out []Point
+	var prevEnd trace.Timestamp
+	for i := range tr.GC {
+		gc := &tr.GC[i]
+		if gc.Start > prevEnd {
+			before := pointAt(tr.HeapSize, prevEnd)
+			after := pointAt(tr.HeapSize, gc.Start)
+			var rate uint64
+			if after > before {
+				rate = uint64(float64(after-before) / time.Duration(gc.Start-prevEnd).Seconds())
+			}
+			out = append(out, Point{When: prevEnd, Value: rate})
+		}
+		if gc.End == 0 {
+			// The trace ended during the cycle.
+			return out
+		}
+		prevEnd = gc.End
+	}
+	return out
+}
+
+// HeapGoalRatios returns the heap size as a percentage of the heap goal in effect at the time, for every change in
+// heap size.
+func HeapGoalRatios(tr *Trace) []Point {
+	out := make([]Point, 0, len(tr.HeapSize))
+	for _, pt := range tr.HeapSize {
+		goal := pointAt(tr.HeapGoal, pt.When)
+		if goal == 0 {
+			continue
+		}
+		out = append(out, Point{When: pt.When, Value: pt.Value * 100 / goal})
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package ptrace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+func TestComputeGCCycles(t *testing.T) {
+	g1 := &Goroutine{ID: 1, Spans: []Span{
+		// Begins before the first cycle.
+		{Start: 90, End: 120, State: StateGCMarkAssist},
+		{Start: 120, End: 150, State: StateActive},
+		{Start: 150, End: 160, State: StateGCMarkAssist},
+	}}
+	g2 := &Goroutine{ID: 2, Spans: []Span{
+		// Spans both cycles.
+		{Start: 190, End: 310, State: StateGCMarkAssist},
+	}}
+	tr := &Trace{
+		Trace: trace.Trace{
+			Version: 1021,
+			Events: []trace.Event{
+				{Ts: 95, Args: [4]uint64{trace.ArgSTWStartKind: uint64(trace.STWGCSweepTermination)}},
+				{Ts: 180, Args: [4]uint64{trace.ArgSTWStartKind: uint64(trace.STWGCMarkTermination)}},
+				{Ts: 290, Args: [4]uint64{trace.ArgSTWStartKind: uint64(trace.STWGCSweepTermination)}},
+				{Ts: 400},
+			},
+		},
+		Goroutines: []*Goroutine{g1, g2},
+		// The second cycle is still running when the trace ends.
+		GC: []Span{{Start: 100, End: 200}, {Start: 300, End: 0}},
+		STW: []Span{
+			// Begins before the cycle's start event.
+			{Start: 95, End: 105, Event: 0},
+			// Ends after the cycle's end event.
+			{Start: 180, End: 210, Event: 1},
+			{Start: 290, End: 310, Event: 2},
+		},
+		HeapSize: []Point{{0, 10}, {150, 50}, {200, 20}, {350, 80}},
+		HeapGoal: []Point{{0, 40}, {250, 60}},
+	}
+
+	cycles := ComputeGCCycles(tr)
+	if len(cycles) != 2 {
+		t.Fatalf("got %d cycles, want 2", len(cycles))
+	}
+
+	for i, test := range []struct {
+		start, end                        trace.Timestamp
+		sweepTermination, markTermination int
+		heapBefore, heapAfter, heapGoal   uint64
+		markAssist                        time.Duration
+		assists                           []GCAssist
+	}{
+		{100, 200, 0, 1, 10, 20, 40, 40, []GCAssist{{g1, 30}, {g2, 10}}},
+		{300, 400, 2, -1, 20, 80, 60, 10, []GCAssist{{g2, 10}}},
+	} {
+		c := &cycles[i]
+		if c.Start != test.start || c.End != test.end {
+			t.Errorf("cycle %d: got range [%d, %d], want [%d, %d]", c.Index, c.Start, c.End, test.start, test.end)
+		}
+		if c.SweepTermination != test.sweepTermination || c.MarkTermination != test.markTermination {
+			t.Errorf("cycle %d: got pauses (%d, %d), want (%d, %d)",
+				c.Index, c.SweepTermination, c.MarkTermination, test.sweepTermination, test.markTermination)
+		}
+		if c.HeapBefore != test.heapBefore || c.HeapAfter != test.heapAfter || c.HeapGoal != test.heapGoal {
+			t.Errorf("cycle %d: got heap (%d, %d, %d), want (%d, %d, %d)",
+				c.Index, c.HeapBefore, c.HeapAfter, c.HeapGoal, test.heapBefore, test.heapAfter, test.heapGoal)
+		}
+		if c.MarkAssist != test.markAssist {
+			t.Errorf("cycle %d: got %s of mark assist, want %s", c.Index, c.MarkAssist, test.markAssist)
+		}
+		if len(c.Assists) != len(test.assists) {
+			t.Errorf("cycle %d: got %d assisting goroutines, want %d", c.Index, len(c.Assists), len(test.assists))
+		} else {
+			for i, a := range c.Assists {
+				if a != test.assists[i] {
+					t.Errorf("cycle %d: assist %d: got (g%d, %s), want (g%d, %s)",
+						c.Index, i, a.Goroutine.ID, a.Duration, test.assists[i].Goroutine.ID, test.assists[i].Duration)
+				}
+			}
+		}
+
+		// The per-cycle assist time has to agree with ComputeGCAssists for the same range.
+		byFunction, _ := ComputeGCAssists(tr, c.Start, c.End)
+		var total time.Duration
+		for _, group := range byFunction {
+			total += group.Assist
+		}
+		if total != c.MarkAssist {
+			t.Errorf("cycle %d: ComputeGCAssists reports %s of mark assist, ComputeGCCycles %s", c.Index, total, c.MarkAssist)
+		}
+	}
+}
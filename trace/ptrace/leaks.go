@@ -0,0 +1,101 @@
+package ptrace
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// PossibleLeak describes a group of goroutines that were created at the same location, blocked at the same location,
+// and were still blocked when the trace ended.
+type PossibleLeak struct {
+	// The stack of the go statement that created the goroutines. This is zero if the goroutines were created before
+	// the trace started.
+	CreationStack uint32
+	// The stack at which the goroutines blocked.
+	BlockingStack uint32
+	// The state the goroutines were blocked in.
+	State      SchedulingState
+	Goroutines []*Goroutine
+
+	// How long the goroutines have been blocked for, as of the end of the trace.
+	MinBlocked   time.Duration
+	MaxBlocked   time.Duration
+	TotalBlocked time.Duration
+}
+
+type possibleLeakKey struct {
+	creationStack uint32
+	blockingStack uint32
+	state         SchedulingState
+}
+
+// IsBlocked reports whether state is one of the states of a goroutine that is waiting for something other than the
+// scheduler.
+func IsBlocked(state SchedulingState) bool {
+	switch state {
+	case StateBlocked, StateBlockedSend, StateBlockedRecv, StateBlockedSelect, StateBlockedSync,
+		StateBlockedSyncOnce, StateBlockedSyncTriggeringGC, StateBlockedCond, StateBlockedNet, StateBlockedGC,
+		StateBlockedSyscall:
+		return true
+	default:
+		return false
+	}
+}
+
+// ComputePossibleLeaks finds goroutines that didn't end before the trace did and whose final span is a blocked one.
+// Goroutines for which exclude returns true are ignored. exclude may be nil. The returned groups are sorted by the
+// number of goroutines, in descending order.
+func ComputePossibleLeaks(tr *Trace, exclude func(g *Goroutine) bool) []PossibleLeak {
+	groups := map[possibleLeakKey]int{}
+	var out []PossibleLeak
+
+	for _, g := range tr.Goroutines {
+		if g.End.Set() || len(g.Spans) == 0 {
+			continue
+		}
+		last := &g.Spans[len(g.Spans)-1]
+		if !IsBlocked(last.State) {
+			continue
+		}
+		if exclude != nil && exclude(g) {
+			continue
+		}
+
+		var key possibleLeakKey
+		if first := &g.Spans[0]; first.State == StateCreated {
+			key.creationStack = tr.Event(first.Event).StkID
+		}
+		key.blockingStack = tr.Event(last.Event).StkID
+		key.state = last.State
+
+		idx, ok := groups[key]
+		if !ok {
+			idx = len(out)
+			groups[key] = idx
+			out = append(out, PossibleLeak{
+				CreationStack: key.creationStack,
+				BlockingStack: key.blockingStack,
+				State:         key.state,
+				MinBlocked:    math.MaxInt64,
+			})
+		}
+
+		leak := &out[idx]
+		d := time.Duration(tr.End() - last.Start)
+		leak.Goroutines = append(leak.Goroutines, g)
+		leak.TotalBlocked += d
+		if d < leak.MinBlocked {
+			leak.MinBlocked = d
+		}
+		if d > leak.MaxBlocked {
+			leak.MaxBlocked = d
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return len(out[i].Goroutines) > len(out[j].Goroutines)
+	})
+
+	return out
+}
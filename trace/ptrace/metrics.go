@@ -0,0 +1,115 @@
+package ptrace
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+// GoroutinesInSyscalls returns the number of goroutines blocked in syscalls over time. Each such goroutine occupies an
+// OS thread, making this an approximation of the number of threads in syscalls.
+func GoroutinesInSyscalls(tr *Trace) []Point {
+	type change struct {
+		when  trace.Timestamp
+		delta int
+	}
+	var changes []change
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			if s.State != StateBlockedSyscall {
+				continue
+			}
+			changes = append(changes, change{s.Start, 1}, change{s.End, -1})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].when < changes[j].when
+	})
+
+	out := make([]Point, 0, len(changes))
+	var cur int
+	for _, c := range changes {
+		cur += c.delta
+		if n := len(out); n > 0 && out[n-1].When == c.when {
+			// Merge simultaneous changes into a single point.
+			out[n-1].Value = uint64(cur)
+		} else {
+			out = append(out, Point{When: c.when, Value: uint64(cur)})
+		}
+	}
+	return out
+}
+
+// UserMetric is a time series of numbers logged with runtime/trace.Log under a single key.
+type UserMetric struct {
+	Key    string
+	Points []Point
+}
+
+// ParseMetricValue parses the message of a user log as a metric value. Only non-negative numbers are supported, and
+// fractional values are rounded to the nearest integer.
+func ParseMetricValue(msg string) (uint64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(msg), 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, false
+	}
+	return uint64(math.Round(f)), true
+}
+
+// UserMetrics extracts numeric time series from user logs. A key is treated as a metric if all of its messages are
+// numbers, or if it starts with one of prefixes, in which case messages that aren't numbers are skipped. The metrics
+// are sorted by key.
+func UserMetrics(tr *Trace, prefixes []string) []UserMetric {
+	type metric struct {
+		points []Point
+		// Whether all messages so far have been numbers.
+		numeric bool
+	}
+	byKey := map[uint64]*metric{}
+	for i := range tr.Events {
+		ev := &tr.Events[i]
+		if ev.Type != trace.EvUserLog {
+			continue
+		}
+		keyID := ev.Args[trace.ArgUserLogKeyID]
+		if tr.Strings[keyID] == "" {
+			continue
+		}
+		m, ok := byKey[keyID]
+		if !ok {
+			m = &metric{numeric: true}
+			byKey[keyID] = m
+		}
+		if v, ok := ParseMetricValue(tr.Strings[ev.Args[trace.ArgUserLogMessage]]); ok {
+			m.points = append(m.points, Point{When: ev.Ts, Value: v})
+		} else {
+			m.numeric = false
+		}
+	}
+
+	hasPrefix := func(key string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var out []UserMetric
+	for keyID, m := range byKey {
+		key := tr.Strings[keyID]
+		if len(m.points) == 0 || (!m.numeric && !hasPrefix(key)) {
+			continue
+		}
+		out = append(out, UserMetric{Key: key, Points: m.points})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
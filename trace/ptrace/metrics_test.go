@@ -0,0 +1,106 @@
+package ptrace
+
+import (
+	"testing"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+func TestParseMetricValue(t *testing.T) {
+	for _, test := range []struct {
+		msg  string
+		want uint64
+		ok   bool
+	}{
+		{"0", 0, true},
+		{"42", 42, true},
+		{" 42\n", 42, true},
+		{"1.4", 1, true},
+		{"1.5", 2, true},
+		{"1e3", 1000, true},
+		{"-1", 0, false},
+		{"+Inf", 0, false},
+		{"NaN", 0, false},
+		{"", 0, false},
+		{"42 requests", 0, false},
+	} {
+		got, ok := ParseMetricValue(test.msg)
+		if got != test.want || ok != test.ok {
+			t.Errorf("ParseMetricValue(%q) = (%d, %t), want (%d, %t)", test.msg, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestUserMetrics(t *testing.T) {
+	strs := map[uint64]string{
+		1:  "queue.len",
+		2:  "db.latency",
+		3:  "status",
+		4:  "db.conns",
+		10: "3",
+		11: "5",
+		12: "starting",
+		13: "7.6",
+		14: "n/a",
+	}
+	log := func(ts trace.Timestamp, key, msg uint64) trace.Event {
+		ev := trace.Event{Ts: ts, Type: trace.EvUserLog}
+		ev.Args[trace.ArgUserLogKeyID] = key
+		ev.Args[trace.ArgUserLogMessage] = msg
+		return ev
+	}
+	tr := &Trace{Trace: trace.Trace{
+		Strings: strs,
+		Events: []trace.Event{
+			log(100, 1, 10),
+			// A log without a key.
+			log(150, 0, 10),
+			log(200, 2, 13),
+			log(300, 3, 12),
+			log(400, 1, 11),
+			log(500, 3, 10),
+			log(600, 2, 14),
+			log(700, 4, 14),
+		},
+	}}
+
+	for _, test := range []struct {
+		prefixes []string
+		want     []UserMetric
+	}{
+		{
+			// Only keys whose messages are all numbers.
+			prefixes: nil,
+			want: []UserMetric{
+				{Key: "queue.len", Points: []Point{{100, 3}, {400, 5}}},
+			},
+		},
+		{
+			// Prefixes allow keys with other messages, but keys without a single number are still skipped.
+			prefixes: []string{"db.", "status"},
+			want: []UserMetric{
+				{Key: "db.latency", Points: []Point{{200, 8}}},
+				{Key: "queue.len", Points: []Point{{100, 3}, {400, 5}}},
+				{Key: "status", Points: []Point{{500, 3}}},
+			},
+		},
+	} {
+		got := UserMetrics(tr, test.prefixes)
+		if len(got) != len(test.want) {
+			t.Errorf("prefixes %q: got %d metrics, want %d: %v", test.prefixes, len(got), len(test.want), got)
+			continue
+		}
+		for i, m := range got {
+			want := test.want[i]
+			if m.Key != want.Key || len(m.Points) != len(want.Points) {
+				t.Errorf("prefixes %q: metric %d: got %v, want %v", test.prefixes, i, m, want)
+				continue
+			}
+			for j, pt := range m.Points {
+				if pt != want.Points[j] {
+					t.Errorf("prefixes %q: metric %q: point %d: got %v, want %v", test.prefixes, m.Key, j, pt, want.Points[j])
+				}
+			}
+		}
+	}
+}
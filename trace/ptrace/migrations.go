@@ -0,0 +1,221 @@
+package ptrace
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+// Migration records a goroutine running on a different processor than the one it previously ran on.
+type Migration struct {
+	Goroutine *Goroutine
+	From      *Processor
+	To        *Processor
+	// The start of the goroutine's first run on the new processor.
+	When trace.Timestamp
+}
+
+// ProcessorRun describes a goroutine running on a processor.
+type ProcessorRun struct {
+	// The goroutine's ID.
+	Goroutine uint64
+	Processor *Processor
+	Start     trace.Timestamp
+	End       trace.Timestamp
+}
+
+// processorRuns returns the runs of all goroutines on all processors for which keep returns true, sorted by start time.
+func processorRuns(tr *Trace, keep func(gid uint64) bool) []ProcessorRun {
+	var runs []ProcessorRun
+	for _, p := range tr.Processors {
+		for i := range p.Spans {
+			s := &p.Spans[i]
+			gid := tr.Event(s.Event).G
+			if keep != nil && !keep(gid) {
+				continue
+			}
+			runs = append(runs, ProcessorRun{Goroutine: gid, Processor: p, Start: s.Start, End: s.End})
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Start < runs[j].Start
+	})
+	return runs
+}
+
+// GoroutineProcessorRuns returns the runs of g on processors, sorted by time.
+func GoroutineProcessorRuns(tr *Trace, g *Goroutine) []ProcessorRun {
+	return processorRuns(tr, func(gid uint64) bool { return gid == g.ID })
+}
+
+// ComputeMigrations returns all migrations in the trace, sorted by time.
+func ComputeMigrations(tr *Trace) []Migration {
+	var out []Migration
+	lastP := map[uint64]*Processor{}
+	for _, r := range processorRuns(tr, nil) {
+		if prev, ok := lastP[r.Goroutine]; ok && prev != r.Processor {
+			out = append(out, Migration{
+				Goroutine: tr.G(r.Goroutine),
+				From:      prev,
+				To:        r.Processor,
+				When:      r.Start,
+			})
+		}
+		lastP[r.Goroutine] = r.Processor
+	}
+	return out
+}
+
+// GoroutineMigrations describes how a goroutine moved between processors.
+type GoroutineMigrations struct {
+	Goroutine *Goroutine
+	// The number of times the goroutine ran on a processor.
+	Runs       int
+	Migrations int
+	// The number of distinct processors the goroutine ran on.
+	Processors int
+	// The share of the goroutine's running time that it spent on the processor it ran on the most, in the range [0,
+	// 1]. A goroutine that always ran on the same processor has an affinity of 1.
+	Affinity float64
+}
+
+// ComputeGoroutineMigrations computes migration statistics for all goroutines, indexed by Goroutine.SeqID.
+// migrations must be the result of ComputeMigrations.
+func ComputeGoroutineMigrations(tr *Trace, migrations []Migration) []GoroutineMigrations {
+	type processorTime struct {
+		p *Processor
+		d time.Duration
+	}
+
+	out := make([]GoroutineMigrations, len(tr.Goroutines))
+	// Most goroutines run on few processors, so a slice is cheaper than a map.
+	times := make([][]processorTime, len(tr.Goroutines))
+	for i, g := range tr.Goroutines {
+		out[i].Goroutine = g
+	}
+	for _, p := range tr.Processors {
+		for i := range p.Spans {
+			s := &p.Spans[i]
+			g := tr.G(tr.Event(s.Event).G)
+			out[g.SeqID].Runs++
+			pts := times[g.SeqID]
+			j := 0
+			for j < len(pts) && pts[j].p != p {
+				j++
+			}
+			if j == len(pts) {
+				pts = append(pts, processorTime{p: p})
+				times[g.SeqID] = pts
+			}
+			pts[j].d += s.Duration()
+		}
+	}
+	for _, m := range migrations {
+		out[m.Goroutine.SeqID].Migrations++
+	}
+	for i, pts := range times {
+		var total, most time.Duration
+		for _, pt := range pts {
+			total += pt.d
+			most = max(most, pt.d)
+		}
+		out[i].Processors = len(pts)
+		if total > 0 {
+			out[i].Affinity = float64(most) / float64(total)
+		} else if len(pts) > 0 {
+			out[i].Affinity = 1
+		}
+	}
+	return out
+}
+
+// FunctionMigrations aggregates the migrations of all goroutines that started in the same function.
+type FunctionMigrations struct {
+	Function   *Function
+	Goroutines int
+	Runs       int
+	Migrations int
+}
+
+// ComputeFunctionMigrations groups goroutine migrations by the goroutines' functions. Goroutines that never ran are
+// skipped. The groups are sorted by the number of migrations, in descending order.
+func ComputeFunctionMigrations(gms []GoroutineMigrations) []FunctionMigrations {
+	var out []FunctionMigrations
+	idx := map[*Function]int{}
+	for i := range gms {
+		gm := &gms[i]
+		if gm.Runs == 0 {
+			continue
+		}
+		j, ok := idx[gm.Goroutine.Function]
+		if !ok {
+			j = len(out)
+			idx[gm.Goroutine.Function] = j
+			out = append(out, FunctionMigrations{Function: gm.Goroutine.Function})
+		}
+		out[j].Goroutines++
+		out[j].Runs += gm.Runs
+		out[j].Migrations += gm.Migrations
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Migrations > out[j].Migrations
+	})
+	return out
+}
+
+// ProcessorGoroutine describes the time a goroutine spent running on a processor.
+type ProcessorGoroutine struct {
+	Goroutine *Goroutine
+	// The number of times the goroutine ran on the processor.
+	Runs int
+	Time time.Duration
+}
+
+type ProcessorStatistics struct {
+	// The goroutines that ran on the processor, sorted by time spent running, in descending order.
+	Goroutines []ProcessorGoroutine
+	// The number of times the processor switched from running one goroutine to running a different one.
+	Switches int
+	// The number of goroutines that migrated to or away from the processor.
+	MigrationsIn  int
+	MigrationsOut int
+}
+
+// ComputeProcessorStatistics computes statistics about the goroutines that ran on p. migrations must be the result of
+// ComputeMigrations.
+func ComputeProcessorStatistics(tr *Trace, p *Processor, migrations []Migration) ProcessorStatistics {
+	var stats ProcessorStatistics
+	idx := map[uint64]int{}
+	var prevG uint64
+	for i := range p.Spans {
+		s := &p.Spans[i]
+		gid := tr.Event(s.Event).G
+		if i > 0 && gid != prevG {
+			stats.Switches++
+		}
+		prevG = gid
+
+		j, ok := idx[gid]
+		if !ok {
+			j = len(stats.Goroutines)
+			idx[gid] = j
+			stats.Goroutines = append(stats.Goroutines, ProcessorGoroutine{Goroutine: tr.G(gid)})
+		}
+		stats.Goroutines[j].Runs++
+		stats.Goroutines[j].Time += s.Duration()
+	}
+	sort.SliceStable(stats.Goroutines, func(i, j int) bool {
+		return stats.Goroutines[i].Time > stats.Goroutines[j].Time
+	})
+
+	for _, m := range migrations {
+		if m.To == p {
+			stats.MigrationsIn++
+		}
+		if m.From == p {
+			stats.MigrationsOut++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,157 @@
+package ptrace
+
+import (
+	"testing"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+// migrationTrace returns a trace with two processors, two goroutines that migrate between them, and a goroutine that
+// never runs.
+//
+//	p0: g1 [0, 100), g2 [100, 150), g1 [300, 400), g1 [400, 450)
+//	p1: g1 [150, 250), g2 [250, 260)
+func migrationTrace() *Trace {
+	fn := &Function{Frame: trace.Frame{Fn: "main.worker"}}
+	g1 := &Goroutine{ID: 1, SeqID: 0, Function: fn}
+	g2 := &Goroutine{ID: 2, SeqID: 1, Function: fn}
+	g3 := &Goroutine{ID: 3, SeqID: 2, Function: &Function{Frame: trace.Frame{Fn: "main.idle"}}}
+
+	tr := &Trace{
+		Goroutines: []*Goroutine{g1, g2, g3},
+		gsByID:     map[uint64]*Goroutine{1: g1, 2: g2, 3: g3},
+	}
+	run := func(g *Goroutine, start, end trace.Timestamp) Span {
+		tr.Events = append(tr.Events, trace.Event{Ts: start, G: g.ID})
+		return Span{Start: start, End: end, Event: EventID(len(tr.Events) - 1), State: StateActive}
+	}
+	p0 := &Processor{ID: 0, SeqID: 0}
+	p1 := &Processor{ID: 1, SeqID: 1}
+	p0.Spans = []Span{run(g1, 0, 100), run(g2, 100, 150)}
+	p1.Spans = []Span{run(g1, 150, 250), run(g2, 250, 260)}
+	p0.Spans = append(p0.Spans, run(g1, 300, 400), run(g1, 400, 450))
+	tr.Processors = []*Processor{p0, p1}
+	return tr
+}
+
+func TestComputeMigrations(t *testing.T) {
+	tr := migrationTrace()
+	g1, g2 := tr.Goroutines[0], tr.Goroutines[1]
+	p0, p1 := tr.Processors[0], tr.Processors[1]
+
+	want := []Migration{
+		{Goroutine: g1, From: p0, To: p1, When: 150},
+		{Goroutine: g2, From: p0, To: p1, When: 250},
+		{Goroutine: g1, From: p1, To: p0, When: 300},
+	}
+	got := ComputeMigrations(tr)
+	if len(got) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(got), len(want))
+	}
+	for i, m := range got {
+		if m != want[i] {
+			t.Errorf("migration %d: got g%d from p%d to p%d at %d, want g%d from p%d to p%d at %d", i,
+				m.Goroutine.ID, m.From.ID, m.To.ID, m.When,
+				want[i].Goroutine.ID, want[i].From.ID, want[i].To.ID, want[i].When)
+		}
+	}
+}
+
+func TestComputeProcessorStatistics(t *testing.T) {
+	tr := migrationTrace()
+	g1, g2 := tr.Goroutines[0], tr.Goroutines[1]
+	migrations := ComputeMigrations(tr)
+
+	for _, test := range []struct {
+		p                           *Processor
+		goroutines                  []ProcessorGoroutine
+		switches                    int
+		migrationsIn, migrationsOut int
+	}{
+		{
+			p: tr.Processors[0],
+			goroutines: []ProcessorGoroutine{
+				{Goroutine: g1, Runs: 3, Time: 250},
+				{Goroutine: g2, Runs: 1, Time: 50},
+			},
+			// Consecutive runs of the same goroutine aren't switches.
+			switches:      2,
+			migrationsIn:  1,
+			migrationsOut: 2,
+		},
+		{
+			p: tr.Processors[1],
+			goroutines: []ProcessorGoroutine{
+				{Goroutine: g1, Runs: 1, Time: 100},
+				{Goroutine: g2, Runs: 1, Time: 10},
+			},
+			switches:      1,
+			migrationsIn:  2,
+			migrationsOut: 1,
+		},
+	} {
+		stats := ComputeProcessorStatistics(tr, test.p, migrations)
+		if stats.Switches != test.switches {
+			t.Errorf("p%d: got %d switches, want %d", test.p.ID, stats.Switches, test.switches)
+		}
+		if stats.MigrationsIn != test.migrationsIn || stats.MigrationsOut != test.migrationsOut {
+			t.Errorf("p%d: got %d/%d migrations in/out, want %d/%d",
+				test.p.ID, stats.MigrationsIn, stats.MigrationsOut, test.migrationsIn, test.migrationsOut)
+		}
+		if len(stats.Goroutines) != len(test.goroutines) {
+			t.Errorf("p%d: got %d goroutines, want %d", test.p.ID, len(stats.Goroutines), len(test.goroutines))
+			continue
+		}
+		for i, pg := range stats.Goroutines {
+			if pg != test.goroutines[i] {
+				t.Errorf("p%d: goroutine %d: got (g%d, %d runs, %s), want (g%d, %d runs, %s)", test.p.ID, i,
+					pg.Goroutine.ID, pg.Runs, pg.Time,
+					test.goroutines[i].Goroutine.ID, test.goroutines[i].Runs, test.goroutines[i].Time)
+			}
+		}
+	}
+}
+
+func TestComputeGoroutineMigrations(t *testing.T) {
+	tr := migrationTrace()
+	gms := ComputeGoroutineMigrations(tr, ComputeMigrations(tr))
+
+	want := []GoroutineMigrations{
+		{Goroutine: tr.Goroutines[0], Runs: 4, Migrations: 2, Processors: 2, Affinity: 250.0 / 350.0},
+		{Goroutine: tr.Goroutines[1], Runs: 2, Migrations: 1, Processors: 2, Affinity: 50.0 / 60.0},
+		// Goroutines that never ran have no affinity.
+		{Goroutine: tr.Goroutines[2]},
+	}
+	if len(gms) != len(want) {
+		t.Fatalf("got %d goroutines, want %d", len(gms), len(want))
+	}
+	for i, gm := range gms {
+		if gm != want[i] {
+			t.Errorf("goroutine %d: got %+v, want %+v", i, gm, want[i])
+		}
+	}
+
+	fms := ComputeFunctionMigrations(gms)
+	if len(fms) != 1 {
+		t.Fatalf("got %d functions, want 1", len(fms))
+	}
+	if fm := fms[0]; fm.Function.Fn != "main.worker" || fm.Goroutines != 2 || fm.Runs != 6 || fm.Migrations != 3 {
+		t.Errorf("got %+v, want 2 goroutines of main.worker with 6 runs and 3 migrations", fm)
+	}
+
+	runs := GoroutineProcessorRuns(tr, tr.Goroutines[0])
+	wantRuns := []ProcessorRun{
+		{Goroutine: 1, Processor: tr.Processors[0], Start: 0, End: 100},
+		{Goroutine: 1, Processor: tr.Processors[1], Start: 150, End: 250},
+		{Goroutine: 1, Processor: tr.Processors[0], Start: 300, End: 400},
+		{Goroutine: 1, Processor: tr.Processors[0], Start: 400, End: 450},
+	}
+	if len(runs) != len(wantRuns) {
+		t.Fatalf("got %d runs, want %d", len(runs), len(wantRuns))
+	}
+	for i, r := range runs {
+		if r != wantRuns[i] {
+			t.Errorf("run %d: got %+v, want %+v", i, r, wantRuns[i])
+		}
+	}
+}
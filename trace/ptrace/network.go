@@ -0,0 +1,158 @@
+package ptrace
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+// NetworkWait identifies a span of a goroutine that was blocked on network I/O.
+type NetworkWait struct {
+	Goroutine *Goroutine
+	// Index into Goroutine.Spans.
+	Span int
+}
+
+func (w NetworkWait) span() *Span {
+	return &w.Goroutine.Spans[w.Span]
+}
+
+// NetworkWaitGroup aggregates network waits that share either the same tags or the same stack.
+type NetworkWaitGroup struct {
+	// The tags shared by all waits in the group. Only set for groups returned in NetworkWaits.ByTags.
+	Tags SpanTags
+	// The stack shared by all waits in the group. Only set for groups returned in NetworkWaits.ByStack.
+	Stack uint32
+	Waits []NetworkWait
+
+	Total time.Duration
+	Max   time.Duration
+}
+
+func (g *NetworkWaitGroup) Avg() time.Duration {
+	if len(g.Waits) == 0 {
+		return 0
+	}
+	return g.Total / time.Duration(len(g.Waits))
+}
+
+func (g *NetworkWaitGroup) add(w NetworkWait, d time.Duration) {
+	g.Waits = append(g.Waits, w)
+	g.Total += d
+	if d > g.Max {
+		g.Max = d
+	}
+}
+
+type NetworkWaits struct {
+	// All waits, sorted by goroutine and start time.
+	All []NetworkWait
+	// Waits grouped by their combination of tags, sorted by total duration, in descending order.
+	ByTags []NetworkWaitGroup
+	// Waits grouped by the stack they blocked at, sorted by total duration, in descending order.
+	ByStack []NetworkWaitGroup
+	Total   time.Duration
+}
+
+// ComputeNetworkWaits collects all spans of goroutines blocked on network I/O and aggregates them by tags and by
+// stack.
+func ComputeNetworkWaits(tr *Trace) NetworkWaits {
+	var out NetworkWaits
+	byTags := map[SpanTags]int{}
+	byStack := map[uint32]int{}
+
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			if s.State != StateBlockedNet {
+				continue
+			}
+
+			w := NetworkWait{Goroutine: g, Span: i}
+			d := s.Duration()
+			out.All = append(out.All, w)
+			out.Total += d
+
+			idx, ok := byTags[s.Tags]
+			if !ok {
+				idx = len(out.ByTags)
+				byTags[s.Tags] = idx
+				out.ByTags = append(out.ByTags, NetworkWaitGroup{Tags: s.Tags})
+			}
+			out.ByTags[idx].add(w, d)
+
+			stk := tr.Event(s.Event).StkID
+			idx, ok = byStack[stk]
+			if !ok {
+				idx = len(out.ByStack)
+				byStack[stk] = idx
+				out.ByStack = append(out.ByStack, NetworkWaitGroup{Stack: stk})
+			}
+			out.ByStack[idx].add(w, d)
+		}
+	}
+
+	sortGroups := func(groups []NetworkWaitGroup) {
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groups[i].Total > groups[j].Total
+		})
+	}
+	sortGroups(out.ByTags)
+	sortGroups(out.ByStack)
+
+	return out
+}
+
+// NetworkWaitConcurrency computes the number of concurrent network waits over time, for all waits whose tags contain
+// all of the tags in mask. The trace is split into the specified number of buckets and each point reports the maximum
+// concurrency observed in its bucket. Consecutive buckets with the same value are merged.
+func NetworkWaitConcurrency(tr *Trace, waits []NetworkWait, mask SpanTags, buckets int) []Point {
+	type delta struct {
+		when trace.Timestamp
+		d    int
+	}
+
+	var deltas []delta
+	for _, w := range waits {
+		s := w.span()
+		if s.Tags&mask != mask {
+			continue
+		}
+		deltas = append(deltas, delta{s.Start, 1}, delta{s.End, -1})
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].when != deltas[j].when {
+			return deltas[i].when < deltas[j].when
+		}
+		// Process ends before starts so that back-to-back waits don't count as concurrent.
+		return deltas[i].d < deltas[j].d
+	})
+
+	end := tr.End()
+	width := end / trace.Timestamp(buckets)
+	if width < 1 {
+		width = 1
+	}
+
+	var out []Point
+	var cur int
+	var di int
+	for start := trace.Timestamp(0); start < end; start += width {
+		bucketEnd := start + width
+		peak := cur
+		for di < len(deltas) && deltas[di].when < bucketEnd {
+			cur += deltas[di].d
+			if cur > peak {
+				peak = cur
+			}
+			di++
+		}
+		if len(out) > 0 && out[len(out)-1].Value == uint64(peak) {
+			continue
+		}
+		out = append(out, Point{When: start, Value: uint64(peak)})
+	}
+
+	return out
+}
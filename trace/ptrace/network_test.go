@@ -0,0 +1,106 @@
+package ptrace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+func TestNetworkWaits(t *testing.T) {
+	const (
+		read = SpanTagTCP | SpanTagRead
+		dial = SpanTagTCP | SpanTagDial
+	)
+	tr := &Trace{
+		Trace: trace.Trace{Events: []trace.Event{
+			0: {Ts: 0},
+			1: {Ts: 100, StkID: 5},
+			2: {Ts: 300, StkID: 6},
+			3: {Ts: 1000},
+		}},
+	}
+	g1 := &Goroutine{ID: 1, Spans: []Span{
+		{Start: 0, End: 100, State: StateActive},
+		{Start: 100, End: 300, State: StateBlockedNet, Event: 1, Tags: read},
+		{Start: 300, End: 1000, State: StateActive},
+	}}
+	g2 := &Goroutine{ID: 2, Spans: []Span{
+		// Overlaps with g1's wait.
+		{Start: 200, End: 400, State: StateBlockedNet, Event: 1, Tags: read},
+		{Start: 400, End: 900, State: StateActive},
+		// Still waiting when the trace ends.
+		{Start: 900, End: 1000, State: StateBlockedNet, Event: 2, Tags: dial},
+	}}
+	g3 := &Goroutine{ID: 3, Spans: []Span{
+		// Begins exactly when g1's wait ends, which doesn't make the two concurrent.
+		{Start: 300, End: 350, State: StateBlockedNet, Event: 2, Tags: dial},
+	}}
+	tr.Goroutines = []*Goroutine{g1, g2, g3}
+
+	waits := ComputeNetworkWaits(tr)
+	wantAll := []NetworkWait{{g1, 1}, {g2, 0}, {g2, 2}, {g3, 0}}
+	if len(waits.All) != len(wantAll) {
+		t.Fatalf("got %d waits, want %d", len(waits.All), len(wantAll))
+	}
+	for i, w := range waits.All {
+		if w != wantAll[i] {
+			t.Errorf("wait %d: got (g%d, span %d), want (g%d, span %d)", i, w.Goroutine.ID, w.Span, wantAll[i].Goroutine.ID, wantAll[i].Span)
+		}
+	}
+	if waits.Total != 550 {
+		t.Errorf("got total %s, want 550ns", waits.Total)
+	}
+
+	type group struct {
+		tags  SpanTags
+		stack uint32
+		waits int
+		total time.Duration
+		max   time.Duration
+		avg   time.Duration
+	}
+	checkGroups := func(name string, got []NetworkWaitGroup, want []group) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d groups, want %d", name, len(got), len(want))
+			return
+		}
+		for i, g := range got {
+			w := want[i]
+			if g.Tags != w.tags || g.Stack != w.stack || len(g.Waits) != w.waits || g.Total != w.total || g.Max != w.max || g.Avg() != w.avg {
+				t.Errorf("%s: group %d: got (tags %b, stack %d, %d waits, total %s, max %s, avg %s), want (tags %b, stack %d, %d waits, total %s, max %s, avg %s)",
+					name, i, g.Tags, g.Stack, len(g.Waits), g.Total, g.Max, g.Avg(), w.tags, w.stack, w.waits, w.total, w.max, w.avg)
+			}
+		}
+	}
+	checkGroups("by tags", waits.ByTags, []group{
+		{tags: read, waits: 2, total: 400, max: 200, avg: 200},
+		{tags: dial, waits: 2, total: 150, max: 100, avg: 75},
+	})
+	checkGroups("by stack", waits.ByStack, []group{
+		{stack: 5, waits: 2, total: 400, max: 200, avg: 200},
+		{stack: 6, waits: 2, total: 150, max: 100, avg: 75},
+	})
+
+	for _, test := range []struct {
+		mask SpanTags
+		want []Point
+	}{
+		{SpanTagTCP, []Point{{0, 0}, {100, 1}, {200, 2}, {400, 1}, {500, 0}, {900, 1}}},
+		{dial, []Point{{0, 0}, {300, 1}, {400, 0}, {900, 1}}},
+		{SpanTagTLS, []Point{{0, 0}}},
+	} {
+		got := NetworkWaitConcurrency(tr, waits.All, test.mask, 10)
+		if len(got) != len(test.want) {
+			t.Errorf("mask %b: got %v, want %v", test.mask, got, test.want)
+			continue
+		}
+		for i, pt := range got {
+			if pt != test.want[i] {
+				t.Errorf("mask %b: got %v, want %v", test.mask, got, test.want)
+				break
+			}
+		}
+	}
+}
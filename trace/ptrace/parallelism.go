@@ -0,0 +1,101 @@
+package ptrace
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+// Parallelism describes how many processors were running user goroutines at the same time.
+type Parallelism struct {
+	Start trace.Timestamp
+	End   trace.Timestamp
+	// The number of processors running user goroutines. Each point marks a change.
+	Points []Point
+	// The time spent at each level of parallelism, indexed by the number of running processors.
+	Durations []time.Duration
+}
+
+// ComputeParallelism computes the number of processors simultaneously running user goroutines between start and end.
+// Spans tagged with SpanTagGC do not count as running user goroutines.
+func ComputeParallelism(tr *Trace, start, end trace.Timestamp) Parallelism {
+	type delta struct {
+		when trace.Timestamp
+		d    int
+	}
+	var deltas []delta
+	for _, p := range tr.Processors {
+		for i := range p.Spans {
+			s := &p.Spans[i]
+			if s.Tags&SpanTagGC != 0 || s.End <= start || s.Start >= end {
+				continue
+			}
+			deltas = append(deltas, delta{max(s.Start, start), 1}, delta{min(s.End, end), -1})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].when < deltas[j].when
+	})
+
+	par := Parallelism{
+		Start:     start,
+		End:       end,
+		Points:    []Point{{When: start, Value: 0}},
+		Durations: []time.Duration{0},
+	}
+	cur := 0
+	for i := 0; i < len(deltas); {
+		when := deltas[i].when
+		// Apply all changes that happen at the same time at once, so that a goroutine stopping and another one
+		// starting don't show up as a dip.
+		for ; i < len(deltas) && deltas[i].when == when; i++ {
+			cur += deltas[i].d
+		}
+		last := &par.Points[len(par.Points)-1]
+		if uint64(cur) == last.Value {
+			continue
+		}
+		par.Durations[last.Value] += time.Duration(when - last.When)
+		if when == last.When {
+			last.Value = uint64(cur)
+		} else {
+			par.Points = append(par.Points, Point{When: when, Value: uint64(cur)})
+		}
+		for len(par.Durations) <= cur {
+			par.Durations = append(par.Durations, 0)
+		}
+	}
+	last := par.Points[len(par.Points)-1]
+	par.Durations[last.Value] += time.Duration(end - last.When)
+	return par
+}
+
+// Average returns the average number of processors that were running user goroutines.
+func (par *Parallelism) Average() float64 {
+	if par.End <= par.Start {
+		return 0
+	}
+	var sum float64
+	for level, d := range par.Durations {
+		sum += float64(level) * float64(d)
+	}
+	return sum / float64(par.End-par.Start)
+}
+
+// Intervals returns the intervals during which exactly level processors were running user goroutines, as spans in the
+// StateActive state.
+func (par *Parallelism) Intervals(level int) []Span {
+	var out []Span
+	for i, pt := range par.Points {
+		if pt.Value != uint64(level) {
+			continue
+		}
+		end := par.End
+		if i+1 < len(par.Points) {
+			end = par.Points[i+1].When
+		}
+		out = append(out, Span{Start: pt.When, End: end, State: StateActive})
+	}
+	return out
+}
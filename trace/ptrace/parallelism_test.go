@@ -0,0 +1,90 @@
+package ptrace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+func TestComputeParallelism(t *testing.T) {
+	tr := &Trace{
+		Processors: []*Processor{
+			// A goroutine handing off to another one at 100 mustn't show up as a dip.
+			{ID: 0, Spans: []Span{{Start: 0, End: 100}, {Start: 100, End: 200}}},
+			// GC work doesn't count as running user goroutines.
+			{ID: 1, Spans: []Span{{Start: 50, End: 150}, {Start: 150, End: 250, Tags: SpanTagGC}}},
+			{ID: 2, Spans: []Span{{Start: 100, End: 120}}},
+		},
+	}
+
+	for _, test := range []struct {
+		start, end trace.Timestamp
+		points     []Point
+		durations  []time.Duration
+		average    float64
+	}{
+		{
+			start:     0,
+			end:       300,
+			points:    []Point{{0, 1}, {50, 2}, {100, 3}, {120, 2}, {150, 1}, {200, 0}},
+			durations: []time.Duration{100, 100, 80, 20},
+			average:   320.0 / 300.0,
+		},
+		{
+			// Spans are clipped to the range.
+			start:     60,
+			end:       130,
+			points:    []Point{{60, 2}, {100, 3}, {120, 2}, {130, 0}},
+			durations: []time.Duration{0, 0, 50, 20},
+			average:   160.0 / 70.0,
+		},
+	} {
+		par := ComputeParallelism(tr, test.start, test.end)
+		if len(par.Points) != len(test.points) {
+			t.Errorf("[%d, %d]: got points %v, want %v", test.start, test.end, par.Points, test.points)
+		} else {
+			for i, pt := range par.Points {
+				if pt != test.points[i] {
+					t.Errorf("[%d, %d]: got points %v, want %v", test.start, test.end, par.Points, test.points)
+					break
+				}
+			}
+		}
+		if len(par.Durations) != len(test.durations) {
+			t.Errorf("[%d, %d]: got durations %v, want %v", test.start, test.end, par.Durations, test.durations)
+		} else {
+			for i, d := range par.Durations {
+				if d != test.durations[i] {
+					t.Errorf("[%d, %d]: got durations %v, want %v", test.start, test.end, par.Durations, test.durations)
+					break
+				}
+			}
+		}
+		if avg := par.Average(); avg != test.average {
+			t.Errorf("[%d, %d]: got average %f, want %f", test.start, test.end, avg, test.average)
+		}
+	}
+
+	par := ComputeParallelism(tr, 0, 300)
+	for _, test := range []struct {
+		level int
+		want  []Span
+	}{
+		{0, []Span{{Start: 200, End: 300, State: StateActive}}},
+		{2, []Span{{Start: 50, End: 100, State: StateActive}, {Start: 120, End: 150, State: StateActive}}},
+		{4, nil},
+	} {
+		got := par.Intervals(test.level)
+		if len(got) != len(test.want) {
+			t.Errorf("level %d: got %d intervals, want %d", test.level, len(got), len(test.want))
+			continue
+		}
+		for i, s := range got {
+			if s.Start != test.want[i].Start || s.End != test.want[i].End || s.State != test.want[i].State {
+				t.Errorf("level %d: interval %d: got [%d, %d], want [%d, %d]",
+					test.level, i, s.Start, s.End, test.want[i].Start, test.want[i].End)
+			}
+		}
+	}
+}
@@ -80,6 +80,9 @@ type Trace struct {
 	Tasks      []*Task
 	HeapSize   []Point
 	HeapGoal   []Point
+	GOMAXPROCS []Point
+	// The number of running Ps over time.
+	RunningProcessors []Point
 	// Mapping from Goroutine ID to list of CPU sample events
 	CPUSamples map[uint64][]EventID
 
@@ -210,6 +213,12 @@ type Processor struct {
 	// goroutine is running at what time. The only benefit of reusing Span is that we can use the same code for
 	// rendering Gs and Ps, but that doesn't seem worth the added cost.
 	Spans []Span
+	// The processor's EvProcStart and EvProcStop events.
+	Events []EventID
+	// The periods during which the processor was started (StateRunningP) or stopped (StateInactive), covering the
+	// entire trace. Each span's Event is the event that started the period, except for the first span, which uses the
+	// event that ended it.
+	Lifecycle []Span
 
 	// Labels used for spans representing this processor
 	// spanLabels []string
@@ -355,6 +364,11 @@ func processEvents(res trace.Trace, tr *Trace, progress func(float64)) error {
 	eventsPerG := map[uint64]int{}
 	eventsPerP := map[int32]int{}
 	eventsPerM := map[int32]int{}
+	// Ps that were running when tracing started don't have a start event, which we only notice once we see their first
+	// stop event. We count relative to the number of such Ps, which may temporarily make runningPs negative, and
+	// correct the points once we know the initial number.
+	var runningPs, initialPs int64
+	seenPs := map[int32]struct{}{}
 	for evID := range res.Events {
 		ev := &res.Events[evID]
 		var gid uint64
@@ -368,6 +382,23 @@ func processEvents(res trace.Trace, tr *Trace, progress func(float64)) error {
 			if supportMachineTimelines {
 				eventsPerM[int32(ev.Args[0])]++
 			}
+			seenPs[ev.P] = struct{}{}
+			runningPs++
+			tr.RunningProcessors = append(tr.RunningProcessors, Point{ev.Ts, uint64(runningPs)})
+			continue
+		case trace.EvProcStop:
+			if _, ok := seenPs[ev.P]; !ok {
+				seenPs[ev.P] = struct{}{}
+				initialPs++
+			}
+			runningPs--
+			tr.RunningProcessors = append(tr.RunningProcessors, Point{ev.Ts, uint64(runningPs)})
+			continue
+		case trace.EvGomaxprocs:
+			tr.GOMAXPROCS = append(tr.GOMAXPROCS, Point{
+				ev.Ts,
+				ev.Args[trace.ArgGomaxprocsValue],
+			})
 			continue
 		case trace.EvHeapAlloc:
 			tr.HeapSize = append(tr.HeapSize, Point{
@@ -380,15 +411,21 @@ func processEvents(res trace.Trace, tr *Trace, progress func(float64)) error {
 				ev.Args[trace.ArgHeapGoalMem],
 			})
 		case trace.EvGCStart, trace.EvSTWStart, trace.EvGCDone, trace.EvSTWDone,
-			trace.EvGomaxprocs, trace.EvUserTaskCreate,
+			trace.EvUserTaskCreate,
 			trace.EvUserTaskEnd, trace.EvUserRegion, trace.EvUserLog, trace.EvCPUSample,
-			trace.EvProcStop, trace.EvGoSysCall:
+			trace.EvGoSysCall:
 			continue
 		default:
 			gid = ev.G
 		}
 		eventsPerG[gid]++
 	}
+	if initialPs > 0 {
+		for i := range tr.RunningProcessors {
+			tr.RunningProcessors[i].Value = uint64(int64(tr.RunningProcessors[i].Value) + initialPs)
+		}
+		tr.RunningProcessors = append([]Point{{0, uint64(initialPs)}}, tr.RunningProcessors...)
+	}
 	for gid, n := range eventsPerG {
 		getG(gid).Spans = make([]Span, 0, n)
 	}
@@ -509,7 +546,7 @@ func processEvents(res trace.Trace, tr *Trace, progress func(float64)) error {
 			state = evTypeToState[ev.Type]
 
 			if ev.Type == trace.EvGoBlock {
-				if blockedIsInactive(tr.gsByID[gid].Function.Fn) {
+				if BlockedIsInactive(tr.gsByID[gid].Function.Fn) {
 					state = StateInactive
 				}
 			}
@@ -517,7 +554,7 @@ func processEvents(res trace.Trace, tr *Trace, progress func(float64)) error {
 			// ev.G is blocked when tracing starts
 			gid = ev.G
 			state = StateBlocked
-			if blockedIsInactive(tr.gsByID[gid].Function.Fn) {
+			if BlockedIsInactive(tr.gsByID[gid].Function.Fn) {
 				state = StateInactive
 			}
 		case trace.EvGoUnblock:
@@ -583,6 +620,9 @@ func processEvents(res trace.Trace, tr *Trace, progress func(float64)) error {
 			}
 
 		case trace.EvProcStart:
+			if p, ok := tr.psByID[ev.P]; ok {
+				p.Events = append(p.Events, EventID(evID))
+			}
 			if supportMachineTimelines {
 				mid := ev.Args[0]
 				m := getM(int32(mid))
@@ -591,6 +631,9 @@ func processEvents(res trace.Trace, tr *Trace, progress func(float64)) error {
 			}
 			continue
 		case trace.EvProcStop:
+			if p, ok := tr.psByID[ev.P]; ok {
+				p.Events = append(p.Events, EventID(evID))
+			}
 			if supportMachineTimelines {
 				m := getM(lastMPerP[ev.P])
 				span := &m.Spans[len(m.Spans)-1]
@@ -680,7 +723,7 @@ func processEvents(res trace.Trace, tr *Trace, progress func(float64)) error {
 			continue
 
 		case trace.EvGomaxprocs:
-			// TODO(dh): graph GOMAXPROCS
+			// Already handled when counting events.
 			continue
 
 		case trace.EvUserTaskCreate:
@@ -940,6 +983,37 @@ evLoop:
 	}
 }
 
+func processorLifecycle(tr *Trace, p *Processor) []Span {
+	if len(p.Events) == 0 {
+		return nil
+	}
+	state := func(ev EventID) SchedulingState {
+		if tr.Events[ev].Type == trace.EvProcStart {
+			return StateRunningP
+		}
+		return StateInactive
+	}
+
+	out := make([]Span, 0, len(p.Events)+1)
+	first := p.Events[0]
+	if ts := tr.Events[first].Ts; ts > 0 {
+		// We don't observe the state before the first event, but it must've been the opposite.
+		prev := StateRunningP
+		if state(first) == StateRunningP {
+			prev = StateInactive
+		}
+		out = append(out, Span{Start: 0, End: ts, Event: first, State: prev})
+	}
+	for i, ev := range p.Events {
+		end := tr.End()
+		if i+1 < len(p.Events) {
+			end = tr.Events[p.Events[i+1]].Ts
+		}
+		out = append(out, Span{Start: tr.Events[ev].Ts, End: end, Event: ev, State: state(ev)})
+	}
+	return out
+}
+
 func populateObjects(tr *Trace, progress func(float64)) {
 	// Note: There is no point populating gs and ps in parallel, because ps only contains a handful of items.
 	tr.Goroutines = make([]*Goroutine, 0, len(tr.gsByID))
@@ -953,6 +1027,7 @@ func populateObjects(tr *Trace, progress func(float64)) {
 	for _, p := range tr.psByID {
 		// OPT(dh): preallocate ps
 		tr.Processors = append(tr.Processors, p)
+		p.Lifecycle = processorLifecycle(tr, p)
 	}
 	progress(2.0 / 5.0)
 
@@ -1089,10 +1164,13 @@ func (s *Span) Events(all []EventID, tr *Trace) []EventID {
 	return all[start:end]
 }
 
+// BlockedIsInactive reports whether fn is the function of a runtime background goroutine that blocks when it has no
+// work to do.
+//
 // Several background goroutines in the runtime go into a blocked state when they have no work to do. In all cases, this
 // is more similar to a goroutine calling runtime.Gosched than to a goroutine really wishing it had work to do. Because
 // of that we put those into the inactive state.
-func blockedIsInactive(fn string) bool {
+func BlockedIsInactive(fn string) bool {
 	if fn == "" {
 		return false
 	}
@@ -0,0 +1,35 @@
+package ptrace
+
+import (
+	"testing"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+func TestRunningProcessors(t *testing.T) {
+	res := trace.Trace{
+		Events: []trace.Event{
+			{Ts: 0, Type: trace.EvProcStart, P: 0},
+			// P1 is already running when tracing starts.
+			{Ts: 10, Type: trace.EvProcStop, P: 1},
+			{Ts: 20, Type: trace.EvProcStop, P: 0},
+			{Ts: 30, Type: trace.EvProcStart, P: 1},
+			{Ts: 40, Type: trace.EvProcStart, P: 2},
+			{Ts: 50, Type: trace.EvProcStop, P: 1},
+		},
+	}
+	tr, err := Parse(res, func(float64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Point{{0, 1}, {0, 2}, {10, 1}, {20, 0}, {30, 1}, {40, 2}, {50, 1}}
+	if len(tr.RunningProcessors) != len(want) {
+		t.Fatalf("got %v, want %v", tr.RunningProcessors, want)
+	}
+	for i, pt := range tr.RunningProcessors {
+		if pt != want[i] {
+			t.Fatalf("got %v, want %v", tr.RunningProcessors, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package ptrace
+
+import (
+	"sort"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+// StateGroup is a coarse classification of goroutine scheduling states.
+type StateGroup uint8
+
+const (
+	StateGroupRunning StateGroup = iota
+	StateGroupReady
+	StateGroupBlocked
+	StateGroupGC
+
+	NumStateGroups
+)
+
+func (sg StateGroup) String() string {
+	switch sg {
+	case StateGroupRunning:
+		return "Running"
+	case StateGroupReady:
+		return "Ready"
+	case StateGroupBlocked:
+		return "Blocked"
+	case StateGroupGC:
+		return "GC"
+	default:
+		return "invalid"
+	}
+}
+
+// StateGroupOf returns the group a goroutine state belongs to. It returns false for states that don't belong to any
+// group, such as inactive goroutines.
+func StateGroupOf(state SchedulingState) (StateGroup, bool) {
+	switch state {
+	case StateActive:
+		return StateGroupRunning, true
+	case StateReady, StateCreated:
+		return StateGroupReady, true
+	case StateGCIdle, StateGCDedicated, StateGCFractional, StateGCMarkAssist, StateGCSweep, StateBlockedGC:
+		return StateGroupGC, true
+	case StateStuck:
+		return StateGroupBlocked, true
+	default:
+		if IsBlocked(state) {
+			return StateGroupBlocked, true
+		}
+		return 0, false
+	}
+}
+
+// spanAt returns the index of the span of g that is active at ts, if any.
+func spanAt(g *Goroutine, ts trace.Timestamp) (int, bool) {
+	idx := sort.Search(len(g.Spans), func(i int) bool {
+		return g.Spans[i].End > ts
+	})
+	if idx == len(g.Spans) || g.Spans[idx].Start > ts {
+		return 0, false
+	}
+	return idx, true
+}
+
+// GoroutinesInStateGroup returns all goroutines whose state at ts belongs to group.
+func GoroutinesInStateGroup(tr *Trace, ts trace.Timestamp, group StateGroup) []*Goroutine {
+	var out []*Goroutine
+	for _, g := range tr.Goroutines {
+		idx, ok := spanAt(g, ts)
+		if !ok {
+			continue
+		}
+		if sg, ok := StateGroupOf(g.Spans[idx].State); ok && sg == group {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// StateGroupCounts counts the goroutines in each state group over time. The trace is split into the specified number
+// of buckets and each bucket reports the maximum number of goroutines that were in the group at any point during the
+// bucket, so that goroutines that were in a group only briefly aren't lost. All series share the same timestamps,
+// which are the buckets' starts.
+func StateGroupCounts(tr *Trace, buckets int) [NumStateGroups][]Point {
+	end := tr.End()
+	width := end / trace.Timestamp(buckets)
+	if width < 1 {
+		width = 1
+	}
+	n := int(end/width) + 1
+
+	type delta struct {
+		ts    trace.Timestamp
+		group StateGroup
+		d     int8
+	}
+	var deltas []delta
+	for _, g := range tr.Goroutines {
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			group, ok := StateGroupOf(s.State)
+			if !ok || s.End <= s.Start {
+				continue
+			}
+			deltas = append(deltas, delta{s.Start, group, 1}, delta{s.End, group, -1})
+		}
+	}
+	// At equal timestamps, process spans ending before spans starting, so that a goroutine moving between two states
+	// of the same group isn't counted twice.
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].ts != deltas[j].ts {
+			return deltas[i].ts < deltas[j].ts
+		}
+		return deltas[i].d < deltas[j].d
+	})
+
+	var out [NumStateGroups][]Point
+	for group := range out {
+		out[group] = make([]Point, n)
+	}
+	var cur [NumStateGroups]int
+	for b := 0; b < n; b++ {
+		// The maximum is at least the number of goroutines that were in the group when the bucket started.
+		peak := cur
+		bucketEnd := trace.Timestamp(b+1) * width
+		for len(deltas) > 0 && deltas[0].ts < bucketEnd {
+			d := deltas[0]
+			deltas = deltas[1:]
+			cur[d.group] += int(d.d)
+			peak[d.group] = max(peak[d.group], cur[d.group])
+		}
+		for group := range out {
+			out[group][b] = Point{When: trace.Timestamp(b) * width, Value: uint64(peak[group])}
+		}
+	}
+	return out
+}
+
+// GoroutineSpan identifies a span of a goroutine.
+type GoroutineSpan struct {
+	Goroutine *Goroutine
+	// Index into Goroutine.Spans.
+	Span int
+}
+
+// GoroutinesAt returns the current span of every goroutine that was alive at ts.
+func GoroutinesAt(tr *Trace, ts trace.Timestamp) []GoroutineSpan {
+	var out []GoroutineSpan
+	for _, g := range tr.Goroutines {
+		idx, ok := spanAt(g, ts)
+		if !ok || g.Spans[idx].State == StateDone {
+			continue
+		}
+		out = append(out, GoroutineSpan{Goroutine: g, Span: idx})
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package ptrace
+
+import (
+	"testing"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+func TestStateGroupCounts(t *testing.T) {
+	tr := &Trace{
+		Trace: trace.Trace{Events: []trace.Event{{Ts: 0}, {Ts: 1000}}},
+		Goroutines: []*Goroutine{
+			{ID: 1, Spans: []Span{
+				{Start: 0, End: 1000, State: StateActive},
+			}},
+			{ID: 2, Spans: []Span{
+				{Start: 0, End: 250, State: StateBlockedRecv},
+				// Shorter than a bucket and not covering any bucket's start.
+				{Start: 250, End: 255, State: StateReady},
+				{Start: 255, End: 1000, State: StateActive},
+			}},
+			{ID: 3, Spans: []Span{
+				// Two consecutive spans in the same group mustn't count the goroutine twice.
+				{Start: 0, End: 500, State: StateActive},
+				{Start: 500, End: 800, State: StateActive},
+				// Doesn't belong to any group.
+				{Start: 800, End: 1000, State: StateInactive},
+			}},
+		},
+	}
+
+	counts := StateGroupCounts(tr, 10)
+	want := [NumStateGroups][]uint64{
+		StateGroupRunning: {2, 2, 3, 3, 3, 3, 3, 3, 3, 2, 2},
+		StateGroupReady:   {0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0},
+		StateGroupBlocked: {1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0},
+		StateGroupGC:      {0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+	for group := StateGroup(0); group < NumStateGroups; group++ {
+		points := counts[group]
+		if len(points) != len(want[group]) {
+			t.Errorf("%s: got %d points, want %d", group, len(points), len(want[group]))
+			continue
+		}
+		for i, pt := range points {
+			if pt.When != trace.Timestamp(i*100) {
+				t.Errorf("%s: point %d: got timestamp %d, want %d", group, i, pt.When, i*100)
+			}
+			if pt.Value != want[group][i] {
+				t.Errorf("%s: bucket %d: got %d goroutines, want %d", group, i, pt.Value, want[group][i])
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package ptrace
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+// Syscall describes a single syscall made by a goroutine.
+type Syscall struct {
+	Goroutine *Goroutine
+	// The EvGoSysCall event, or the EvGoInSyscall event for syscalls that were already in progress when the trace
+	// started.
+	Event EventID
+	// Index into Goroutine.Spans of the span during which the goroutine was blocked in the syscall, or -1 if the syscall
+	// didn't block.
+	Span int
+}
+
+// Blocking reports whether the syscall blocked, and thus has a known duration.
+func (sc Syscall) Blocking() bool {
+	return sc.Span != -1
+}
+
+func (sc Syscall) Duration() time.Duration {
+	if sc.Span == -1 {
+		return 0
+	}
+	return sc.Goroutine.Spans[sc.Span].Duration()
+}
+
+// SyscallGroup aggregates syscalls that were made via the same wrapper function from the same call site.
+type SyscallGroup struct {
+	// The function that made the syscall, usually a wrapper in package syscall. Empty if the stack is unknown.
+	Function string
+	// The first frame outside the runtime and the syscall wrappers. The zero value if it is unknown.
+	CallSite trace.Frame
+
+	Syscalls []Syscall
+	// The number of syscalls that blocked.
+	Blocking int
+	// The number of distinct goroutines that made the syscalls.
+	Goroutines int
+
+	// Total, percentiles and maximum of the durations of blocking syscalls. The trace doesn't record the durations of
+	// syscalls that didn't block.
+	Total time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// syscallCallSite returns the first frame of stk that isn't part of the runtime or the syscall wrappers.
+func syscallCallSite(tr *Trace, stk []uint64) trace.Frame {
+	for _, pc := range stk {
+		f := tr.PCs[pc]
+		switch {
+		case strings.HasPrefix(f.Fn, "runtime."),
+			strings.HasPrefix(f.Fn, "syscall."),
+			strings.HasPrefix(f.Fn, "internal/"),
+			strings.HasPrefix(f.Fn, "golang.org/x/sys/"):
+			continue
+		default:
+			return f
+		}
+	}
+	return trace.Frame{}
+}
+
+// percentile returns the p-th percentile of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
+
+// ComputeSyscalls collects all syscalls, both blocking and non-blocking, and groups them by wrapper function and call
+// site. The groups are sorted by the total time spent in blocking syscalls, in descending order.
+func ComputeSyscalls(tr *Trace) []SyscallGroup {
+	type key struct {
+		fn string
+		pc uint64
+	}
+	idx := map[key]int{}
+	var groups []SyscallGroup
+
+	add := func(sc Syscall, stkID uint32) {
+		var k key
+		var site trace.Frame
+		if stk := tr.Stacks[stkID]; len(stk) != 0 {
+			site = syscallCallSite(tr, stk)
+			k = key{tr.PCs[stk[0]].Fn, site.PC}
+		}
+		i, ok := idx[k]
+		if !ok {
+			i = len(groups)
+			idx[k] = i
+			groups = append(groups, SyscallGroup{Function: k.fn, CallSite: site})
+		}
+		groups[i].Syscalls = append(groups[i].Syscalls, sc)
+	}
+
+	for _, g := range tr.Goroutines {
+		// The starts of blocking syscall spans, which have been backdated to their EvGoSysCall events.
+		blocking := map[trace.Timestamp]int{}
+		for i := range g.Spans {
+			s := &g.Spans[i]
+			if s.State != StateBlockedSyscall {
+				continue
+			}
+			ev := tr.Event(s.Event)
+			if ev.Type == trace.EvGoInSyscall {
+				add(Syscall{Goroutine: g, Event: s.Event, Span: i}, ev.StkID)
+			} else {
+				blocking[s.Start] = i
+			}
+		}
+		for _, evID := range g.Events {
+			ev := tr.Event(evID)
+			if ev.Type != trace.EvGoSysCall {
+				continue
+			}
+			span, ok := blocking[ev.Ts]
+			if !ok {
+				span = -1
+			}
+			add(Syscall{Goroutine: g, Event: evID, Span: span}, ev.StkID)
+		}
+	}
+
+	for i := range groups {
+		group := &groups[i]
+		var ds []time.Duration
+		gs := map[*Goroutine]struct{}{}
+		for _, sc := range group.Syscalls {
+			gs[sc.Goroutine] = struct{}{}
+			if !sc.Blocking() {
+				continue
+			}
+			d := sc.Duration()
+			ds = append(ds, d)
+			group.Total += d
+		}
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		group.Blocking = len(ds)
+		group.Goroutines = len(gs)
+		group.P50 = percentile(ds, 0.5)
+		group.P90 = percentile(ds, 0.9)
+		group.P99 = percentile(ds, 0.99)
+		if len(ds) != 0 {
+			group.Max = ds[len(ds)-1]
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Total != groups[j].Total {
+			return groups[i].Total > groups[j].Total
+		}
+		return len(groups[i].Syscalls) > len(groups[j].Syscalls)
+	})
+	return groups
+}
@@ -0,0 +1,120 @@
+package ptrace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joonho3020/gotraceui/trace"
+)
+
+func TestComputeSyscalls(t *testing.T) {
+	const (
+		stkRead  = 1
+		stkWrite = 2
+	)
+	res := trace.Trace{
+		Stacks: map[uint32][]uint64{
+			stkRead:  {0x10, 0x11, 0x20},
+			stkWrite: {0x10, 0x30},
+		},
+		PCs: map[uint64]trace.Frame{
+			0x10: {PC: 0x10, Fn: "syscall.Syscall"},
+			0x11: {PC: 0x11, Fn: "syscall.read"},
+			0x20: {PC: 0x20, Fn: "main.readLoop"},
+			0x30: {PC: 0x30, Fn: "main.write"},
+		},
+		Events: []trace.Event{
+			0: {Ts: 0, Type: trace.EvProcStart, P: 0},
+			1: {Ts: 1, Type: trace.EvGoCreate, Args: [4]uint64{trace.ArgGoCreateG: 1}},
+			2: {Ts: 2, Type: trace.EvGoCreate, Args: [4]uint64{trace.ArgGoCreateG: 2}},
+			// g2 is already in a syscall when tracing starts.
+			3: {Ts: 3, Type: trace.EvGoInSyscall, G: 2},
+			4: {Ts: 10, Type: trace.EvGoStart, G: 1, P: 0},
+			// A syscall that doesn't block.
+			5: {Ts: 20, Type: trace.EvGoSysCall, G: 1, P: 0, StkID: stkRead},
+			// A syscall that blocks. The runtime only notices at 50 and emits EvGoSysBlock then, but the span gets
+			// backdated to the EvGoSysCall.
+			6:  {Ts: 30, Type: trace.EvGoSysCall, G: 1, P: 0, StkID: stkRead},
+			7:  {Ts: 50, Type: trace.EvGoSysBlock, G: 1, P: 0},
+			8:  {Ts: 51, Type: trace.EvProcStop, P: 0},
+			9:  {Ts: 100, Type: trace.EvGoSysExit, G: 1},
+			10: {Ts: 105, Type: trace.EvGoSysExit, G: 2},
+			11: {Ts: 110, Type: trace.EvProcStart, P: 0},
+			12: {Ts: 110, Type: trace.EvGoStart, G: 1, P: 0},
+			13: {Ts: 120, Type: trace.EvGoSysCall, G: 1, P: 0, StkID: stkWrite},
+			14: {Ts: 130, Type: trace.EvGoEnd, G: 1, P: 0},
+			15: {Ts: 140, Type: trace.EvGoStart, G: 2, P: 0},
+			16: {Ts: 150, Type: trace.EvGoSysCall, G: 2, P: 0, StkID: stkRead},
+			17: {Ts: 200, Type: trace.EvGoSysBlock, G: 2, P: 0},
+			18: {Ts: 300, Type: trace.EvGoSysExit, G: 2},
+		},
+	}
+	tr, err := Parse(res, func(float64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g1, g2 := tr.G(1), tr.G(2)
+	if s := g1.Spans[2]; s.State != StateBlockedSyscall || s.Start != 30 || g1.Spans[1].End != 30 {
+		t.Fatalf("blocking syscall span wasn't backdated: got spans %v", g1.Spans)
+	}
+
+	groups := ComputeSyscalls(tr)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
+	}
+
+	type syscall struct {
+		g     *Goroutine
+		event EventID
+		span  int
+	}
+	for i, test := range []struct {
+		fn       string
+		callSite string
+		syscalls []syscall
+		blocking int
+		total    time.Duration
+		max      time.Duration
+	}{
+		{
+			fn:       "syscall.Syscall",
+			callSite: "main.readLoop",
+			syscalls: []syscall{{g1, 5, -1}, {g1, 6, 2}, {g2, 16, 4}},
+			blocking: 2,
+			total:    70 + 150,
+			max:      150,
+		},
+		{
+			// Without a stack, the syscall that was in progress when tracing started can't be attributed.
+			syscalls: []syscall{{g2, 3, 1}},
+			blocking: 1,
+			total:    102,
+			max:      102,
+		},
+		{
+			fn:       "syscall.Syscall",
+			callSite: "main.write",
+			syscalls: []syscall{{g1, 13, -1}},
+		},
+	} {
+		group := &groups[i]
+		if group.Function != test.fn || group.CallSite.Fn != test.callSite {
+			t.Errorf("group %d: got (%q, %q), want (%q, %q)", i, group.Function, group.CallSite.Fn, test.fn, test.callSite)
+		}
+		if group.Blocking != test.blocking || group.Total != test.total || group.Max != test.max {
+			t.Errorf("group %d: got %d blocking syscalls totalling %s, max %s, want %d totalling %s, max %s",
+				i, group.Blocking, group.Total, group.Max, test.blocking, test.total, test.max)
+		}
+		if len(group.Syscalls) != len(test.syscalls) {
+			t.Errorf("group %d: got %d syscalls, want %d", i, len(group.Syscalls), len(test.syscalls))
+			continue
+		}
+		for j, sc := range group.Syscalls {
+			want := test.syscalls[j]
+			if sc.Goroutine != want.g || sc.Event != want.event || sc.Span != want.span {
+				t.Errorf("group %d: syscall %d: got (g%d, event %d, span %d), want (g%d, event %d, span %d)",
+					i, j, sc.Goroutine.ID, sc.Event, sc.Span, want.g.ID, want.event, want.span)
+			}
+		}
+	}
+}